@@ -14,16 +14,197 @@ func Connect(dsn string) *gorm.DB {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
+	migrateLegacySchema(db)
+
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Session{},
 		&models.VerificationToken{},
+		&models.PasswordResetToken{},
+		&models.TOTPCredential{},
+		&models.RecoveryCode{},
+		&models.MFAChallenge{},
+		&models.AuthThrottle{},
+		&models.AuditEvent{},
+		&models.APIKey{},
+		&models.IdentityProvider{},
+		&models.IdentityProviderDomain{},
+		&models.UserIdentity{},
+		&models.SSOLoginState{},
+		&models.Passkey{},
+		&models.WebAuthnChallenge{},
+		&models.Organization{},
+		&models.OrganizationMembership{},
+		&models.OrganizationInvitation{},
+		&models.Firm{},
+		&models.FirmMembership{},
+		&models.FirmInvitation{},
+		&models.LawyerProfile{},
 		&models.Case{},
 		&models.CaseAssignment{},
+		&models.CaseFirmAssignment{},
+		&models.CaseCollaborator{},
+		&models.CaseInvitation{},
 		&models.CaseDocument{},
+		&models.CaseStatusChange{},
 	); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
 
+	ensureSearchIndexes(db)
+
 	return db
 }
+
+// ensureSearchIndexes creates the GIN indexes behind case and document
+// search. They index the search vector expressions rather than stored
+// columns, so they stay current without triggers.
+func ensureSearchIndexes(db *gorm.DB) {
+	indexes := map[string]string{
+		"idx_cases_search":          "CREATE INDEX IF NOT EXISTS idx_cases_search ON cases USING GIN (" + models.CaseSearchVector + ")",
+		"idx_case_documents_search": "CREATE INDEX IF NOT EXISTS idx_case_documents_search ON case_documents USING GIN (" + models.DocumentSearchVector + ")",
+	}
+	for name, statement := range indexes {
+		if err := db.Exec(statement).Error; err != nil {
+			log.Fatalf("failed to create search index %s: %v", name, err)
+		}
+	}
+}
+
+// migrateLegacySchema reshapes tables that AutoMigrate cannot change on its own.
+func migrateLegacySchema(db *gorm.DB) {
+	migrator := db.Migrator()
+
+	// Sessions used to store the raw bearer token. Those rows cannot be
+	// converted to keyed hashes, so they are discarded and users sign in again.
+	if migrator.HasTable(&models.Session{}) && migrator.HasColumn(&models.Session{}, "token") {
+		if err := db.Exec("DELETE FROM sessions").Error; err != nil {
+			log.Fatalf("failed to clear legacy sessions: %v", err)
+		}
+		if err := migrator.DropColumn(&models.Session{}, "token"); err != nil {
+			log.Fatalf("failed to drop legacy session token column: %v", err)
+		}
+	}
+
+	// Cases used to belong to the user who created them. Every client gets a
+	// personal organization that takes over their cases, so the column can be
+	// made NOT NULL by AutoMigrate afterwards.
+	if migrator.HasTable(&models.Case{}) && !migrator.HasColumn(&models.Case{}, "organization_id") {
+		if err := db.AutoMigrate(&models.Organization{}, &models.OrganizationMembership{}); err != nil {
+			log.Fatalf("failed to create organization tables: %v", err)
+		}
+		if err := db.Exec("ALTER TABLE cases ADD COLUMN organization_id uuid").Error; err != nil {
+			log.Fatalf("failed to add case organization column: %v", err)
+		}
+		if err := backfillOrganizations(db); err != nil {
+			log.Fatalf("failed to move cases into organizations: %v", err)
+		}
+	}
+
+	// Assignments used to take effect immediately. Existing ones count as
+	// accepted so their lawyers keep access.
+	if migrator.HasTable(&models.CaseAssignment{}) && !migrator.HasColumn(&models.CaseAssignment{}, "status") {
+		if err := db.Exec("ALTER TABLE case_assignments ADD COLUMN status varchar(16) NOT NULL DEFAULT 'accepted', ADD COLUMN accepted_at timestamptz").Error; err != nil {
+			log.Fatalf("failed to add assignment status columns: %v", err)
+		}
+		if err := db.Exec("UPDATE case_assignments SET accepted_at = created_at").Error; err != nil {
+			log.Fatalf("failed to backfill assignment acceptance: %v", err)
+		}
+	}
+
+	// Firm engagements used to take effect immediately. Existing ones count
+	// as accepted so the firms' admins keep access.
+	if migrator.HasTable(&models.CaseFirmAssignment{}) && !migrator.HasColumn(&models.CaseFirmAssignment{}, "status") {
+		if err := db.Exec("ALTER TABLE case_firm_assignments ADD COLUMN status varchar(16) NOT NULL DEFAULT 'accepted', ADD COLUMN accepted_at timestamptz").Error; err != nil {
+			log.Fatalf("failed to add firm engagement status columns: %v", err)
+		}
+		if err := db.Exec("UPDATE case_firm_assignments SET accepted_at = created_at").Error; err != nil {
+			log.Fatalf("failed to backfill firm engagement acceptance: %v", err)
+		}
+	}
+
+	// Identity providers used to be configured globally by administrators.
+	// Each one is handed to the organization most of its linked accounts
+	// belong to; providers nobody has signed in with cannot be attributed and
+	// are removed, to be configured again by the organization that owns them.
+	if migrator.HasTable(&models.IdentityProvider{}) && !migrator.HasColumn(&models.IdentityProvider{}, "organization_id") {
+		if err := db.Exec("ALTER TABLE identity_providers ADD COLUMN organization_id uuid").Error; err != nil {
+			log.Fatalf("failed to add identity provider organization column: %v", err)
+		}
+		if err := db.Exec(`UPDATE identity_providers SET organization_id = (
+			SELECT m.organization_id FROM user_identities i
+			JOIN organization_memberships m ON m.user_id = i.user_id
+			WHERE i.provider_id = identity_providers.id
+			GROUP BY m.organization_id ORDER BY count(*) DESC, m.organization_id LIMIT 1)`).Error; err != nil {
+			log.Fatalf("failed to assign identity providers to organizations: %v", err)
+		}
+		result := db.Exec("DELETE FROM identity_providers WHERE organization_id IS NULL")
+		if result.Error != nil {
+			log.Fatalf("failed to remove unattributed identity providers: %v", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("removed %d identity providers that belong to no organization", result.RowsAffected)
+		}
+	}
+
+	// Identity providers used to list their email domains without proof of
+	// ownership. The claims move to their own table unverified, so each has
+	// to be verified again before the provider can sign anyone in with it.
+	if migrator.HasTable(&models.IdentityProvider{}) && migrator.HasColumn(&models.IdentityProvider{}, "email_domains") {
+		if !migrator.HasTable(&models.IdentityProviderDomain{}) {
+			if err := migrator.CreateTable(&models.IdentityProviderDomain{}); err != nil {
+				log.Fatalf("failed to create identity provider domains: %v", err)
+			}
+		}
+		result := db.Exec(`INSERT INTO identity_provider_domains (id, provider_id, domain, verification_token, created_at, updated_at)
+			SELECT gen_random_uuid(), p.id, d.domain, md5(random()::text || clock_timestamp()::text), now(), now()
+			FROM identity_providers p
+			CROSS JOIN LATERAL (SELECT DISTINCT domain FROM regexp_split_to_table(p.email_domains, '\s+') AS domain WHERE domain <> '') d`)
+		if result.Error != nil {
+			log.Fatalf("failed to move identity provider domains: %v", result.Error)
+		}
+		if err := db.Exec("ALTER TABLE identity_providers DROP COLUMN email_domains").Error; err != nil {
+			log.Fatalf("failed to drop identity provider email domains column: %v", err)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("%d identity provider email domains need to be verified again", result.RowsAffected)
+		}
+	}
+
+	// Documents used to be visible to everyone on a case. Personal documents
+	// become restricted to the client's organization and lead counsel.
+	if migrator.HasTable(&models.CaseDocument{}) && !migrator.HasColumn(&models.CaseDocument{}, "visibility") {
+		if err := db.Exec("ALTER TABLE case_documents ADD COLUMN visibility varchar(16) NOT NULL DEFAULT 'shared'").Error; err != nil {
+			log.Fatalf("failed to add document visibility column: %v", err)
+		}
+		if err := db.Exec("UPDATE case_documents SET visibility = ? WHERE category = ?", models.DocumentVisibilityRestricted, "personal").Error; err != nil {
+			log.Fatalf("failed to restrict personal documents: %v", err)
+		}
+	}
+}
+
+func backfillOrganizations(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Where("role = ? OR id IN (SELECT user_id FROM cases)", models.UserRoleClient).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			org := models.Organization{Name: user.CompanyName, CreatedByID: user.ID}
+			if err := tx.Create(&org).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.OrganizationMembership{
+				OrganizationID: org.ID,
+				UserID:         user.ID,
+				Role:           models.OrgRoleOwner,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE cases SET organization_id = ? WHERE user_id = ?", org.ID, user.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	adminUserPageSize           = 50
+	adminUserMaxPageSize        = 200
+	auditActionAdminApprove     = "admin.user_approve"
+	auditActionAdminReject      = "admin.user_reject"
+	auditActionAdminSuspend     = "admin.user_suspend"
+	auditActionAdminReactivate  = "admin.user_reactivate"
+	auditActionAdminForceVerify = "admin.user_verify"
+)
+
+type adminReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type adminUserResponse struct {
+	userResponse
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty"`
+	SuspensionReason string     `json:"suspensionReason,omitempty"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// HandleAdminListUsers searches accounts. Filters: q (email or company name),
+// role, approvalStatus and suspended=true|false; limit and offset page the
+// results.
+func (h *AuthHandler) HandleAdminListUsers(ctx *gin.Context) {
+	query := h.db.Model(&models.User{})
+
+	if q := strings.TrimSpace(ctx.Query("q")); q != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q)) + "%"
+		query = query.Where("LOWER(email) LIKE ? OR LOWER(company_name) LIKE ?", pattern, pattern)
+	}
+	if role := strings.TrimSpace(ctx.Query("role")); role != "" {
+		query = query.Where("role = ?", strings.ToLower(role))
+	}
+	if status := strings.TrimSpace(ctx.Query("approvalStatus")); status != "" {
+		query = query.Where("approval_status = ?", strings.ToLower(status))
+	}
+	switch ctx.Query("suspended") {
+	case "true":
+		query = query.Where("suspended_at IS NOT NULL")
+	case "false":
+		query = query.Where("suspended_at IS NULL")
+	}
+
+	limit, offset, ok := parsePage(ctx, adminUserPageSize, adminUserMaxPageSize)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch users"})
+		return
+	}
+
+	var users []models.User
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch users"})
+		return
+	}
+
+	payload := make([]adminUserResponse, 0, len(users))
+	for i := range users {
+		payload = append(payload, toAdminUserResponse(&users[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"users": payload, "total": total})
+}
+
+// parsePage reads the limit and offset query parameters, answering 400 when
+// they are out of range.
+func parsePage(ctx *gin.Context, defaultLimit, maxLimit int) (int, int, bool) {
+	limit, ok := parseLimit(ctx, defaultLimit, maxLimit)
+	if !ok {
+		return 0, 0, false
+	}
+	offset := 0
+	if raw := ctx.Query("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+	return limit, offset, true
+}
+
+// parseLimit reads the limit query parameter, answering 400 when it is out of
+// range.
+func parseLimit(ctx *gin.Context, defaultLimit, maxLimit int) (int, bool) {
+	raw := ctx.Query("limit")
+	if raw == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxLimit)})
+		return 0, false
+	}
+	return limit, true
+}
+
+func (h *AuthHandler) HandleAdminGetUser(ctx *gin.Context) {
+	target, ok := h.loadAdminTarget(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"user": toAdminUserResponse(target)})
+}
+
+// HandleAdminApproveLawyer clears a lawyer for case assignments.
+func (h *AuthHandler) HandleAdminApproveLawyer(ctx *gin.Context) {
+	h.setLawyerApproval(ctx, models.ApprovalApproved, auditActionAdminApprove)
+}
+
+// HandleAdminRejectLawyer keeps a lawyer from being assigned and takes them
+// off the cases they are assigned to. The account can still sign in and may
+// be approved later, but has to be assigned again.
+func (h *AuthHandler) HandleAdminRejectLawyer(ctx *gin.Context) {
+	h.setLawyerApproval(ctx, models.ApprovalRejected, auditActionAdminReject)
+}
+
+// HandleAdminSuspendUser blocks an account from signing in and revokes its
+// sessions and pending sign-ins. API keys stop working while it is suspended.
+func (h *AuthHandler) HandleAdminSuspendUser(ctx *gin.Context) {
+	admin := currentUser(ctx)
+	target, ok := h.loadAdminTarget(ctx)
+	if !ok {
+		return
+	}
+	if target.ID == admin.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend your own account"})
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted.
+	var req adminReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suspension payload"})
+		return
+	}
+	reason := truncateString(strings.TrimSpace(req.Reason), 512)
+
+	now := time.Now().UTC()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(target).Updates(map[string]interface{}{
+			"suspended_at":      now,
+			"suspension_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", target.ID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", target.ID).Delete(&models.MFAChallenge{}).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to suspend user"})
+		return
+	}
+	target.SuspendedAt = &now
+	target.SuspensionReason = reason
+
+	recordAudit(h.db, ctx, auditActionAdminSuspend, &admin.ID, &target.ID, map[string]any{"reason": reason})
+	ctx.JSON(http.StatusOK, gin.H{"user": toAdminUserResponse(target)})
+}
+
+func (h *AuthHandler) HandleAdminReactivateUser(ctx *gin.Context) {
+	admin := currentUser(ctx)
+	target, ok := h.loadAdminTarget(ctx)
+	if !ok {
+		return
+	}
+
+	if target.Suspended() {
+		if err := h.db.Model(target).Updates(map[string]interface{}{
+			"suspended_at":      nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to reactivate user"})
+			return
+		}
+		target.SuspendedAt = nil
+		target.SuspensionReason = ""
+		recordAudit(h.db, ctx, auditActionAdminReactivate, &admin.ID, &target.ID, nil)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": toAdminUserResponse(target)})
+}
+
+// HandleAdminVerifyUser marks an account's email as verified without a code,
+// for users who cannot receive the verification email.
+func (h *AuthHandler) HandleAdminVerifyUser(ctx *gin.Context) {
+	admin := currentUser(ctx)
+	target, ok := h.loadAdminTarget(ctx)
+	if !ok {
+		return
+	}
+
+	if !target.Verified {
+		if err := h.db.Model(target).Update("verified", true).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to verify user"})
+			return
+		}
+		target.Verified = true
+		_ = h.db.Where("user_id = ?", target.ID).Delete(&models.VerificationToken{}).Error
+		recordAudit(h.db, ctx, auditActionAdminForceVerify, &admin.ID, &target.ID, nil)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": toAdminUserResponse(target)})
+}
+
+// HandleAdminStats reports platform-wide counts for the admin dashboard.
+func (h *AuthHandler) HandleAdminStats(ctx *gin.Context) {
+	type grouped struct {
+		Key   string
+		Count int64
+	}
+	countBy := func(model any, column string) (map[string]int64, error) {
+		var rows []grouped
+		if err := h.db.Model(model).Select(column + " AS key, COUNT(*) AS count").Group(column).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			counts[row.Key] = row.Count
+		}
+		return counts, nil
+	}
+
+	now := time.Now().UTC()
+	usersByRole, err := countBy(&models.User{}, "role")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to compute stats"})
+		return
+	}
+	casesByStatus, err := countBy(&models.Case{}, "status")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to compute stats"})
+		return
+	}
+
+	counts := map[string]*gorm.DB{
+		"pendingLawyers":    h.db.Model(&models.User{}).Where("role = ? AND approval_status = ?", models.UserRoleLawyer, models.ApprovalPending),
+		"suspendedUsers":    h.db.Model(&models.User{}).Where("suspended_at IS NOT NULL"),
+		"unverifiedUsers":   h.db.Model(&models.User{}).Where("verified = ?", false),
+		"newUsersLast7Days": h.db.Model(&models.User{}).Where("created_at > ?", now.Add(-7*24*time.Hour)),
+		"organizations":     h.db.Model(&models.Organization{}),
+		"firms":             h.db.Model(&models.Firm{}),
+		"cases":             h.db.Model(&models.Case{}),
+		"documents":         h.db.Model(&models.CaseDocument{}),
+		"assignments":       h.db.Model(&models.CaseAssignment{}),
+		"activeSessions":    h.db.Model(&models.Session{}).Where("expires_at > ?", now),
+		"activeAPIKeys":     h.db.Model(&models.APIKey{}).Where("revoked_at IS NULL AND expires_at > ?", now),
+	}
+	stats := gin.H{"usersByRole": usersByRole, "casesByStatus": casesByStatus}
+	for name, query := range counts {
+		var n int64
+		if err := query.Count(&n).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to compute stats"})
+			return
+		}
+		stats[name] = n
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+func (h *AuthHandler) setLawyerApproval(ctx *gin.Context, status, action string) {
+	admin := currentUser(ctx)
+	target, ok := h.loadAdminTarget(ctx)
+	if !ok {
+		return
+	}
+	if target.Role != models.UserRoleLawyer {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Only lawyer accounts require approval"})
+		return
+	}
+
+	var req adminReasonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid approval payload"})
+		return
+	}
+
+	if target.ApprovalStatus != status {
+		previous := target.ApprovalStatus
+		reason := truncateString(strings.TrimSpace(req.Reason), 512)
+		removalReason := defaultString(reason, "No longer approved for case assignments")
+
+		// A rejected lawyer is taken off every case they are on.
+		var ended []models.CaseAssignment
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(target).Update("approval_status", status).Error; err != nil {
+				return err
+			}
+			if status != models.ApprovalRejected {
+				return nil
+			}
+			var assignments []models.CaseAssignment
+			if err := tx.Where("lawyer_id = ? AND status IN ?", target.ID, models.ActiveAssignmentStatuses).
+				Find(&assignments).Error; err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			for i := range assignments {
+				removed, err := endAssignment(tx, &assignments[i], admin.ID, removalReason, now)
+				if err != nil {
+					return err
+				}
+				if removed {
+					ended = append(ended, assignments[i])
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update approval"})
+			return
+		}
+		target.ApprovalStatus = status
+		recordAudit(h.db, ctx, action, &admin.ID, &target.ID, map[string]any{
+			"from":   previous,
+			"reason": reason,
+		})
+		for i := range ended {
+			announceAssignmentRemoval(ctx, h.db, h.mailer, &ended[i], admin, removalReason)
+		}
+		redeemPendingCaseInvitations(ctx, h.db, target)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": toAdminUserResponse(target)})
+}
+
+func (h *AuthHandler) loadAdminTarget(ctx *gin.Context) (*models.User, bool) {
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return nil, false
+	}
+
+	var target models.User
+	if err := h.db.First(&target, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch user"})
+		return nil, false
+	}
+
+	return &target, true
+}
+
+func toAdminUserResponse(user *models.User) adminUserResponse {
+	return adminUserResponse{
+		userResponse:     toUserResponse(user),
+		SuspendedAt:      user.SuspendedAt,
+		SuspensionReason: user.SuspensionReason,
+		UpdatedAt:        user.UpdatedAt,
+	}
+}
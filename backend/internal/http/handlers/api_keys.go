@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	apiKeyTokenPrefix       = "lxf_"
+	apiKeyDisplayPrefixLen  = 12
+	apiKeyPurpose           = "api-key"
+	apiKeyDefaultTTL        = 90 * 24 * time.Hour
+	apiKeyMaxTTL            = 365 * 24 * time.Hour
+	apiKeyUsageWriteGap     = time.Minute
+	auditActionAPIKeyCreate = "api_key.create"
+	auditActionAPIKeyRevoke = "api_key.revoke"
+)
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays"`
+}
+
+type apiKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (h *AuthHandler) HandleListAPIKeys(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var keys []models.APIKey
+	if err := h.db.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch API keys"})
+		return
+	}
+
+	payload := make([]apiKeyResponse, 0, len(keys))
+	for i := range keys {
+		payload = append(payload, toAPIKeyResponse(&keys[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"apiKeys": payload})
+}
+
+func (h *AuthHandler) HandleCreateAPIKey(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key payload"})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	scopes, ok := normaliseScopes(req.Scopes)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":           "Unsupported scope",
+			"supportedScopes": models.APIKeyScopes,
+		})
+		return
+	}
+
+	ttl := apiKeyDefaultTTL
+	if req.ExpiresInDays != 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	if ttl <= 0 || ttl > apiKeyMaxTTL {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays must be between 1 and 365"})
+		return
+	}
+
+	secret, err := generateSessionToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create API key"})
+		return
+	}
+	token := apiKeyTokenPrefix + secret
+
+	key := models.APIKey{
+		UserID:    user.ID,
+		Name:      truncateString(name, 255),
+		Prefix:    token[:apiKeyDisplayPrefixLen],
+		TokenHash: h.hashAPIKey(token),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+	if err := h.db.Create(&key).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create API key"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionAPIKeyCreate, &user.ID, &user.ID, map[string]any{
+		"apiKeyId": key.ID.String(),
+		"scopes":   scopes,
+	})
+
+	// The raw token is returned exactly once; only its hash is kept.
+	ctx.JSON(http.StatusCreated, gin.H{
+		"apiKey": toAPIKeyResponse(&key),
+		"token":  token,
+	})
+}
+
+func (h *AuthHandler) HandleRevokeAPIKey(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	keyID, err := uuid.Parse(ctx.Param("keyId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key id"})
+		return
+	}
+
+	var key models.APIKey
+	if err := h.db.Where("id = ? AND user_id = ?", keyID, user.ID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch API key"})
+		return
+	}
+
+	if key.RevokedAt == nil {
+		now := time.Now().UTC()
+		if err := h.db.Model(&key).Update("revoked_at", now).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke API key"})
+			return
+		}
+		key.RevokedAt = &now
+		recordAudit(h.db, ctx, auditActionAPIKeyRevoke, &user.ID, &user.ID, map[string]any{
+			"apiKeyId": key.ID.String(),
+		})
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// requireAPIKey resolves a bearer API key, rejecting unknown, revoked and
+// expired keys. Usage timestamps are refreshed at most once a minute.
+func (h *AuthHandler) requireAPIKey(ctx *gin.Context, token string) (*models.APIKey, bool) {
+	var key models.APIKey
+	if err := h.db.Preload("User").Where("token_hash = ?", h.hashAPIKey(token)).First(&key).Error; err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return nil, false
+	}
+
+	now := time.Now().UTC()
+	if key.RevokedAt != nil || now.After(key.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "API key expired or revoked"})
+		return nil, false
+	}
+	if key.User.Suspended() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return nil, false
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageWriteGap {
+		ip := truncateString(ctx.ClientIP(), 64)
+		if err := h.db.Model(&key).Updates(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error; err == nil {
+			key.LastUsedAt = &now
+			key.LastUsedIP = ip
+		}
+	}
+
+	return &key, true
+}
+
+func (h *AuthHandler) hashAPIKey(token string) string {
+	mac := hmac.New(sha256.New, h.keyring.Key(apiKeyPurpose))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isAPIKeyToken(token string) bool {
+	return strings.HasPrefix(token, apiKeyTokenPrefix)
+}
+
+func normaliseScopes(requested []string) ([]string, bool) {
+	seen := map[string]bool{}
+	scopes := make([]string, 0, len(requested))
+	for _, raw := range requested {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		supported := false
+		for _, candidate := range models.APIKeyScopes {
+			if candidate == scope {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, len(scopes) > 0
+}
+
+func toAPIKeyResponse(key *models.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
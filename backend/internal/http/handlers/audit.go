@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	auditActionLockout = "auth.lockout"
+)
+
+// recordAudit appends an audit event. Failures are logged rather than
+// surfaced: auditing must never block the request that triggered it.
+func recordAudit(db *gorm.DB, ctx *gin.Context, action string, actorID, subjectID *uuid.UUID, details map[string]any) {
+	event := models.AuditEvent{
+		Action:    action,
+		ActorID:   actorID,
+		SubjectID: subjectID,
+	}
+	if ctx != nil {
+		event.IP = truncateString(ctx.ClientIP(), 64)
+		event.UserAgent = truncateString(ctx.GetHeader("User-Agent"), 255)
+	}
+	if len(details) > 0 {
+		event.Details = datatypes.JSONMap(details)
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("audit: unable to record %s: %v", action, err)
+	}
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"math/big"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,18 +20,35 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"lexiflow/backend/internal/config"
+	"lexiflow/backend/internal/mailer"
 	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/oidc"
+	"lexiflow/backend/internal/secrets"
+	"lexiflow/backend/internal/throttle"
+	"lexiflow/backend/internal/webauthn"
 )
 
 type AuthHandler struct {
-	db *gorm.DB
+	db               *gorm.DB
+	mailer           mailer.Mailer
+	keyring          *secrets.Keyring
+	limiter          *throttle.Limiter
+	oidc             *oidc.Client
+	webauthn         *webauthn.RelyingParty
+	appBaseURL       string
+	apiBaseURL       string
+	mfaRequiredRoles []string
+	sessions         config.SessionConfig
+	// lookupTXT resolves the TXT records that prove control of an email
+	// domain claimed for single sign-on.
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
 }
 
 const (
-	sessionCookieName       = "lexiflow_session"
-	sessionInactivityWindow = 10 * time.Minute
-	sessionAbsoluteTTL      = 24 * time.Hour
-	verificationCodeTTL     = 15 * time.Minute
+	sessionCookieName   = "lexiflow_session"
+	sessionTokenPurpose = "session-token"
+	verificationCodeTTL = 15 * time.Minute
 )
 
 type registerRequest struct {
@@ -36,8 +60,9 @@ type registerRequest struct {
 }
 
 type loginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"rememberMe"`
 }
 
 type verifyRequest struct {
@@ -54,45 +79,50 @@ type resendVerificationRequest struct {
 }
 
 type userResponse struct {
-	ID           uuid.UUID `json:"id"`
-	CompanyName  string    `json:"companyName"`
-	Email        string    `json:"email"`
-	Verified     bool      `json:"verified"`
-	Subscription string    `json:"subscription"`
-	Role         string    `json:"role"`
-	CreatedAt    string    `json:"createdAt"`
-	Role         string    `json:"role"`
+	ID             uuid.UUID `json:"id"`
+	CompanyName    string    `json:"companyName"`
+	Email          string    `json:"email"`
+	Verified       bool      `json:"verified"`
+	Subscription   string    `json:"subscription"`
+	Role           string    `json:"role"`
+	ApprovalStatus string    `json:"approvalStatus"`
+	MFAEnabled     bool      `json:"mfaEnabled"`
+	CreatedAt      string    `json:"createdAt"`
 }
 
 type authSuccessResponse struct {
 	User             userResponse `json:"user"`
 	SessionExpiresAt string       `json:"sessionExpiresAt"`
 	SessionToken     string       `json:"sessionToken,omitempty"`
+	CSRFToken        string       `json:"csrfToken,omitempty"`
 }
 
 type registerSuccessResponse struct {
 	User             userResponse `json:"user"`
-	VerificationCode string       `json:"verificationCode,omitempty"`
+	VerificationSent bool         `json:"verificationSent"`
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
-}
-
-func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
-	auth := router.Group("/auth")
-	{
-		auth.POST("/register", h.handleRegister)
-		auth.POST("/login", h.handleLogin)
-		auth.POST("/verify-email", h.handleVerifyEmail)
-		auth.POST("/verify-email/resend", h.handleResendVerification)
-		auth.POST("/subscription", h.handleUpdateSubscription)
-		auth.GET("/me", h.handleCurrentUser)
-		auth.POST("/logout", h.handleLogout)
+func NewAuthHandler(db *gorm.DB, cfg config.Config, mail mailer.Mailer) *AuthHandler {
+	return &AuthHandler{
+		db:      db,
+		mailer:  mail,
+		keyring: secrets.NewKeyring(cfg.AppSecret),
+		limiter: throttle.New(db),
+		oidc:    oidc.NewClient(nil),
+		webauthn: webauthn.New(webauthn.Config{
+			RPID:    cfg.WebAuthn.RPID,
+			RPName:  cfg.WebAuthn.RPName,
+			Origins: cfg.WebAuthn.Origins,
+		}),
+		appBaseURL:       cfg.AppBaseURL,
+		apiBaseURL:       cfg.APIBaseURL,
+		mfaRequiredRoles: cfg.MFARequiredRoles,
+		sessions:         cfg.Session,
+		lookupTXT:        net.DefaultResolver.LookupTXT,
 	}
 }
 
-func (h *AuthHandler) handleRegister(ctx *gin.Context) {
+func (h *AuthHandler) HandleRegister(ctx *gin.Context) {
 	var req registerRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration payload"})
@@ -114,7 +144,7 @@ func (h *AuthHandler) handleRegister(ctx *gin.Context) {
 
 	subscription := req.Plan
 	if subscription == "" {
-		subscription = "starter"
+		subscription = models.PlanStarter
 	}
 
 	role := strings.TrimSpace(strings.ToLower(req.Role))
@@ -133,25 +163,41 @@ func (h *AuthHandler) handleRegister(ctx *gin.Context) {
 		Subscription: subscription,
 		Role:         role,
 	}
+	// Self-registered lawyers wait for an administrator to vet them.
+	if role == models.UserRoleLawyer {
+		user.ApprovalStatus = models.ApprovalPending
+	}
 
-	if err := h.db.Create(&user).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// Clients start with a personal organization they can invite
+		// colleagues into.
+		if user.Role == models.UserRoleClient {
+			_, err := createOrganization(tx, &user, user.CompanyName)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to persist account"})
 		return
 	}
 
-	code, err := h.issueVerificationCode(&user)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate verification code"})
+	if err := h.sendVerificationCode(ctx.Request.Context(), &user); err != nil {
+		log.Printf("auth: unable to send verification email to %s: %v", user.Email, err)
+		ctx.JSON(http.StatusCreated, registerSuccessResponse{User: toUserResponse(&user)})
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, registerSuccessResponse{
 		User:             toUserResponse(&user),
-		VerificationCode: code,
+		VerificationSent: true,
 	})
 }
 
-func (h *AuthHandler) handleLogin(ctx *gin.Context) {
+func (h *AuthHandler) HandleLogin(ctx *gin.Context) {
 	var req loginRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login payload"})
@@ -159,47 +205,62 @@ func (h *AuthHandler) handleLogin(ctx *gin.Context) {
 	}
 
 	email := strings.ToLower(req.Email)
+	subjects := throttleSubjects(ctx, loginAccountRule, loginIPRule, email)
+	if !h.checkThrottle(ctx, throttleScopeLogin, subjects) {
+		return
+	}
+
 	var user models.User
 	if err := h.db.Where("email = ?", email).First(&user).Error; err != nil {
+		h.recordThrottleFailure(ctx, throttleScopeLogin, subjects, nil)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect email or password"})
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		h.recordThrottleFailure(ctx, throttleScopeLogin, subjects, &user.ID)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect email or password"})
 		return
 	}
 
+	if user.Suspended() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return
+	}
+
 	if !user.Verified {
-		code, err := h.issueVerificationCode(&user)
-		if err != nil {
+		if err := h.sendVerificationCode(ctx.Request.Context(), &user); err != nil {
+			log.Printf("auth: unable to send verification email to %s: %v", user.Email, err)
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
 			return
 		}
 		ctx.JSON(http.StatusForbidden, gin.H{
 			"error":             "Email not verified",
-			"verificationCode":  code,
+			"verificationSent":  true,
 			"verificationValid": int(verificationCodeTTL.Minutes()),
 		})
 		return
 	}
 
-	session, err := h.createSession(ctx, &user)
+	if user.MFAEnabled || h.mfaRequired(&user) {
+		h.respondMFAChallenge(ctx, &user, req.RememberMe)
+		return
+	}
+
+	h.resetThrottle(throttleScopeLogin, subjects[0])
+
+	session, err := h.createSession(ctx, &user, req.RememberMe)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create session"})
 		return
 	}
 
-	setSessionCookie(ctx, session.Token)
+	h.setSessionCookie(ctx, session)
 
-	ctx.JSON(http.StatusOK, authSuccessResponse{
-		User:             toUserResponse(&user),
-		SessionExpiresAt: session.ExpiresAt.UTC().Format(time.RFC3339),
-		SessionToken:     session.Token,
-	})
+	ctx.JSON(http.StatusOK, h.authSuccess(&user, session))
 }
 
-func (h *AuthHandler) handleVerifyEmail(ctx *gin.Context) {
+func (h *AuthHandler) HandleVerifyEmail(ctx *gin.Context) {
 	var req verifyRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verify payload"})
@@ -207,14 +268,26 @@ func (h *AuthHandler) handleVerifyEmail(ctx *gin.Context) {
 	}
 
 	email := strings.ToLower(req.Email)
+	subjects := throttleSubjects(ctx, verifyAccountRule, verifyIPRule, email)
+	if !h.checkThrottle(ctx, throttleScopeVerifyEmail, subjects) {
+		return
+	}
+
 	var user models.User
 	if err := h.db.Where("email = ?", email).First(&user).Error; err != nil {
+		h.recordThrottleFailure(ctx, throttleScopeVerifyEmail, subjects, nil)
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
 		return
 	}
 
 	var token models.VerificationToken
 	if err := h.db.Where("user_id = ? AND code = ?", user.ID, req.Code).First(&token).Error; err != nil {
+		result := h.recordThrottleFailure(ctx, throttleScopeVerifyEmail, subjects, &user.ID)
+		if len(result.LockedOut) > 0 {
+			// Burn the outstanding code so guessing cannot resume where it
+			// left off once the lockout expires.
+			_ = h.db.Where("user_id = ?", user.ID).Delete(&models.VerificationToken{}).Error
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid verification code"})
 		return
 	}
@@ -222,14 +295,14 @@ func (h *AuthHandler) handleVerifyEmail(ctx *gin.Context) {
 	now := time.Now().UTC()
 	if now.After(token.ExpiresAt) {
 		_ = h.db.Delete(&token).Error
-		code, err := h.issueVerificationCode(&user)
-		if err != nil {
+		if err := h.sendVerificationCode(ctx.Request.Context(), &user); err != nil {
+			log.Printf("auth: unable to send verification email to %s: %v", user.Email, err)
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Verification code expired"})
 			return
 		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"error":             "Verification code expired",
-			"verificationCode":  code,
+			"verificationSent":  true,
 			"verificationValid": int(verificationCodeTTL.Minutes()),
 		})
 		return
@@ -244,15 +317,13 @@ func (h *AuthHandler) handleVerifyEmail(ctx *gin.Context) {
 	}
 
 	_ = h.db.Where("user_id = ?", user.ID).Delete(&models.VerificationToken{}).Error
+	h.resetThrottle(throttleScopeVerifyEmail, subjects[0])
 
 	ctx.JSON(http.StatusOK, gin.H{"user": toUserResponse(&user)})
 }
 
-func (h *AuthHandler) handleUpdateSubscription(ctx *gin.Context) {
-	session, user, ok := h.requireSession(ctx)
-	if !ok {
-		return
-	}
+func (h *AuthHandler) HandleUpdateSubscription(ctx *gin.Context) {
+	session, user := currentSession(ctx), currentUser(ctx)
 
 	var req subscriptionRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -271,78 +342,80 @@ func (h *AuthHandler) handleUpdateSubscription(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, authSuccessResponse{
-		User:             toUserResponse(user),
-		SessionExpiresAt: session.ExpiresAt.UTC().Format(time.RFC3339),
-		SessionToken:     session.Token,
-	})
+	ctx.JSON(http.StatusOK, h.authSuccess(user, session))
 }
 
-func (h *AuthHandler) handleResendVerification(ctx *gin.Context) {
+// HandleResendVerification emails a fresh code to an unverified account. It
+// answers the same way whether or not the email belongs to one, so it cannot
+// be used to discover accounts, and every request counts towards the resend
+// throttle.
+func (h *AuthHandler) HandleResendVerification(ctx *gin.Context) {
 	var req resendVerificationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification request"})
 		return
 	}
 
-	email := strings.ToLower(req.Email)
-	var user models.User
-	if err := h.db.Where("email = ?", email).First(&user).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	subjects := throttleSubjects(ctx, resendAccountRule, resendIPRule, email)
+	if !h.checkThrottle(ctx, throttleScopeResendVerification, subjects) {
 		return
 	}
 
-	if user.Verified {
-		ctx.JSON(http.StatusOK, registerSuccessResponse{
-			User: toUserResponse(&user),
-		})
-		return
+	var user models.User
+	err := h.db.Where("email = ?", email).First(&user).Error
+	var userID *uuid.UUID
+	if err == nil {
+		userID = &user.ID
 	}
+	h.recordThrottleFailure(ctx, throttleScopeResendVerification, subjects, userID)
 
-	code, err := h.issueVerificationCode(&user)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to generate verification code"})
-		return
+	if err == nil && !user.Verified {
+		if err := h.sendVerificationCode(ctx.Request.Context(), &user); err != nil {
+			log.Printf("auth: unable to send verification email to %s: %v", user.Email, err)
+		}
 	}
 
-	ctx.JSON(http.StatusOK, registerSuccessResponse{
-		User:             toUserResponse(&user),
-		VerificationCode: code,
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "If an unverified account uses this email, a new verification code is on its way",
 	})
 }
 
-func (h *AuthHandler) handleCurrentUser(ctx *gin.Context) {
-	session, user, ok := h.requireSession(ctx)
-	if !ok {
-		return
-	}
+func (h *AuthHandler) HandleCurrentUser(ctx *gin.Context) {
+	session, user := currentSession(ctx), currentUser(ctx)
 
-	ctx.JSON(http.StatusOK, authSuccessResponse{
-		User:             toUserResponse(user),
-		SessionExpiresAt: session.ExpiresAt.UTC().Format(time.RFC3339),
-		SessionToken:     session.Token,
-	})
+	ctx.JSON(http.StatusOK, h.authSuccess(user, session))
 }
 
-func (h *AuthHandler) handleLogout(ctx *gin.Context) {
-	token := extractSessionToken(ctx)
+func (h *AuthHandler) HandleLogout(ctx *gin.Context) {
+	token, _ := extractSessionToken(ctx)
 	if token != "" {
-		h.db.Where("token = ?", token).Delete(&models.Session{})
+		h.db.Where("token_hash = ?", h.hashSessionToken(token)).Delete(&models.Session{})
 	}
-	clearSessionCookie(ctx)
+	h.clearSessionCookie(ctx)
 	ctx.JSON(http.StatusOK, gin.H{"status": "logged out"})
 }
 
+func (h *AuthHandler) authSuccess(user *models.User, session *models.Session) authSuccessResponse {
+	return authSuccessResponse{
+		User:             toUserResponse(user),
+		SessionExpiresAt: session.ExpiresAt.UTC().Format(time.RFC3339),
+		SessionToken:     session.Token,
+		CSRFToken:        h.csrfToken(session),
+	}
+}
+
 func toUserResponse(user *models.User) userResponse {
 	return userResponse{
-		ID:           user.ID,
-		CompanyName:  user.CompanyName,
-		Email:        user.Email,
-		Verified:     user.Verified,
-		Subscription: user.Subscription,
-		Role:         user.Role,
-		CreatedAt:    user.CreatedAt.UTC().Format(time.RFC3339),
-		Role:         user.Role,
+		ID:             user.ID,
+		CompanyName:    user.CompanyName,
+		Email:          user.Email,
+		Verified:       user.Verified,
+		Subscription:   user.Subscription,
+		Role:           user.Role,
+		ApprovalStatus: user.ApprovalStatus,
+		MFAEnabled:     user.MFAEnabled,
+		CreatedAt:      user.CreatedAt.UTC().Format(time.RFC3339),
 	}
 }
 
@@ -369,10 +442,55 @@ func (h *AuthHandler) issueVerificationCode(user *models.User) (string, error) {
 	return code, nil
 }
 
-func (h *AuthHandler) createSession(ctx *gin.Context, user *models.User) (*models.Session, error) {
+// sendVerificationCode replaces any outstanding code for the user and emails
+// the new one. The code is never returned to the caller.
+func (h *AuthHandler) sendVerificationCode(ctx context.Context, user *models.User) error {
+	code, err := h.issueVerificationCode(user)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplateVerification, user.Email, mailer.VerificationData{
+		CompanyName:  user.CompanyName,
+		Code:         code,
+		ValidMinutes: int(verificationCodeTTL.Minutes()),
+		VerifyURL:    h.appURL("/verify-email", url.Values{"email": {user.Email}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, msg)
+}
+
+func (h *AuthHandler) appURL(path string, query url.Values) string {
+	return appLink(h.appBaseURL, path, query)
+}
+
+// appLink builds a front-end URL for outbound email.
+func appLink(baseURL, path string, query url.Values) string {
+	link := baseURL + path
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// sessionLimits returns the idle timeout and absolute lifetime for a session
+// owned by role, and whether remember-me is honoured for it.
+func (h *AuthHandler) sessionLimits(role string, rememberMe bool) (time.Duration, time.Duration, bool) {
+	policy := h.sessions.PolicyFor(role)
+	if rememberMe && policy.RememberAbsoluteTTL > 0 {
+		return policy.RememberIdleTimeout, policy.RememberAbsoluteTTL, true
+	}
+	return policy.IdleTimeout, policy.AbsoluteTTL, false
+}
+
+func (h *AuthHandler) createSession(ctx *gin.Context, user *models.User, rememberMe bool) (*models.Session, error) {
 	now := time.Now().UTC()
 	// Clean up stale sessions opportunistically.
 	_ = h.db.Where("expires_at < ?", now).Delete(&models.Session{}).Error
+	_ = h.limiter.Prune(24 * time.Hour)
 
 	token, err := generateSessionToken()
 	if err != nil {
@@ -381,94 +499,163 @@ func (h *AuthHandler) createSession(ctx *gin.Context, user *models.User) (*model
 
 	userAgent := truncateString(ctx.GetHeader("User-Agent"), 255)
 	ip := truncateString(ctx.ClientIP(), 64)
+	idle, absolute, remember := h.sessionLimits(user.Role, rememberMe)
 
 	session := models.Session{
 		UserID:       user.ID,
+		TokenHash:    h.hashSessionToken(token),
 		Token:        token,
 		UserAgent:    userAgent,
 		IP:           ip,
+		RememberMe:   remember,
 		LastActivity: now,
-		ExpiresAt:    now.Add(sessionInactivityWindow),
-	}
-
-	if sessionAbsoluteTTL > 0 {
-		absoluteExpiry := now.Add(sessionAbsoluteTTL)
-		if session.ExpiresAt.After(absoluteExpiry) {
-			session.ExpiresAt = absoluteExpiry
-		}
+		ExpiresAt:    sessionExpiry(now, now, idle, absolute),
 	}
 
 	if err := h.db.Create(&session).Error; err != nil {
 		return nil, err
 	}
 
+	redeemPendingCaseInvitations(ctx, h.db, user)
+
 	return &session, nil
 }
 
 func (h *AuthHandler) requireSession(ctx *gin.Context) (*models.Session, *models.User, bool) {
-	token := extractSessionToken(ctx)
+	token, fromCookie := extractSessionToken(ctx)
 	if token == "" {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return nil, nil, false
 	}
 
 	var session models.Session
-	if err := h.db.Preload("User").Where("token = ?", token).First(&session).Error; err != nil {
-		clearSessionCookie(ctx)
+	if err := h.db.Preload("User").Where("token_hash = ?", h.hashSessionToken(token)).First(&session).Error; err != nil {
+		h.clearSessionCookie(ctx)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired"})
 		return nil, nil, false
 	}
+	session.Token = token
+
+	if session.User.Suspended() {
+		_ = h.db.Delete(&session).Error
+		h.clearSessionCookie(ctx)
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return nil, nil, false
+	}
+
+	// Browsers attach the cookie to cross-site requests, so cookie-borne
+	// credentials must also prove same-origin intent on mutating calls.
+	// Bearer tokens are never sent ambiently and skip the check.
+	if fromCookie && !h.validCSRF(ctx, &session) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "CSRF token missing or invalid"})
+		return nil, nil, false
+	}
+
+	// Limits come from the current policy for the user's role, so tightening
+	// the configuration also applies to sessions that already exist.
+	idle, absolute, _ := h.sessionLimits(session.User.Role, session.RememberMe)
 
 	now := time.Now().UTC()
 	if now.After(session.ExpiresAt) ||
-		now.Sub(session.LastActivity) > sessionInactivityWindow ||
-		(sessionAbsoluteTTL > 0 && now.Sub(session.CreatedAt) > sessionAbsoluteTTL) {
+		now.Sub(session.LastActivity) > idle ||
+		(absolute > 0 && now.Sub(session.CreatedAt) > absolute) {
 		_ = h.db.Delete(&session).Error
-		clearSessionCookie(ctx)
+		h.clearSessionCookie(ctx)
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired"})
 		return nil, nil, false
 	}
 
-	newExpiry := now.Add(sessionInactivityWindow)
-	if sessionAbsoluteTTL > 0 {
-		absoluteExpiry := session.CreatedAt.Add(sessionAbsoluteTTL)
-		if newExpiry.After(absoluteExpiry) {
-			newExpiry = absoluteExpiry
+	// Sliding refresh is persisted at most once per ActivityWriteInterval so
+	// read-heavy clients do not turn every request into an UPDATE. The
+	// interval is capped well inside the idle timeout so an active session
+	// is always refreshed before it lapses.
+	writeInterval := h.sessions.ActivityWriteInterval
+	if writeInterval > idle/2 {
+		writeInterval = idle / 2
+	}
+	if now.Sub(session.LastActivity) >= writeInterval {
+		session.LastActivity = now
+		session.ExpiresAt = sessionExpiry(now, session.CreatedAt, idle, absolute)
+		if err := h.db.Model(&session).Updates(map[string]interface{}{
+			"last_activity": session.LastActivity,
+			"expires_at":    session.ExpiresAt,
+		}).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to refresh session"})
+			return nil, nil, false
 		}
 	}
 
-	session.LastActivity = now
-	session.ExpiresAt = newExpiry
-	if err := h.db.Model(&session).Updates(map[string]interface{}{
-		"last_activity": session.LastActivity,
-		"expires_at":    session.ExpiresAt,
-	}).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to refresh session"})
-		return nil, nil, false
+	if fromCookie {
+		h.setSessionCookie(ctx, &session)
 	}
-
-	setSessionCookie(ctx, session.Token)
 	return &session, &session.User, true
 }
 
-func extractSessionToken(ctx *gin.Context) string {
+// sessionExpiry slides the idle deadline forward from now without passing the
+// absolute limit measured from creation.
+func sessionExpiry(now, createdAt time.Time, idle, absolute time.Duration) time.Time {
+	expiry := now.Add(idle)
+	if absolute > 0 {
+		if absoluteExpiry := createdAt.Add(absolute); expiry.After(absoluteExpiry) {
+			expiry = absoluteExpiry
+		}
+	}
+	return expiry
+}
+
+// extractSessionToken returns the presented session token and whether it
+// arrived in the session cookie rather than an Authorization header.
+func extractSessionToken(ctx *gin.Context) (string, bool) {
 	if header := ctx.GetHeader("Authorization"); strings.HasPrefix(strings.ToLower(header), "bearer ") {
-		return strings.TrimSpace(header[7:])
+		return strings.TrimSpace(header[7:]), false
 	}
 
-	if cookie, err := ctx.Cookie(sessionCookieName); err == nil {
-		return cookie
+	if cookie, err := ctx.Cookie(sessionCookieName); err == nil && cookie != "" {
+		return cookie, true
 	}
 
-	return ""
+	return "", false
+}
+
+// hashSessionToken derives the value stored in sessions.token_hash. The key
+// comes from APP_SECRET so a database dump alone cannot be replayed.
+func (h *AuthHandler) hashSessionToken(token string) string {
+	mac := hmac.New(sha256.New, h.keyring.Key(sessionTokenPurpose))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setSessionCookie issues the session and CSRF cookies. Remember-me sessions
+// get persistent cookies; others last only as long as the browser session.
+func (h *AuthHandler) setSessionCookie(ctx *gin.Context, session *models.Session) {
+	maxAge := 0
+	if session.RememberMe {
+		maxAge = int(time.Until(session.ExpiresAt).Seconds())
+		if maxAge <= 0 {
+			maxAge = -1
+		}
+	}
+	ctx.SetSameSite(h.sameSite())
+	ctx.SetCookie(sessionCookieName, session.Token, maxAge, "/", h.sessions.CookieDomain, h.sessions.CookieSecure, true)
+	// The CSRF cookie is readable by scripts so the front-end can echo it.
+	ctx.SetCookie(csrfCookieName, h.csrfToken(session), maxAge, "/", h.sessions.CookieDomain, h.sessions.CookieSecure, false)
 }
 
-func setSessionCookie(ctx *gin.Context, token string) {
-	ctx.SetCookie(sessionCookieName, token, int(sessionInactivityWindow.Seconds()), "/", "", false, true)
+func (h *AuthHandler) clearSessionCookie(ctx *gin.Context) {
+	ctx.SetSameSite(h.sameSite())
+	ctx.SetCookie(sessionCookieName, "", -1, "/", h.sessions.CookieDomain, h.sessions.CookieSecure, true)
+	ctx.SetCookie(csrfCookieName, "", -1, "/", h.sessions.CookieDomain, h.sessions.CookieSecure, false)
 }
 
-func clearSessionCookie(ctx *gin.Context) {
-	ctx.SetCookie(sessionCookieName, "", -1, "/", "", false, true)
+func (h *AuthHandler) sameSite() http.SameSite {
+	switch h.sessions.CookieSameSite {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
 }
 
 func generateSessionToken() (string, error) {
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+// caseAccess holds a user's organization roles and case roles so the policy
+// subject for any of their cases can be derived without further queries.
+type caseAccess struct {
+	userID    uuid.UUID
+	orgRoles  map[uuid.UUID]string
+	caseRoles map[uuid.UUID]string
+	proposed  map[uuid.UUID]bool
+}
+
+// loadCaseAccess loads userID's roles, limited to caseIDs when any are given.
+// Admins of a firm engaged on a case act as counsel on it unless they hold a
+// role of their own, and are proposed on it until the firm accepts.
+func loadCaseAccess(db *gorm.DB, userID uuid.UUID, caseIDs ...uuid.UUID) (*caseAccess, error) {
+	access := &caseAccess{
+		userID:    userID,
+		orgRoles:  map[uuid.UUID]string{},
+		caseRoles: map[uuid.UUID]string{},
+		proposed:  map[uuid.UUID]bool{},
+	}
+	scoped := func(query *gorm.DB) *gorm.DB {
+		if len(caseIDs) > 0 {
+			return query.Where("case_id IN ?", caseIDs)
+		}
+		return query
+	}
+
+	var memberships []models.OrganizationMembership
+	if err := db.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	for _, membership := range memberships {
+		access.orgRoles[membership.OrganizationID] = membership.Role
+	}
+
+	var engagements []models.CaseFirmAssignment
+	if err := scoped(db.Where("firm_id IN (?) AND status IN ?", administeredFirms(db, userID), models.ActiveAssignmentStatuses)).
+		Find(&engagements).Error; err != nil {
+		return nil, err
+	}
+	for _, engagement := range engagements {
+		// Another firm's acceptance outweighs a proposal on the same case.
+		if _, engaged := access.caseRoles[engagement.CaseID]; !engaged || engagement.Status == models.AssignmentAccepted {
+			access.proposed[engagement.CaseID] = engagement.Status == models.AssignmentProposed
+		}
+		access.caseRoles[engagement.CaseID] = models.CaseRoleCounsel
+	}
+
+	var assignments []models.CaseAssignment
+	if err := scoped(db.Where("lawyer_id = ? AND status IN ?", userID, models.ActiveAssignmentStatuses)).
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		access.caseRoles[assignment.CaseID] = assignment.Role
+		access.proposed[assignment.CaseID] = assignment.Status == models.AssignmentProposed
+	}
+
+	var collaborations []models.CaseCollaborator
+	if err := scoped(db.Where("user_id = ?", userID)).Find(&collaborations).Error; err != nil {
+		return nil, err
+	}
+	for _, collaboration := range collaborations {
+		access.caseRoles[collaboration.CaseID] = collaboration.Role
+	}
+
+	return access, nil
+}
+
+func (a *caseAccess) subject(caseModel *models.Case) policy.Subject {
+	return policy.Subject{
+		UserID:   a.userID,
+		OrgRole:  a.orgRoles[caseModel.OrganizationID],
+		CaseRole: a.caseRoles[caseModel.ID],
+		Proposed: a.proposed[caseModel.ID],
+	}
+}
+
+// authorizeCase loads a case and checks that user may perform action on it.
+// Users with no access get 404 so case ids cannot be probed.
+func (h *CaseHandler) authorizeCase(ctx *gin.Context, caseID uuid.UUID, user *models.User, action policy.Action) (*models.Case, policy.Subject, bool) {
+	var caseModel models.Case
+	err := h.db.Where("id = ?", caseID).First(&caseModel).Error
+	var access *caseAccess
+	if err == nil {
+		access, err = loadCaseAccess(h.db, user.ID, caseID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+		}
+		return nil, policy.Subject{}, false
+	}
+
+	subject := access.subject(&caseModel)
+	if !subject.Participant() {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+		return nil, policy.Subject{}, false
+	}
+	if !policy.Case(subject, &caseModel, action) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your access to this case does not permit this action"})
+		return nil, policy.Subject{}, false
+	}
+	return &caseModel, subject, true
+}
+
+// authorizeDocument loads a document of a case the user participates in and
+// checks that they may perform action on it. Documents hidden from the user
+// answer 404 like missing ones.
+func (h *CaseHandler) authorizeDocument(ctx *gin.Context, caseID, documentID uuid.UUID, user *models.User, action policy.Action) (*models.CaseDocument, policy.Subject, bool) {
+	_, subject, ok := h.authorizeCase(ctx, caseID, user, policy.ActionView)
+	if !ok {
+		return nil, policy.Subject{}, false
+	}
+
+	var document models.CaseDocument
+	if err := h.db.Where("id = ? AND case_id = ?", documentID, caseID).First(&document).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+			return nil, policy.Subject{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load document"})
+		return nil, policy.Subject{}, false
+	}
+	if !policy.Document(subject, &document, policy.ActionView) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return nil, policy.Subject{}, false
+	}
+	if !policy.Document(subject, &document, action) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your access to this case does not permit this action"})
+		return nil, policy.Subject{}, false
+	}
+	return &document, subject, true
+}
+
+// visibleCases is a subquery selecting the cases userID has any access to:
+// through organization membership, an active lawyer assignment, a
+// collaborator grant or administering a firm proposed for or engaged on the
+// case.
+func visibleCases(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.Case{}).Select("cases.id").
+		Where("cases.organization_id IN (?)", memberOrganizations(db, userID)).
+		Or("cases.id IN (?)", db.Model(&models.CaseAssignment{}).Select("case_id").
+			Where("lawyer_id = ? AND status IN ?", userID, models.ActiveAssignmentStatuses)).
+		Or("cases.id IN (?)", db.Model(&models.CaseCollaborator{}).Select("case_id").Where("user_id = ?", userID)).
+		Or("cases.id IN (?)", engagedCases(db, userID, models.ActiveAssignmentStatuses...))
+}
+
+// viewableDocuments is a subquery selecting the documents userID may view,
+// mirroring policy.Document: every document of their organizations' cases
+// and, on other cases they have access to and are not merely proposed on,
+// shared documents, plus restricted ones where they are lead counsel. As in
+// loadCaseAccess, a lawyer's own assignment decides whether they are proposed
+// before any firm engagement does.
+func viewableDocuments(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	organizationCases := db.Model(&models.Case{}).Select("id").
+		Where("organization_id IN (?)", memberOrganizations(db, userID))
+	assignedCases := db.Model(&models.CaseAssignment{}).Select("case_id").
+		Where("lawyer_id = ? AND status IN ?", userID, models.ActiveAssignmentStatuses)
+	proposedCases := db.Model(&models.Case{}).Select("id").
+		Where("id IN (?)", db.Model(&models.CaseAssignment{}).Select("case_id").
+			Where("lawyer_id = ? AND status = ?", userID, models.AssignmentProposed)).
+		Or(db.Where("id IN (?)", engagedCases(db, userID, models.AssignmentProposed)).
+			Where("id NOT IN (?)", engagedCases(db, userID, models.AssignmentAccepted)).
+			Where("id NOT IN (?)", assignedCases))
+	leadCases := db.Model(&models.CaseAssignment{}).Select("case_id").
+		Where("lawyer_id = ? AND status = ? AND role = ?", userID, models.AssignmentAccepted, models.CaseRoleLeadCounsel)
+
+	return db.Model(&models.CaseDocument{}).Select("case_documents.id").
+		Where("case_documents.case_id IN (?)", organizationCases).
+		Or(db.Where("case_documents.case_id IN (?) AND case_documents.case_id NOT IN (?)", visibleCases(db, userID), proposedCases).
+			Where(db.Where("case_documents.visibility = ?", models.DocumentVisibilityShared).
+				Or("case_documents.case_id IN (?)", leadCases)))
+}
+
+// engagedCases is a subquery selecting the cases engaging a firm userID
+// administers with one of statuses.
+func engagedCases(db *gorm.DB, userID uuid.UUID, statuses ...string) *gorm.DB {
+	return db.Model(&models.CaseFirmAssignment{}).Select("case_id").
+		Where("firm_id IN (?) AND status IN ?", administeredFirms(db, userID), statuses)
+}
+
+// administeredFirms is a subquery selecting the firms userID administers on
+// cases. A lawyer who is not approved for assignments, such as one an
+// administrator rejected, reaches no case through their firms.
+func administeredFirms(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.FirmMembership{}).Select("firm_id").
+		Where("user_id = ? AND role = ?", userID, models.FirmRoleAdmin).
+		Where("user_id IN (?)", db.Model(&models.User{}).Select("id").Where("approval_status = ?", models.ApprovalApproved))
+}
+
+// memberOrganizations is a subquery selecting the organizations userID
+// belongs to.
+func memberOrganizations(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Model(&models.OrganizationMembership{}).Select("organization_id").Where("user_id = ?", userID)
+}
+
+func normaliseCaseRole(role string, allowed []string) (string, bool) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	for _, candidate := range allowed {
+		if candidate == role {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// documentVisibility resolves the visibility of a new document: the requested
+// one, or restricted for personal documents and shared otherwise.
+func documentVisibility(requested, category string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(requested)) {
+	case "":
+		if category == "personal" {
+			return models.DocumentVisibilityRestricted, true
+		}
+		return models.DocumentVisibilityShared, true
+	case models.DocumentVisibilityShared:
+		return models.DocumentVisibilityShared, true
+	case models.DocumentVisibilityRestricted:
+		return models.DocumentVisibilityRestricted, true
+	}
+	return "", false
+}
+
+// leadCounselTaken reports whether a lawyer other than lawyerID holds the
+// lead counsel role on caseID through an active assignment.
+func leadCounselTaken(db *gorm.DB, caseID, lawyerID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.CaseAssignment{}).
+		Where("case_id = ? AND lawyer_id <> ? AND role = ? AND status IN ?",
+			caseID, lawyerID, models.CaseRoleLeadCounsel, models.ActiveAssignmentStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// resolveLawyerCaseRole validates the case role requested for lawyerID. An
+// empty request resolves to "" so callers keep an existing role. A case has
+// at most one lead counsel.
+func (h *CaseHandler) resolveLawyerCaseRole(ctx *gin.Context, caseID, lawyerID uuid.UUID, requested string) (string, bool) {
+	if strings.TrimSpace(requested) == "" {
+		return "", true
+	}
+	role, ok := normaliseCaseRole(requested, models.LawyerCaseRoles)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case role"})
+		return "", false
+	}
+	if role == models.CaseRoleLeadCounsel {
+		taken, err := leadCounselTaken(h.db, caseID, lawyerID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case role"})
+			return "", false
+		}
+		if taken {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Case already has lead counsel"})
+			return "", false
+		}
+	}
+	return role, true
+}
+
+// permitVisibility stops participants from creating documents they would not
+// be able to see themselves.
+func (h *CaseHandler) permitVisibility(ctx *gin.Context, subject policy.Subject, visibility string) bool {
+	if visibility == models.DocumentVisibilityRestricted && !policy.SeesRestricted(subject) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your access to this case does not permit restricted documents"})
+		return false
+	}
+	return true
+}
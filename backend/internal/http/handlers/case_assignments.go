@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+const (
+	auditActionAssignmentStatus     = "case.assignment_status"
+	auditActionAssignmentRemove     = "case.assignment_remove"
+	auditActionLeadTransfer         = "case.lead_transfer"
+	auditActionFirmEngagementStatus = "case.firm_engagement_status"
+)
+
+type assignmentStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+type transferLeadRequest struct {
+	LawyerID         string `json:"lawyerId" binding:"required"`
+	PreviousLeadRole string `json:"previousLeadRole"`
+	Reason           string `json:"reason"`
+}
+
+// assignmentTransitions maps each status a lawyer can move their assignment
+// to onto the status it has to be in beforehand.
+var assignmentTransitions = map[string]string{
+	models.AssignmentAccepted:  models.AssignmentProposed,
+	models.AssignmentDeclined:  models.AssignmentProposed,
+	models.AssignmentWithdrawn: models.AssignmentAccepted,
+	models.AssignmentCompleted: models.AssignmentAccepted,
+}
+
+// HandleAcceptAssignment takes on a proposed assignment, which opens the
+// case's documents to the lawyer.
+func (h *CaseHandler) HandleAcceptAssignment(ctx *gin.Context) {
+	h.transitionAssignment(ctx, models.AssignmentAccepted)
+}
+
+func (h *CaseHandler) HandleDeclineAssignment(ctx *gin.Context) {
+	h.transitionAssignment(ctx, models.AssignmentDeclined)
+}
+
+func (h *CaseHandler) HandleWithdrawAssignment(ctx *gin.Context) {
+	h.transitionAssignment(ctx, models.AssignmentWithdrawn)
+}
+
+func (h *CaseHandler) HandleCompleteAssignment(ctx *gin.Context) {
+	h.transitionAssignment(ctx, models.AssignmentCompleted)
+}
+
+// transitionAssignment moves the caller's assignment on the :id case to
+// status with an optional reason. The update is conditional on the current
+// status so concurrent responses cannot both succeed.
+func (h *CaseHandler) transitionAssignment(ctx *gin.Context, status string) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	var req assignmentStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	var assignment models.CaseAssignment
+	if err := h.db.Where("case_id = ? AND lawyer_id = ?", caseID, user.ID).First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch assignment"})
+		return
+	}
+
+	from := assignmentTransitions[status]
+	if assignment.Status != from {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status, "status": assignment.Status})
+		return
+	}
+	if status == models.AssignmentAccepted && !user.Assignable() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is not approved for assignments"})
+		return
+	}
+
+	now := time.Now().UTC()
+	reason := truncateString(strings.TrimSpace(req.Reason), 512)
+	updates := map[string]any{"status": status, "status_reason": reason}
+	switch status {
+	case models.AssignmentAccepted:
+		updates["accepted_at"] = now
+		assignment.AcceptedAt = &now
+	case models.AssignmentDeclined:
+		updates["declined_at"] = now
+		assignment.DeclinedAt = &now
+	case models.AssignmentWithdrawn:
+		updates["withdrawn_at"] = now
+		assignment.WithdrawnAt = &now
+	case models.AssignmentCompleted:
+		updates["completed_at"] = now
+		assignment.CompletedAt = &now
+	}
+
+	result := h.db.Model(&models.CaseAssignment{}).
+		Where("id = ? AND status = ?", assignment.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update assignment"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment changed, reload and try again"})
+		return
+	}
+	assignment.Status = status
+	assignment.StatusReason = reason
+
+	recordAudit(h.db, ctx, auditActionAssignmentStatus, &user.ID, &user.ID, map[string]any{
+		"caseId": caseID.String(),
+		"from":   from,
+		"to":     status,
+		"reason": reason,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"assignment": toCaseLawyerResponse(&assignment, user)})
+}
+
+// reproposeAssignment turns an ended assignment back into a fresh proposal
+// when the lawyer is assigned again.
+func reproposeAssignment(assignment *models.CaseAssignment) {
+	assignment.Status = models.AssignmentProposed
+	assignment.StatusReason = ""
+	assignment.AcceptedAt = nil
+	assignment.DeclinedAt = nil
+	assignment.WithdrawnAt = nil
+	assignment.CompletedAt = nil
+	assignment.RemovedAt = nil
+	assignment.RemovedByID = nil
+}
+
+// HandleRemoveAssignment takes a lawyer off the case, with an optional reason.
+// The assignment is kept as a record; the lawyer loses access to the case and
+// its documents on their next request.
+func (h *CaseHandler) HandleRemoveAssignment(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	lawyerID, err := uuid.Parse(ctx.Param("lawyerId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+		return
+	}
+
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
+		return
+	}
+
+	var req assignmentStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	var assignment models.CaseAssignment
+	if err := h.db.Where("case_id = ? AND lawyer_id = ?", caseID, lawyerID).First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch assignment"})
+		return
+	}
+
+	if !h.removeAssignment(ctx, &assignment, user, req.Reason) {
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// removeAssignment ends an active assignment on behalf of user, records who
+// did it and why, and tells the lawyer. It writes the error response itself
+// and reports whether the assignment was removed.
+func (h *CaseHandler) removeAssignment(ctx *gin.Context, assignment *models.CaseAssignment, user *models.User, reason string) bool {
+	if !assignment.Active() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + assignment.Status, "status": assignment.Status})
+		return false
+	}
+
+	reason = truncateString(strings.TrimSpace(reason), 512)
+	removed, err := endAssignment(h.db, assignment, user.ID, reason, time.Now().UTC())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to remove assignment"})
+		return false
+	}
+	if !removed {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment changed, reload and try again"})
+		return false
+	}
+
+	announceAssignmentRemoval(ctx, h.db, h.mailer, assignment, user, reason)
+	return true
+}
+
+// endAssignment marks an active assignment removed by removedByID. It
+// reports false when the assignment was no longer active, so a concurrent
+// change is never overwritten.
+func endAssignment(db *gorm.DB, assignment *models.CaseAssignment, removedByID uuid.UUID, reason string, now time.Time) (bool, error) {
+	result := db.Model(&models.CaseAssignment{}).
+		Where("id = ? AND status IN ?", assignment.ID, models.ActiveAssignmentStatuses).
+		Updates(map[string]any{
+			"status":        models.AssignmentRemoved,
+			"status_reason": reason,
+			"removed_at":    now,
+			"removed_by_id": removedByID,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// announceAssignmentRemoval audits an assignment endAssignment removed and
+// emails the lawyer. assignment still carries the status it had before.
+func announceAssignmentRemoval(ctx *gin.Context, db *gorm.DB, m mailer.Mailer, assignment *models.CaseAssignment, user *models.User, reason string) {
+	recordAudit(db, ctx, auditActionAssignmentRemove, &user.ID, &assignment.LawyerID, map[string]any{
+		"caseId": assignment.CaseID.String(),
+		"from":   assignment.Status,
+		"role":   assignment.Role,
+		"reason": reason,
+	})
+
+	caseModel, clientName, ok := caseMailContext(db, assignment.CaseID)
+	if ok {
+		notifyLawyer(ctx, db, m, assignment.LawyerID, mailer.TemplateAssignmentRemoved, mailer.AssignmentRemovedData{
+			CaseName:   caseModel.Name,
+			ClientName: clientName,
+			RemovedBy:  user.CompanyName,
+			Reason:     reason,
+		})
+	}
+}
+
+// HandleTransferLead makes another lawyer on the case lead counsel. The
+// current lead, if any, stays on the case with previousLeadRole, counsel by
+// default. Both lawyers are told about the change.
+func (h *CaseHandler) HandleTransferLead(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
+		return
+	}
+
+	var req transferLeadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer payload"})
+		return
+	}
+
+	lawyerID, err := uuid.Parse(req.LawyerID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+		return
+	}
+
+	previousRole, ok := normaliseCaseRole(defaultString(req.PreviousLeadRole, models.CaseRoleCounsel), models.LawyerCaseRoles)
+	if !ok || previousRole == models.CaseRoleLeadCounsel {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case role"})
+		return
+	}
+
+	var target models.CaseAssignment
+	if err := h.db.Preload("Lawyer").Where("case_id = ? AND lawyer_id = ?", caseID, lawyerID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch assignment"})
+		return
+	}
+	if !target.Active() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + target.Status, "status": target.Status})
+		return
+	}
+	if target.Role == models.CaseRoleLeadCounsel {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is already lead counsel"})
+		return
+	}
+
+	var previous []models.CaseAssignment
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Lawyer").
+			Where("case_id = ? AND role = ? AND status IN ?", caseID, models.CaseRoleLeadCounsel, models.ActiveAssignmentStatuses).
+			Find(&previous).Error; err != nil {
+			return err
+		}
+		for i := range previous {
+			if err := tx.Model(&previous[i]).Update("role", previousRole).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.CaseAssignment{}).
+			Where("id = ? AND role = ? AND status IN ?", target.ID, target.Role, models.ActiveAssignmentStatuses).
+			Update("role", models.CaseRoleLeadCounsel)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAssignmentChanged
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errAssignmentChanged) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment changed, reload and try again"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to transfer lead counsel"})
+		return
+	}
+	reason := truncateString(strings.TrimSpace(req.Reason), 512)
+	details := map[string]any{
+		"caseId":       caseID.String(),
+		"previousRole": target.Role,
+		"reason":       reason,
+	}
+	target.Role = models.CaseRoleLeadCounsel
+	if len(previous) > 0 {
+		details["previousLead"] = previous[0].LawyerID.String()
+		details["previousLeadRole"] = previousRole
+	}
+	recordAudit(h.db, ctx, auditActionLeadTransfer, &user.ID, &lawyerID, details)
+
+	if caseModel, clientName, ok := caseMailContext(h.db, caseID); ok {
+		notice := mailer.LeadCounselData{
+			CaseName:   caseModel.Name,
+			ClientName: clientName,
+			ChangedBy:  user.CompanyName,
+			NewLead:    target.Lawyer.CompanyName,
+			Reason:     reason,
+			CasesURL:   appLink(h.appBaseURL, "/lawyer/cases", nil),
+		}
+		promoted := notice
+		promoted.Role = strings.ReplaceAll(models.CaseRoleLeadCounsel, "_", " ")
+		promoted.Promoted = true
+		notifyLawyer(ctx, h.db, h.mailer, lawyerID, mailer.TemplateLeadCounsel, promoted)
+
+		demoted := notice
+		demoted.Role = strings.ReplaceAll(previousRole, "_", " ")
+		for i := range previous {
+			notifyLawyer(ctx, h.db, h.mailer, previous[i].LawyerID, mailer.TemplateLeadCounsel, demoted)
+		}
+	}
+
+	payload := gin.H{"leadCounsel": toCaseLawyerResponse(&target, &target.Lawyer)}
+	if len(previous) > 0 {
+		previous[0].Role = previousRole
+		payload["previousLead"] = toCaseLawyerResponse(&previous[0], &previous[0].Lawyer)
+	}
+
+	ctx.JSON(http.StatusOK, payload)
+}
+
+var errAssignmentChanged = errors.New("assignment changed")
+
+// caseMailContext loads the case and the name of the organization that owns
+// it for a notification. Failures are logged and reported as not ok.
+func caseMailContext(db *gorm.DB, caseID uuid.UUID) (*models.Case, string, bool) {
+	var caseModel models.Case
+	if err := db.First(&caseModel, "id = ?", caseID).Error; err != nil {
+		log.Printf("cases: unable to load case %s for notification: %v", caseID, err)
+		return nil, "", false
+	}
+	var organization models.Organization
+	if err := db.First(&organization, "id = ?", caseModel.OrganizationID).Error; err != nil {
+		log.Printf("cases: unable to load organization of case %s for notification: %v", caseID, err)
+		return nil, "", false
+	}
+	return &caseModel, organization.Name, true
+}
+
+// notifyLawyer emails a lawyer about a change already made to their
+// assignment, so delivery failures are logged rather than reported.
+func notifyLawyer(ctx *gin.Context, db *gorm.DB, m mailer.Mailer, lawyerID uuid.UUID, template string, data any) {
+	var lawyer models.User
+	if err := db.First(&lawyer, "id = ?", lawyerID).Error; err != nil {
+		log.Printf("cases: unable to load lawyer %s for notification: %v", lawyerID, err)
+		return
+	}
+	msg, err := mailer.Render(template, lawyer.Email, data)
+	if err == nil {
+		err = m.Send(ctx.Request.Context(), msg)
+	}
+	if err != nil {
+		log.Printf("cases: unable to send %s notification to %s: %v", template, lawyer.Email, err)
+	}
+}
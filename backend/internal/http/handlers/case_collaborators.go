@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+const (
+	auditActionCaseCollaboratorAdd    = "case.collaborator_add"
+	auditActionCaseCollaboratorRemove = "case.collaborator_remove"
+)
+
+type addCollaboratorRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role"`
+}
+
+// HandleAddCollaborator grants a client account outside the case's
+// organization access to this one case, or changes the role it already has.
+func (h *CaseHandler) HandleAddCollaborator(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage)
+	if !ok {
+		return
+	}
+
+	var req addCollaboratorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collaborator payload"})
+		return
+	}
+
+	role, ok := normaliseCaseRole(defaultString(req.Role, models.CaseRoleCollaborator), models.ClientCaseRoles)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case role"})
+		return
+	}
+
+	var target models.User
+	if err := h.db.Where("email = ? AND role = ?", strings.ToLower(strings.TrimSpace(req.Email)), models.UserRoleClient).
+		First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "No client account uses that email"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch user"})
+		return
+	}
+	if target.Suspended() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Account is suspended"})
+		return
+	}
+
+	var members int64
+	if err := h.db.Model(&models.OrganizationMembership{}).
+		Where("organization_id = ? AND user_id = ?", caseModel.OrganizationID, target.ID).
+		Count(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch user"})
+		return
+	}
+	if members > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "User already belongs to the case's organization"})
+		return
+	}
+
+	var collaborator models.CaseCollaborator
+	err = h.db.Where("case_id = ? AND user_id = ?", caseID, target.ID).First(&collaborator).Error
+
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusCreated
+		collaborator = models.CaseCollaborator{
+			CaseID:    caseID,
+			UserID:    target.ID,
+			Role:      role,
+			AddedByID: user.ID,
+		}
+		if err := h.db.Create(&collaborator).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to add collaborator"})
+			return
+		}
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to add collaborator"})
+		return
+	case role != collaborator.Role:
+		collaborator.Role = role
+		if err := h.db.Save(&collaborator).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update collaborator"})
+			return
+		}
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseCollaboratorAdd, &user.ID, &target.ID, map[string]any{
+		"caseId": caseID.String(),
+		"role":   role,
+	})
+
+	ctx.JSON(status, gin.H{"collaborator": caseClientResponse{
+		ID:          target.ID,
+		CompanyName: target.CompanyName,
+		Email:       target.Email,
+		Role:        collaborator.Role,
+	}})
+}
+
+// HandleRemoveCollaborator revokes a collaborator's access. Collaborators may
+// always remove themselves.
+func (h *CaseHandler) HandleRemoveCollaborator(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	targetID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	required := policy.ActionManage
+	if targetID == user.ID {
+		required = policy.ActionView
+	}
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, required); !ok {
+		return
+	}
+
+	result := h.db.Where("case_id = ? AND user_id = ?", caseID, targetID).Delete(&models.CaseCollaborator{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to remove collaborator"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseCollaboratorRemove, &user.ID, &targetID, map[string]any{
+		"caseId": caseID.String(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
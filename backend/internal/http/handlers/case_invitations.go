@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+const (
+	caseInvitationTTL           = 14 * 24 * time.Hour
+	auditActionCaseInvite       = "case.lawyer_invite"
+	auditActionCaseInviteRevoke = "case.lawyer_invite_revoke"
+	auditActionCaseInviteAccept = "case.lawyer_invite_accept"
+)
+
+type inviteLawyerRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+	Notes string `json:"notes"`
+}
+
+type caseInvitationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Notes     string    `json:"notes,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (h *CaseHandler) HandleListCaseInvitations(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
+		return
+	}
+
+	var invitations []models.CaseInvitation
+	if err := h.db.Where("case_id = ? AND accepted_at IS NULL AND expires_at > ?", caseID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch invitations"})
+		return
+	}
+
+	payload := make([]caseInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		payload = append(payload, toCaseInvitationResponse(&invitations[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"invitations": payload})
+}
+
+// HandleInviteLawyer emails a lawyer, registered or not, a single-use link
+// offering a role on the case. Inviting the same address again replaces its
+// pending invitation.
+func (h *CaseHandler) HandleInviteLawyer(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage)
+	if !ok {
+		return
+	}
+
+	var req inviteLawyerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+
+	role, ok := h.resolveLawyerCaseRole(ctx, caseID, uuid.Nil, defaultString(req.Role, models.CaseRoleCounsel))
+	if !ok {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var existing models.User
+	err = h.db.Where("email = ?", email).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Not registered yet; the invitation waits for their account.
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	case existing.Role != models.UserRoleLawyer:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "That email belongs to an account that is not a lawyer"})
+		return
+	default:
+		var assigned int64
+		if err := h.db.Model(&models.CaseAssignment{}).
+			Where("case_id = ? AND lawyer_id = ? AND status IN ?", caseID, existing.ID, models.ActiveAssignmentStatuses).
+			Count(&assigned).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+			return
+		}
+		if assigned > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is already assigned to this case"})
+			return
+		}
+	}
+
+	var organization models.Organization
+	if err := h.db.First(&organization, "id = ?", caseModel.OrganizationID).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	invitation := models.CaseInvitation{
+		CaseID:      caseID,
+		Email:       email,
+		Role:        role,
+		Notes:       strings.TrimSpace(req.Notes),
+		TokenHash:   hashToken(token),
+		InvitedByID: user.ID,
+		ExpiresAt:   time.Now().UTC().Add(caseInvitationTTL),
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("case_id = ? AND email = ? AND accepted_at IS NULL", caseID, email).
+			Delete(&models.CaseInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	msg, err := mailer.Render(mailer.TemplateCaseInvitation, email, mailer.CaseInvitationData{
+		CaseName:    caseModel.Name,
+		ClientName:  organization.Name,
+		InviterName: user.CompanyName,
+		Role:        strings.ReplaceAll(role, "_", " "),
+		AcceptURL:   appLink(h.appBaseURL, "/case-invitations/accept", url.Values{"token": {token}}),
+		ValidDays:   int(caseInvitationTTL.Hours() / 24),
+	})
+	if err == nil {
+		err = h.mailer.Send(ctx.Request.Context(), msg)
+	}
+	if err != nil {
+		log.Printf("cases: unable to send case invitation to %s: %v", email, err)
+		_ = h.db.Delete(&invitation).Error
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Unable to send invitation email"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseInvite, &user.ID, nil, map[string]any{
+		"caseId": caseID.String(),
+		"email":  email,
+		"role":   role,
+	})
+
+	ctx.JSON(http.StatusCreated, gin.H{"invitation": toCaseInvitationResponse(&invitation)})
+}
+
+func (h *CaseHandler) HandleRevokeCaseInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	invitationID, err := uuid.Parse(ctx.Param("invitationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation id"})
+		return
+	}
+
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
+		return
+	}
+
+	result := h.db.Where("id = ? AND case_id = ? AND accepted_at IS NULL", invitationID, caseID).
+		Delete(&models.CaseInvitation{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke invitation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseInviteRevoke, &user.ID, nil, map[string]any{
+		"caseId":       caseID.String(),
+		"invitationId": invitationID.String(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// HandleAcceptCaseInvitation redeems an invitation for the signed-in lawyer,
+// who must use the address it was sent to and be approved for assignments.
+func (h *CaseHandler) HandleAcceptCaseInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req acceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+
+	if !user.Assignable() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is not approved for assignments"})
+		return
+	}
+
+	tokenHash := hashToken(strings.TrimSpace(req.Token))
+	now := time.Now().UTC()
+
+	var assignment *models.CaseAssignment
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.CaseInvitation
+		if err := tx.Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvitationInvalid
+			}
+			return err
+		}
+		if invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+			return errInvitationInvalid
+		}
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return errInvitationEmail
+		}
+
+		var err error
+		assignment, err = redeemCaseInvitation(tx, &invitation, user, models.AssignmentAccepted, now)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalid):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or has expired"})
+		case errors.Is(err, errInvitationEmail):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "This invitation was sent to a different email address"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to accept invitation"})
+		}
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseInviteAccept, &user.ID, &user.ID, map[string]any{
+		"caseId": assignment.CaseID.String(),
+		"role":   assignment.Role,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"caseId":     assignment.CaseID,
+		"assignment": toCaseLawyerResponse(assignment, user),
+	})
+}
+
+// redeemCaseInvitation claims invitation for lawyer and turns it into an
+// assignment with status inside tx: accepted when the lawyer followed the
+// link, proposed when it was redeemed on their behalf. The claim is a
+// conditional update so an invitation is redeemed at most once. If another
+// lawyer became lead counsel after the invitation was sent, the invitee joins
+// as counsel instead.
+func redeemCaseInvitation(tx *gorm.DB, invitation *models.CaseInvitation, lawyer *models.User, status string, now time.Time) (*models.CaseAssignment, error) {
+	result := tx.Model(&models.CaseInvitation{}).
+		Where("id = ? AND accepted_at IS NULL", invitation.ID).
+		Updates(map[string]any{"accepted_at": now, "accepted_by_id": lawyer.ID})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvitationInvalid
+	}
+
+	role := invitation.Role
+	if role == models.CaseRoleLeadCounsel {
+		taken, err := leadCounselTaken(tx, invitation.CaseID, lawyer.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			role = models.CaseRoleCounsel
+		}
+	}
+
+	// A lawyer assigned in the meantime keeps the assignment they have unless
+	// it already ended.
+	var assignment models.CaseAssignment
+	err := tx.Where("case_id = ? AND lawyer_id = ?", invitation.CaseID, lawyer.ID).First(&assignment).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		assignment = models.CaseAssignment{CaseID: invitation.CaseID, LawyerID: lawyer.ID}
+	case err != nil:
+		return nil, err
+	case assignment.Active():
+		return &assignment, nil
+	}
+
+	assignment.Role = role
+	assignment.Notes = invitation.Notes
+	reproposeAssignment(&assignment)
+	if status == models.AssignmentAccepted {
+		assignment.Status = models.AssignmentAccepted
+		assignment.AcceptedAt = &now
+	}
+	if err := tx.Save(&assignment).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// redeemPendingCaseInvitations converts the unexpired invitations addressed
+// to user into proposed assignments once the account may take cases: a
+// verified, approved and active lawyer. It runs whenever such a lawyer signs
+// in or is approved; failures are logged and leave the invitations pending.
+func redeemPendingCaseInvitations(ctx *gin.Context, db *gorm.DB, user *models.User) {
+	if !user.Verified || !user.Assignable() {
+		return
+	}
+
+	now := time.Now().UTC()
+	var invitations []models.CaseInvitation
+	if err := db.Where("email = ? AND accepted_at IS NULL AND expires_at > ?", strings.ToLower(user.Email), now).
+		Find(&invitations).Error; err != nil {
+		log.Printf("cases: unable to load case invitations for %s: %v", user.ID, err)
+		return
+	}
+
+	for i := range invitations {
+		var assignment *models.CaseAssignment
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			assignment, err = redeemCaseInvitation(tx, &invitations[i], user, models.AssignmentProposed, now)
+			return err
+		})
+		if err != nil {
+			if !errors.Is(err, errInvitationInvalid) {
+				log.Printf("cases: unable to redeem case invitation %s: %v", invitations[i].ID, err)
+			}
+			continue
+		}
+		recordAudit(db, ctx, auditActionCaseInviteAccept, &user.ID, &user.ID, map[string]any{
+			"caseId": assignment.CaseID.String(),
+			"role":   assignment.Role,
+		})
+	}
+}
+
+func toCaseInvitationResponse(invitation *models.CaseInvitation) caseInvitationResponse {
+	return caseInvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		Notes:     invitation.Notes,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	caseListPageSize    = 50
+	caseListMaxPageSize = 100
+	caseListViewSummary = "summary"
+)
+
+// caseListSort orders the case list by column, with the case id breaking
+// ties. key renders a case's value of the column for a cursor and parseKey
+// reads it back.
+type caseListSort struct {
+	column   string
+	key      func(*models.Case) string
+	parseKey func(string) (any, error)
+}
+
+var caseListSorts = map[string]caseListSort{
+	"created": {
+		column:   "cases.created_at",
+		key:      func(c *models.Case) string { return c.CreatedAt.UTC().Format(time.RFC3339Nano) },
+		parseKey: parseCursorTime,
+	},
+	"updated": {
+		column:   "cases.updated_at",
+		key:      func(c *models.Case) string { return c.UpdatedAt.UTC().Format(time.RFC3339Nano) },
+		parseKey: parseCursorTime,
+	},
+	"name": {
+		column:   "cases.name",
+		key:      func(c *models.Case) string { return c.Name },
+		parseKey: func(raw string) (any, error) { return raw, nil },
+	},
+	"priority": {
+		column:   "CASE cases.priority WHEN 'High' THEN 3 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 1 ELSE 0 END",
+		key:      func(c *models.Case) string { return strconv.Itoa(casePriorityRank(c.Priority)) },
+		parseKey: func(raw string) (any, error) { return strconv.Atoi(raw) },
+	},
+}
+
+// caseListCursor marks where a page of the case list ended: the sort it was
+// produced for and the last case's sort key and id.
+type caseListCursor struct {
+	Sort string    `json:"s"`
+	Key  string    `json:"k"`
+	ID   uuid.UUID `json:"i"`
+}
+
+// caseSummaryResponse is the summary view of a case in the list: its own
+// fields, counsel and the caller's access, without documents, firms,
+// collaborators or invitations.
+type caseSummaryResponse struct {
+	ID              uuid.UUID            `json:"id"`
+	Organization    *caseOrgResponse     `json:"organization,omitempty"`
+	Name            string               `json:"name"`
+	Priority        string               `json:"priority"`
+	Status          string               `json:"status"`
+	MatterType      string               `json:"matterType"`
+	Owner           string               `json:"owner"`
+	AssignedLawyers []caseLawyerResponse `json:"assignedLawyers"`
+	Client          *caseClientResponse  `json:"client,omitempty"`
+	Access          caseAccessResponse   `json:"access"`
+	Version         int64                `json:"version"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+}
+
+// HandleListCases pages through the cases the caller can see. Filters narrow
+// the list, sort picks the order (newest first by default, a leading "-"
+// meaning descending) and cursor continues from the nextCursor of the
+// previous page, which must have used the same sort. view=summary leaves out
+// documents and the other per-case detail.
+func (h *CaseHandler) HandleListCases(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	query := h.db.Model(&models.Case{}).
+		Where("cases.id IN (?)", visibleCases(h.db, user.ID))
+
+	query, ok := h.filterCaseList(ctx, query)
+	if !ok {
+		return
+	}
+
+	sortParam := defaultString(ctx.Query("sort"), "-created")
+	sort, ok := caseListSorts[strings.TrimPrefix(sortParam, "-")]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return
+	}
+	descending := strings.HasPrefix(sortParam, "-")
+
+	view := strings.TrimSpace(ctx.Query("view"))
+	if view != "" && view != caseListViewSummary {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view"})
+		return
+	}
+
+	limit, ok := parseLimit(ctx, caseListPageSize, caseListMaxPageSize)
+	if !ok {
+		return
+	}
+
+	var cursor *caseListCursor
+	var cursorKey any
+	if raw := strings.TrimSpace(ctx.Query("cursor")); raw != "" {
+		decoded, key, ok := decodeCaseListCursor(raw, sortParam, sort)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor for this sort"})
+			return
+		}
+		cursor, cursorKey = &decoded, key
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch cases"})
+		return
+	}
+
+	if cursor != nil {
+		comparison := ">"
+		if descending {
+			comparison = "<"
+		}
+		query = query.Where("("+sort.column+", cases.id) "+comparison+" (?, ?)", cursorKey, cursor.ID)
+	}
+
+	direction := " ASC"
+	if descending {
+		direction = " DESC"
+	}
+	query = query.Order(sort.column + direction).Order("cases.id" + direction).
+		Preload("Assignments.Lawyer").
+		Preload("User").
+		Preload("Organization")
+	if view != caseListViewSummary {
+		query = query.
+			Preload("Documents").
+			Preload("FirmAssignments.Firm").
+			Preload("Collaborators.User").
+			Preload("Invitations", "accepted_at IS NULL AND expires_at > ?", time.Now().UTC())
+	}
+
+	var cases []models.Case
+	if err := query.Limit(limit + 1).Find(&cases).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch cases"})
+		return
+	}
+
+	body := gin.H{"total": total}
+	if len(cases) > limit {
+		cases = cases[:limit]
+		last := &cases[limit-1]
+		body["nextCursor"] = encodeCaseListCursor(caseListCursor{Sort: sortParam, Key: sort.key(last), ID: last.ID})
+	}
+
+	caseIDs := make([]uuid.UUID, 0, len(cases))
+	for i := range cases {
+		caseIDs = append(caseIDs, cases[i].ID)
+	}
+	access := &caseAccess{}
+	if len(caseIDs) > 0 {
+		var err error
+		if access, err = loadCaseAccess(h.db, user.ID, caseIDs...); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch cases"})
+			return
+		}
+	}
+
+	if view == caseListViewSummary {
+		payload := make([]caseSummaryResponse, 0, len(cases))
+		for i := range cases {
+			payload = append(payload, toCaseSummaryResponse(&cases[i], h.toCaseResponse(&cases[i], access.subject(&cases[i]))))
+		}
+		body["cases"] = payload
+	} else {
+		payload := make([]caseResponse, 0, len(cases))
+		for i := range cases {
+			payload = append(payload, h.toCaseResponse(&cases[i], access.subject(&cases[i])))
+		}
+		body["cases"] = payload
+	}
+
+	ctx.JSON(http.StatusOK, body)
+}
+
+// filterCaseList applies the case list filters: organizationId, status,
+// priority and matterType (each repeatable), lawyerId for cases the lawyer
+// is actively assigned to, and createdAfter, createdBefore, updatedAfter and
+// updatedBefore, each an RFC 3339 timestamp or a date. Lower bounds are
+// inclusive and upper bounds exclusive.
+func (h *CaseHandler) filterCaseList(ctx *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	if raw := strings.TrimSpace(ctx.Query("organizationId")); raw != "" {
+		orgID, err := uuid.Parse(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization id"})
+			return nil, false
+		}
+		query = query.Where("cases.organization_id = ?", orgID)
+	}
+
+	if statuses := lowerNonEmpty(ctx.QueryArray("status")); len(statuses) > 0 {
+		query = query.Where("LOWER(cases.status) IN ?", statuses)
+	}
+
+	if raw := ctx.QueryArray("priority"); len(raw) > 0 {
+		priorities := make([]string, 0, len(raw))
+		for _, value := range raw {
+			priority, ok := normaliseCasePriority(value)
+			if !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case priority"})
+				return nil, false
+			}
+			priorities = append(priorities, priority)
+		}
+		query = query.Where("cases.priority IN ?", priorities)
+	}
+
+	if matterTypes := lowerNonEmpty(ctx.QueryArray("matterType")); len(matterTypes) > 0 {
+		query = query.Where("LOWER(TRIM(cases.matter_type)) IN ?", matterTypes)
+	}
+
+	if raw := strings.TrimSpace(ctx.Query("lawyerId")); raw != "" {
+		lawyerID, err := uuid.Parse(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+			return nil, false
+		}
+		query = query.Where("cases.id IN (?)", h.db.Model(&models.CaseAssignment{}).Select("case_id").
+			Where("lawyer_id = ? AND status IN ?", lawyerID, models.ActiveAssignmentStatuses))
+	}
+
+	for _, bound := range []struct{ param, condition string }{
+		{"createdAfter", "cases.created_at >= ?"},
+		{"createdBefore", "cases.created_at < ?"},
+		{"updatedAfter", "cases.updated_at >= ?"},
+		{"updatedBefore", "cases.updated_at < ?"},
+	} {
+		raw := strings.TrimSpace(ctx.Query(bound.param))
+		if raw == "" {
+			continue
+		}
+		at, err := parseListTime(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.param})
+			return nil, false
+		}
+		query = query.Where(bound.condition, at)
+	}
+
+	return query, true
+}
+
+func toCaseSummaryResponse(model *models.Case, full caseResponse) caseSummaryResponse {
+	return caseSummaryResponse{
+		ID:              model.ID,
+		Organization:    full.Organization,
+		Name:            model.Name,
+		Priority:        model.Priority,
+		Status:          model.Status,
+		MatterType:      model.MatterType,
+		Owner:           model.Owner,
+		AssignedLawyers: full.AssignedLawyers,
+		Client:          full.Client,
+		Access:          full.Access,
+		Version:         model.Version,
+		CreatedAt:       model.CreatedAt,
+		UpdatedAt:       model.UpdatedAt,
+	}
+}
+
+func encodeCaseListCursor(cursor caseListCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(mustMarshalJSON(cursor)))
+}
+
+// decodeCaseListCursor reads a cursor and its sort key, failing when it is
+// malformed or was produced for another sort.
+func decodeCaseListCursor(raw, sortParam string, sort caseListSort) (caseListCursor, any, bool) {
+	var cursor caseListCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.Sort != sortParam || cursor.ID == uuid.Nil {
+		return caseListCursor{}, nil, false
+	}
+	key, err := sort.parseKey(cursor.Key)
+	if err != nil {
+		return caseListCursor{}, nil, false
+	}
+	return cursor, key, true
+}
+
+func parseCursorTime(raw string) (any, error) {
+	return time.Parse(time.RFC3339Nano, raw)
+}
+
+// parseListTime accepts an RFC 3339 timestamp or a date, read as midnight
+// UTC.
+func parseListTime(raw string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+func casePriorityRank(priority string) int {
+	switch priority {
+	case models.CasePriorityHigh:
+		return 3
+	case models.CasePriorityMedium:
+		return 2
+	case models.CasePriorityLow:
+		return 1
+	}
+	return 0
+}
+
+func lowerNonEmpty(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			lowered = append(lowered, value)
+		}
+	}
+	return lowered
+}
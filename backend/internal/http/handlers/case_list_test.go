@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"lexiflow/backend/internal/models"
+)
+
+func TestCaseListCursorRoundTrip(t *testing.T) {
+	caseModel := &models.Case{
+		ID:        uuid.New(),
+		Name:      "Acme v. Globex, \"phase 2\"",
+		Priority:  models.CasePriorityHigh,
+		CreatedAt: time.Date(2024, 3, 1, 9, 30, 0, 123456789, time.FixedZone("CET", 3600)),
+		UpdatedAt: time.Date(2024, 5, 17, 18, 0, 0, 1000, time.UTC),
+	}
+	want := map[string]any{
+		"created":  caseModel.CreatedAt,
+		"updated":  caseModel.UpdatedAt,
+		"name":     caseModel.Name,
+		"priority": 3,
+	}
+
+	for sortParam, sort := range caseListSorts {
+		raw := encodeCaseListCursor(caseListCursor{Sort: sortParam, Key: sort.key(caseModel), ID: caseModel.ID})
+		cursor, key, ok := decodeCaseListCursor(raw, sortParam, sort)
+		if !ok {
+			t.Errorf("%s: cursor %q did not decode", sortParam, raw)
+			continue
+		}
+		if cursor.ID != caseModel.ID || cursor.Sort != sortParam {
+			t.Errorf("%s: decoded %+v", sortParam, cursor)
+		}
+		switch expected := want[sortParam].(type) {
+		case time.Time:
+			if at, isTime := key.(time.Time); !isTime || !at.Equal(expected) {
+				t.Errorf("%s: key %v, want %v", sortParam, key, expected)
+			}
+		default:
+			if key != expected {
+				t.Errorf("%s: key %#v, want %#v", sortParam, key, expected)
+			}
+		}
+	}
+}
+
+func TestDecodeCaseListCursorRejectsInvalidCursors(t *testing.T) {
+	id := uuid.New()
+	encodeJSON := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	cases := []struct {
+		name, raw, sort string
+	}{
+		{"other sort", encodeCaseListCursor(caseListCursor{Sort: "name", Key: "Acme", ID: id}), "created"},
+		{"not base64", "not a cursor!", "name"},
+		{"not JSON", encodeJSON("name:Acme"), "name"},
+		{"missing id", encodeJSON(`{"s":"name","k":"Acme"}`), "name"},
+		{"invalid id", encodeJSON(`{"s":"name","k":"Acme","i":"42"}`), "name"},
+		{"invalid time key", encodeCaseListCursor(caseListCursor{Sort: "created", Key: "yesterday", ID: id}), "created"},
+		{"invalid priority key", encodeCaseListCursor(caseListCursor{Sort: "priority", Key: "High", ID: id}), "priority"},
+	}
+	for _, tc := range cases {
+		if _, _, ok := decodeCaseListCursor(tc.raw, tc.sort, caseListSorts[tc.sort]); ok {
+			t.Errorf("%s: cursor %q decoded", tc.name, tc.raw)
+		}
+	}
+}
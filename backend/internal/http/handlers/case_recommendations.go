@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+	"lexiflow/backend/internal/recommend"
+)
+
+const (
+	recommendationPageSize    = 10
+	recommendationMaxPageSize = 50
+)
+
+type lawyerRecommendationResponse struct {
+	Lawyer  lawyerProfileResponse `json:"lawyer"`
+	Score   int                   `json:"score"`
+	Factors []recommend.Factor    `json:"factors"`
+}
+
+type assignmentCount struct {
+	LawyerID uuid.UUID
+	Status   string
+	Count    int
+}
+
+// HandleRecommendLawyers ranks directory lawyers for the case, best first,
+// with the factors behind each score. Lawyers already on the case and those
+// who are unavailable are left out; limit and offset page the ranking.
+func (h *CaseHandler) HandleRecommendLawyers(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePage(ctx, recommendationPageSize, recommendationMaxPageSize)
+	if !ok {
+		return
+	}
+
+	criteria := recommend.CriteriaFor(caseModel)
+
+	var profiles []models.LawyerProfile
+	if err := h.db.Preload("User").
+		Joins("JOIN users ON users.id = lawyer_profiles.user_id").
+		Where("users.role = ? AND users.approval_status = ? AND users.suspended_at IS NULL",
+			models.UserRoleLawyer, models.ApprovalApproved).
+		Where("lawyer_profiles.availability <> ?", models.AvailabilityUnavailable).
+		Where("lawyer_profiles.user_id NOT IN (?)", h.db.Model(&models.CaseAssignment{}).Select("lawyer_id").
+			Where("case_id = ? AND status IN ?", caseID, models.ActiveAssignmentStatuses)).
+		Find(&profiles).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to recommend lawyers"})
+		return
+	}
+
+	candidates, err := h.recommendationCandidates(caseID, criteria, profiles)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to recommend lawyers"})
+		return
+	}
+
+	ranked := recommend.Rank(criteria, candidates)
+	page := ranked[min(offset, len(ranked)):min(offset+limit, len(ranked))]
+
+	byLawyer := make(map[uuid.UUID]*models.LawyerProfile, len(profiles))
+	for i := range profiles {
+		byLawyer[profiles[i].UserID] = &profiles[i]
+	}
+	pageProfiles := make([]models.LawyerProfile, 0, len(page))
+	for _, recommendation := range page {
+		pageProfiles = append(pageProfiles, *byLawyer[recommendation.LawyerID])
+	}
+	lawyers, err := toLawyerProfileResponses(h.db, pageProfiles)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to recommend lawyers"})
+		return
+	}
+
+	payload := make([]lawyerRecommendationResponse, 0, len(page))
+	for i, recommendation := range page {
+		payload = append(payload, lawyerRecommendationResponse{
+			Lawyer:  lawyers[i],
+			Score:   recommendation.Score,
+			Factors: recommendation.Factors,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"criteria": criteria, "recommendations": payload, "total": len(ranked)})
+}
+
+// recommendationCandidates attaches each lawyer's assignment history on other
+// cases to their profile: assignments by status and completed cases of the
+// same matter type.
+func (h *CaseHandler) recommendationCandidates(caseID uuid.UUID, criteria recommend.Criteria, profiles []models.LawyerProfile) ([]recommend.Candidate, error) {
+	candidates := make([]recommend.Candidate, 0, len(profiles))
+	if len(profiles) == 0 {
+		return candidates, nil
+	}
+
+	lawyerIDs := make([]uuid.UUID, 0, len(profiles))
+	for i := range profiles {
+		lawyerIDs = append(lawyerIDs, profiles[i].UserID)
+	}
+
+	var counts []assignmentCount
+	if err := h.db.Model(&models.CaseAssignment{}).
+		Select("lawyer_id, status, COUNT(*) AS count").
+		Where("lawyer_id IN ? AND case_id <> ?", lawyerIDs, caseID).
+		Group("lawyer_id, status").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	statuses := map[uuid.UUID]map[string]int{}
+	for _, count := range counts {
+		if statuses[count.LawyerID] == nil {
+			statuses[count.LawyerID] = map[string]int{}
+		}
+		statuses[count.LawyerID][count.Status] = count.Count
+	}
+
+	similar := map[uuid.UUID]int{}
+	if criteria.MatterType != "" {
+		var similarCounts []assignmentCount
+		if err := h.db.Model(&models.CaseAssignment{}).
+			Select("case_assignments.lawyer_id, COUNT(*) AS count").
+			Joins("JOIN cases ON cases.id = case_assignments.case_id").
+			Where("case_assignments.lawyer_id IN ? AND case_assignments.case_id <> ? AND case_assignments.status = ?",
+				lawyerIDs, caseID, models.AssignmentCompleted).
+			Where("LOWER(TRIM(cases.matter_type)) = ?", criteria.MatterType).
+			Group("case_assignments.lawyer_id").
+			Scan(&similarCounts).Error; err != nil {
+			return nil, err
+		}
+		for _, count := range similarCounts {
+			similar[count.LawyerID] = count.Count
+		}
+	}
+
+	for i := range profiles {
+		candidates = append(candidates, recommend.Candidate{
+			Profile:          &profiles[i],
+			Statuses:         statuses[profiles[i].UserID],
+			SimilarCompleted: similar[profiles[i].UserID],
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+const (
+	searchPageSize       = 20
+	searchMaxPageSize    = 50
+	searchMaxQueryLength = 256
+	searchTypeCases      = "cases"
+	searchTypeDocuments  = "documents"
+	// searchMaxDetailHighlights bounds the metadata excerpts of one case.
+	searchMaxDetailHighlights = 3
+	// documentTextLimit bounds the text extracted from an upload, keeping its
+	// search vector well under PostgreSQL's tsvector size limit.
+	documentTextLimit = 256 << 10
+	// PostgreSQL marks highlights with these private-use characters so the
+	// text around them can be HTML-escaped before they become <mark> tags.
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+var (
+	highlightMarkers        = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+	highlightFieldOptions   = highlightMarkers + ", HighlightAll=true"
+	highlightExcerptOptions = highlightMarkers + `, MinWords=10, MaxWords=30, MaxFragments=2, FragmentDelimiter=" … "`
+	highlightMarkup         = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+)
+
+type caseSearchRow struct {
+	ID                 uuid.UUID
+	Name               string
+	Status             string
+	Priority           string
+	MatterType         string
+	UpdatedAt          time.Time
+	Rank               float64
+	NameHeadline       string
+	MatterTypeHeadline string
+	SummaryHeadline    string
+	DetailsHeadline    datatypes.JSON
+}
+
+type documentSearchRow struct {
+	ID                  uuid.UUID
+	CaseID              uuid.UUID
+	Title               string
+	Category            string
+	Visibility          string
+	UploadedByID        *uuid.UUID
+	UpdatedAt           time.Time
+	Rank                float64
+	TitleHeadline       string
+	DescriptionHeadline string
+	ContentHeadline     string
+}
+
+// searchHighlight is an excerpt of a matching field, HTML-escaped, with the
+// matched terms wrapped in <mark>.
+type searchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+type caseSearchResult struct {
+	ID         uuid.UUID         `json:"id"`
+	Name       string            `json:"name"`
+	Status     string            `json:"status"`
+	Priority   string            `json:"priority"`
+	MatterType string            `json:"matterType"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+	Rank       float64           `json:"rank"`
+	Highlights []searchHighlight `json:"highlights"`
+}
+
+type documentSearchResult struct {
+	ID         uuid.UUID         `json:"id"`
+	Name       string            `json:"name"`
+	Category   string            `json:"category"`
+	Visibility string            `json:"visibility"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
+	Case       caseOrgResponse   `json:"case"`
+	Rank       float64           `json:"rank"`
+	Highlights []searchHighlight `json:"highlights"`
+}
+
+// HandleSearch runs a full-text query (web search syntax: quoted phrases, or
+// and -term) over the cases and documents the caller may view, best matches
+// first. type limits the search to cases or documents; limit and offset page
+// each kind separately.
+func (h *CaseHandler) HandleSearch(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" || len(q) > searchMaxQueryLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "q must be between 1 and 256 characters"})
+		return
+	}
+
+	searchType := strings.TrimSpace(ctx.Query("type"))
+	if searchType != "" && searchType != searchTypeCases && searchType != searchTypeDocuments {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search type"})
+		return
+	}
+
+	limit, offset, ok := parsePage(ctx, searchPageSize, searchMaxPageSize)
+	if !ok {
+		return
+	}
+
+	body := gin.H{"query": q}
+	totals := gin.H{}
+	if searchType != searchTypeDocuments {
+		results, total, err := h.searchCases(user, q, limit, offset)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to search"})
+			return
+		}
+		body["cases"], totals["cases"] = results, total
+	}
+	if searchType != searchTypeCases {
+		results, total, err := h.searchDocuments(user, q, limit, offset)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to search"})
+			return
+		}
+		body["documents"], totals["documents"] = results, total
+	}
+	body["totals"] = totals
+
+	ctx.JSON(http.StatusOK, body)
+}
+
+// searchCases matches q against the cases user can open with GET /cases/:id.
+func (h *CaseHandler) searchCases(user *models.User, q string, limit, offset int) ([]caseSearchResult, int64, error) {
+	query := h.db.Model(&models.Case{}).
+		Joins("CROSS JOIN websearch_to_tsquery('english', ?) AS search_query", q).
+		Where(models.CaseSearchVector+" @@ search_query").
+		Where("cases.id IN (?)", visibleCases(h.db, user.ID))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []caseSearchRow
+	if err := query.
+		Select("cases.id, cases.name, cases.status, cases.priority, cases.matter_type, cases.updated_at, "+
+			"ts_rank_cd("+models.CaseSearchVector+", search_query) AS rank, "+
+			"ts_headline('english', cases.name, search_query, ?) AS name_headline, "+
+			"ts_headline('english', coalesce(cases.matter_type, ''), search_query, ?) AS matter_type_headline, "+
+			"ts_headline('english', coalesce(cases.summary, ''), search_query, ?) AS summary_headline, "+
+			"ts_headline('english', coalesce(cases.metadata -> 'stakeholders', '[]'::jsonb) || "+
+			"coalesce(cases.metadata -> 'tasks', '[]'::jsonb), search_query, ?) AS details_headline",
+			highlightFieldOptions, highlightFieldOptions, highlightExcerptOptions, highlightExcerptOptions).
+		Order("rank DESC").Order("cases.updated_at DESC").Order("cases.id").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]caseSearchResult, 0, len(rows))
+	for _, row := range rows {
+		highlights := collectHighlights(nil, "name", row.NameHeadline)
+		highlights = collectHighlights(highlights, "matterType", row.MatterTypeHeadline)
+		highlights = collectHighlights(highlights, "summary", row.SummaryHeadline)
+		var details any
+		if json.Unmarshal(row.DetailsHeadline, &details) == nil {
+			detailHighlights := collectHighlights(nil, "metadata", details)
+			highlights = append(highlights, detailHighlights[:min(len(detailHighlights), searchMaxDetailHighlights)]...)
+		}
+		results = append(results, caseSearchResult{
+			ID:         row.ID,
+			Name:       row.Name,
+			Status:     row.Status,
+			Priority:   row.Priority,
+			MatterType: row.MatterType,
+			UpdatedAt:  row.UpdatedAt,
+			Rank:       row.Rank,
+			Highlights: highlights,
+		})
+	}
+	return results, total, nil
+}
+
+// searchDocuments matches q against the documents user may view, applying
+// the document policy once more to the page found.
+func (h *CaseHandler) searchDocuments(user *models.User, q string, limit, offset int) ([]documentSearchResult, int64, error) {
+	query := h.db.Model(&models.CaseDocument{}).
+		Joins("CROSS JOIN websearch_to_tsquery('english', ?) AS search_query", q).
+		Where(models.DocumentSearchVector+" @@ search_query").
+		Where("case_documents.id IN (?)", viewableDocuments(h.db, user.ID))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []documentSearchRow
+	if err := query.
+		Select("case_documents.id, case_documents.case_id, case_documents.title, case_documents.category, "+
+			"case_documents.visibility, case_documents.uploaded_by_id, case_documents.updated_at, "+
+			"ts_rank_cd("+models.DocumentSearchVector+", search_query) AS rank, "+
+			"ts_headline('english', case_documents.title, search_query, ?) AS title_headline, "+
+			"ts_headline('english', coalesce(case_documents.description, ''), search_query, ?) AS description_headline, "+
+			"ts_headline('english', coalesce(case_documents.content_text, ''), search_query, ?) AS content_headline",
+			highlightFieldOptions, highlightExcerptOptions, highlightExcerptOptions).
+		Order("rank DESC").Order("case_documents.updated_at DESC").Order("case_documents.id").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]documentSearchResult, 0, len(rows))
+	if len(rows) == 0 {
+		return results, total, nil
+	}
+
+	caseIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		caseIDs = append(caseIDs, row.CaseID)
+	}
+	var cases []models.Case
+	if err := h.db.Select("id, organization_id, name").Where("id IN ?", caseIDs).Find(&cases).Error; err != nil {
+		return nil, 0, err
+	}
+	casesByID := make(map[uuid.UUID]*models.Case, len(cases))
+	for i := range cases {
+		casesByID[cases[i].ID] = &cases[i]
+	}
+	access, err := loadCaseAccess(h.db, user.ID, caseIDs...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, row := range rows {
+		caseModel := casesByID[row.CaseID]
+		document := models.CaseDocument{ID: row.ID, CaseID: row.CaseID, Visibility: row.Visibility, UploadedByID: row.UploadedByID}
+		if caseModel == nil || !policy.Document(access.subject(caseModel), &document, policy.ActionView) {
+			continue
+		}
+		highlights := collectHighlights(nil, "name", row.TitleHeadline)
+		highlights = collectHighlights(highlights, "description", row.DescriptionHeadline)
+		highlights = collectHighlights(highlights, "content", row.ContentHeadline)
+		results = append(results, documentSearchResult{
+			ID:         row.ID,
+			Name:       row.Title,
+			Category:   row.Category,
+			Visibility: row.Visibility,
+			UpdatedAt:  row.UpdatedAt,
+			Case:       caseOrgResponse{ID: caseModel.ID, Name: caseModel.Name},
+			Rank:       row.Rank,
+			Highlights: highlights,
+		})
+	}
+	return results, total, nil
+}
+
+// collectHighlights appends the excerpts of headline that contain a match:
+// headline itself when it is a string, or every matching string nested in
+// it when it is decoded JSON.
+func collectHighlights(highlights []searchHighlight, field string, headline any) []searchHighlight {
+	if highlights == nil {
+		highlights = []searchHighlight{}
+	}
+	switch value := headline.(type) {
+	case string:
+		if strings.Contains(value, highlightStart) {
+			highlights = append(highlights, searchHighlight{Field: field, Snippet: highlightMarkup.Replace(html.EscapeString(value))})
+		}
+	case []any:
+		for _, item := range value {
+			highlights = collectHighlights(highlights, field, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			highlights = collectHighlights(highlights, field, value[key])
+		}
+	}
+	return highlights
+}
+
+// extractDocumentText returns the text of a stored upload for search, up to
+// documentTextLimit. Only plain-text formats are read; anything else yields
+// no text.
+func extractDocumentText(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, documentTextLimit))
+	if err != nil || !strings.HasPrefix(http.DetectContentType(data), "text/") {
+		return ""
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(string(data), ""), "\x00", "")
+}
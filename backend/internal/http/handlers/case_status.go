@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+	"lexiflow/backend/internal/workflow"
+)
+
+const auditActionCaseStatus = "case.status_change"
+
+type changeCaseStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+type caseStatusChangeResponse struct {
+	From          string     `json:"from,omitempty"`
+	To            string     `json:"to"`
+	Reason        string     `json:"reason,omitempty"`
+	ChangedBy     *uuid.UUID `json:"changedBy,omitempty"`
+	ChangedByName string     `json:"changedByName,omitempty"`
+	ChangedAt     time.Time  `json:"changedAt"`
+}
+
+var errCaseChanged = errors.New("case changed")
+
+// HandleChangeCaseStatus moves a case to another status of its workflow,
+// recording who did it and why. Like other case mutations it requires
+// If-Match with the case's current ETag, so a change made from a stale view
+// is refused rather than overwriting a concurrent one.
+func (h *CaseHandler) HandleChangeCaseStatus(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	var req changeCaseStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status payload"})
+		return
+	}
+
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionEdit)
+	if !ok {
+		return
+	}
+
+	ifMatch, ok := requireIfMatch(ctx)
+	if !ok {
+		return
+	}
+	if !ifMatchVersion(ifMatch, caseModel.Version) {
+		h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
+		return
+	}
+
+	status, ok := h.resolveCaseStatus(ctx, caseModel, caseModel.MatterType, req.Status, user)
+	if !ok {
+		return
+	}
+	if status == caseModel.Status {
+		h.respondWithCase(ctx, caseID, user, http.StatusOK)
+		return
+	}
+
+	from, reason := caseModel.Status, truncateString(strings.TrimSpace(req.Reason), 512)
+	if err := h.saveCaseUpdates(caseModel, map[string]any{"status": status}, user.ID, reason); err != nil {
+		if errors.Is(err, errCaseChanged) {
+			h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to change case status"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionCaseStatus, &user.ID, nil, map[string]any{
+		"caseId": caseID.String(),
+		"from":   from,
+		"to":     status,
+		"reason": reason,
+	})
+
+	h.respondWithCase(ctx, caseID, user, http.StatusOK)
+}
+
+// resolveCaseStatus matches requested against the workflow of matterType and
+// checks that user may move the case there, answering 400 for an unknown
+// status, 409 for a transition the workflow lacks and 403 for one their role
+// may not make. Requesting the current status is always allowed.
+func (h *CaseHandler) resolveCaseStatus(ctx *gin.Context, caseModel *models.Case, matterType, requested string, user *models.User) (string, bool) {
+	flow := h.workflows.For(matterType)
+	status, ok := flow.Status(requested)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown case status; expected one of " + strings.Join(flow.Statuses, ", ")})
+		return "", false
+	}
+	if status == caseModel.Status {
+		return status, true
+	}
+
+	switch err := flow.Check(caseModel.Status, status, user.Role); {
+	case err == nil:
+		return status, true
+	case errors.Is(err, workflow.ErrRole):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your role cannot move this case from " + caseModel.Status + " to " + status})
+	default:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Cases cannot move from " + caseModel.Status + " to " + status})
+	}
+	return "", false
+}
+
+// permitMatterTypeChange stops a new matter type from moving the case through
+// its workflow unchecked. A case whose status is not part of the new workflow
+// behaves as if it were in that workflow's initial status, so the change is
+// only allowed when the caller could move the case there in its current one.
+func (h *CaseHandler) permitMatterTypeChange(ctx *gin.Context, caseModel *models.Case, matterType string, user *models.User) bool {
+	target := h.workflows.For(matterType)
+	if _, ok := target.Status(caseModel.Status); ok {
+		return true
+	}
+	if err := h.workflows.For(caseModel.MatterType).Check(caseModel.Status, target.Initial(), user.Role); err != nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": "Changing the matter type would move the case from " + caseModel.Status + " to " + target.Initial(),
+		})
+		return false
+	}
+	return true
+}
+
+// saveCaseUpdates applies updates to the case provided it is still at the
+// version it was read at, bumping the version, and records the status change
+// when updates move the case to another status. errCaseChanged reports that
+// someone else saved the case first.
+func (h *CaseHandler) saveCaseUpdates(caseModel *models.Case, updates map[string]any, userID uuid.UUID, reason string) error {
+	from, version := caseModel.Status, caseModel.Version
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		updates["version"] = gorm.Expr("version + 1")
+		result := tx.Model(caseModel).Where("version = ?", version).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCaseChanged
+		}
+
+		status, ok := updates["status"].(string)
+		if !ok || status == from {
+			return nil
+		}
+		return tx.Create(&models.CaseStatusChange{
+			CaseID:      caseModel.ID,
+			FromStatus:  from,
+			ToStatus:    status,
+			Reason:      reason,
+			ChangedByID: &userID,
+		}).Error
+	})
+}
+
+// nextCaseStatuses lists the statuses the account behind subject may move the
+// case to, none when they may not edit it.
+func (h *CaseHandler) nextCaseStatuses(model *models.Case, subject policy.Subject, role string) []string {
+	if !policy.Case(subject, model, policy.ActionEdit) {
+		return nil
+	}
+	return h.workflows.For(model.MatterType).Next(model.Status, role)
+}
+
+func toCaseStatusChangeResponses(changes []models.CaseStatusChange) []caseStatusChangeResponse {
+	responses := make([]caseStatusChangeResponse, 0, len(changes))
+	for _, change := range changes {
+		response := caseStatusChangeResponse{
+			From:      change.FromStatus,
+			To:        change.ToStatus,
+			Reason:    change.Reason,
+			ChangedBy: change.ChangedByID,
+			ChangedAt: change.CreatedAt,
+		}
+		if change.ChangedBy != nil {
+			response.ChangedByName = change.ChangedBy.CompanyName
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+)
+
+const (
+	auditActionCaseUpdate = "case.update"
+	mergePatchContentType = "application/merge-patch+json"
+)
+
+// caseTextFields maps the optional text fields of a case patch onto their
+// columns and maximum lengths; zero means unbounded. null clears them.
+var caseTextFields = map[string]struct {
+	column string
+	max    int
+}{
+	"matterType": {"matter_type", 255},
+	"owner":      {"owner", 255},
+	"summary":    {"summary", 0},
+	"aiFocus":    {"ai_focus", 255},
+}
+
+// HandleUpdateCase edits a case with a JSON Merge Patch (RFC 7386), provided
+// If-Match carries the case's current ETag. Fields left out are unchanged.
+// name, priority and status cannot be null; the other text fields are cleared
+// by null. status must be a transition the case's workflow allows the caller,
+// judged by the matter type the patch leaves it with, and a new matter type
+// whose workflow lacks the case's status must not skip a transition the
+// caller could not make (see permitMatterTypeChange). aiContext and metadata
+// are merged key by key: nested objects merge recursively, null removes a
+// key, and null for the whole map clears it.
+func (h *CaseHandler) HandleUpdateCase(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	if contentType := ctx.ContentType(); contentType != "" && contentType != mergePatchContentType && contentType != "application/json" {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Case updates must be sent as " + mergePatchContentType})
+		return
+	}
+
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionEdit)
+	if !ok {
+		return
+	}
+
+	ifMatch, ok := requireIfMatch(ctx)
+	if !ok {
+		return
+	}
+	if !ifMatchVersion(ifMatch, caseModel.Version) {
+		h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
+		return
+	}
+
+	var patch map[string]any
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&patch); err != nil || patch == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Case patch must be a JSON object"})
+		return
+	}
+
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	updates := map[string]any{}
+	requestedStatus := ""
+	for _, field := range fields {
+		value := patch[field]
+		switch field {
+		case "name":
+			name, ok := value.(string)
+			if name = strings.TrimSpace(name); !ok || name == "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "name must be a non-empty string"})
+				return
+			}
+			updates["name"] = truncateString(name, 255)
+		case "priority":
+			raw, _ := value.(string)
+			priority, ok := normaliseCasePriority(raw)
+			if !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case priority"})
+				return
+			}
+			updates["priority"] = priority
+		case "status":
+			status, ok := value.(string)
+			if status = strings.TrimSpace(status); !ok || status == "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "status must be a non-empty string"})
+				return
+			}
+			requestedStatus = status
+		case "matterType", "owner", "summary", "aiFocus":
+			text, ok := value.(string)
+			if !ok && value != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": field + " must be a string or null"})
+				return
+			}
+			text = strings.TrimSpace(text)
+			if limit := caseTextFields[field].max; limit > 0 {
+				text = truncateString(text, limit)
+			}
+			updates[caseTextFields[field].column] = text
+		case "aiContext", "metadata":
+			column, current := "ai_context", caseModel.AIContext
+			if field == "metadata" {
+				column, current = "metadata", caseModel.Metadata
+			}
+			switch value.(type) {
+			case nil:
+				updates[column] = nil
+			case map[string]any:
+				merged, _ := mergePatch(map[string]any(current), value).(map[string]any)
+				updates[column] = datatypes.JSONMap(merged)
+			default:
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": field + " must be an object or null"})
+				return
+			}
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or read-only case field: " + field})
+			return
+		}
+	}
+
+	if matterType, ok := updates["matter_type"].(string); ok && matterType != caseModel.MatterType {
+		if !h.permitMatterTypeChange(ctx, caseModel, matterType, user) {
+			return
+		}
+	}
+	if requestedStatus != "" {
+		matterType := caseModel.MatterType
+		if patched, ok := updates["matter_type"].(string); ok {
+			matterType = patched
+		}
+		status, ok := h.resolveCaseStatus(ctx, caseModel, matterType, requestedStatus, user)
+		if !ok {
+			return
+		}
+		updates["status"] = status
+	}
+
+	if len(updates) > 0 {
+		if err := h.saveCaseUpdates(caseModel, updates, user.ID, ""); err != nil {
+			if errors.Is(err, errCaseChanged) {
+				h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update case"})
+			return
+		}
+
+		recordAudit(h.db, ctx, auditActionCaseUpdate, &user.ID, nil, map[string]any{
+			"caseId": caseID.String(),
+			"fields": fields,
+		})
+	}
+
+	h.respondWithCase(ctx, caseID, user, http.StatusOK)
+}
+
+// mergePatch applies an RFC 7386 merge patch to target: a patch object is
+// merged key by key, null removing the key, and any other value replaces the
+// target outright.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	result := map[string]any{}
+	if targetObject, ok := target.(map[string]any); ok {
+		for key, value := range targetObject {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = mergePatch(result[key], value)
+	}
+	return result
+}
+
+// normaliseCasePriority matches priority case-insensitively against
+// models.CasePriorities.
+func normaliseCasePriority(priority string) (string, bool) {
+	for _, candidate := range models.CasePriorities {
+		if strings.EqualFold(candidate, strings.TrimSpace(priority)) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
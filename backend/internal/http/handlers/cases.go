@@ -3,6 +3,7 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,16 +14,23 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"lexiflow/backend/internal/config"
+	"lexiflow/backend/internal/mailer"
 	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/policy"
+	"lexiflow/backend/internal/workflow"
 )
 
 type CaseHandler struct {
-	db        *gorm.DB
-	auth      *AuthHandler
-	uploadDir string
+	db         *gorm.DB
+	mailer     mailer.Mailer
+	uploadDir  string
+	appBaseURL string
+	workflows  workflow.Set
 }
 
 type createCaseRequest struct {
+	OrganizationID    string                `json:"organizationId"`
 	Name              string                `json:"name" binding:"required"`
 	Priority          string                `json:"priority"`
 	Status            string                `json:"status"`
@@ -46,6 +54,7 @@ type caseDocumentPayload struct {
 	Description string `json:"description"`
 	Status      string `json:"status"`
 	Category    string `json:"category"`
+	Visibility  string `json:"visibility"`
 	StoragePath string `json:"storagePath"`
 }
 
@@ -55,103 +64,173 @@ type attachDocumentRequest struct {
 	Description string `json:"description"`
 	Status      string `json:"status"`
 	Category    string `json:"category"`
+	Visibility  string `json:"visibility"`
 	StoragePath string `json:"storagePath"`
 }
 
+type updateDocumentRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Status      *string `json:"status"`
+	Category    *string `json:"category"`
+	Visibility  *string `json:"visibility"`
+}
+
 type assignLawyerRequest struct {
 	LawyerID string `json:"lawyerId" binding:"required"`
+	Role     string `json:"role"`
 	Notes    string `json:"notes"`
 }
 
+type assignFirmRequest struct {
+	FirmID string `json:"firmId" binding:"required"`
+	Notes  string `json:"notes"`
+}
+
 type caseResponse struct {
-	ID              uuid.UUID              `json:"id"`
-	Name            string                 `json:"name"`
-	Priority        string                 `json:"priority"`
-	Status          string                 `json:"status"`
-	MatterType      string                 `json:"matterType"`
-	Owner           string                 `json:"owner"`
-	Summary         string                 `json:"summary"`
-	AIFocus         string                 `json:"aiFocus"`
-	AIContext       map[string]any         `json:"aiContext,omitempty"`
-	Metadata        map[string]any         `json:"metadata,omitempty"`
-	Documents       []caseDocumentResponse `json:"documents"`
-	AssignedLawyers []caseLawyerResponse   `json:"assignedLawyers"`
-	Client          *caseClientResponse    `json:"client,omitempty"`
-	CreatedAt       time.Time              `json:"createdAt"`
-	UpdatedAt       time.Time              `json:"updatedAt"`
+	ID                 uuid.UUID                  `json:"id"`
+	Organization       *caseOrgResponse           `json:"organization,omitempty"`
+	Name               string                     `json:"name"`
+	Priority           string                     `json:"priority"`
+	Status             string                     `json:"status"`
+	MatterType         string                     `json:"matterType"`
+	Owner              string                     `json:"owner"`
+	Summary            string                     `json:"summary"`
+	AIFocus            string                     `json:"aiFocus"`
+	AIContext          map[string]any             `json:"aiContext,omitempty"`
+	Metadata           map[string]any             `json:"metadata,omitempty"`
+	Documents          []caseDocumentResponse     `json:"documents"`
+	AssignedLawyers    []caseLawyerResponse       `json:"assignedLawyers"`
+	AssignedFirms      []caseFirmResponse         `json:"assignedFirms"`
+	Collaborators      []caseClientResponse       `json:"collaborators"`
+	PendingInvitations []caseInvitationResponse   `json:"pendingInvitations,omitempty"`
+	Client             *caseClientResponse        `json:"client,omitempty"`
+	StatusHistory      []caseStatusChangeResponse `json:"statusHistory,omitempty"`
+	NextStatuses       []string                   `json:"nextStatuses,omitempty"`
+	Access             caseAccessResponse         `json:"access"`
+	Version            int64                      `json:"version"`
+	CreatedAt          time.Time                  `json:"createdAt"`
+	UpdatedAt          time.Time                  `json:"updatedAt"`
+}
+
+// caseAccessResponse tells the caller how they relate to the case and what
+// the policy lets them do with it. Proposed is set for a lawyer whose
+// assignment awaits their answer.
+type caseAccessResponse struct {
+	OrganizationRole string          `json:"organizationRole,omitempty"`
+	CaseRole         string          `json:"caseRole,omitempty"`
+	Proposed         bool            `json:"proposed,omitempty"`
+	Permissions      []policy.Action `json:"permissions"`
 }
 
 type caseDocumentResponse struct {
-	ID          uuid.UUID `json:"id"`
-	CaseID      uuid.UUID `json:"caseId"`
-	Name        string    `json:"name"`
-	Owner       string    `json:"owner"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	Category    string    `json:"category"`
-	StoragePath string    `json:"storagePath"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID           uuid.UUID  `json:"id"`
+	CaseID       uuid.UUID  `json:"caseId"`
+	Name         string     `json:"name"`
+	Owner        string     `json:"owner"`
+	Description  string     `json:"description"`
+	Status       string     `json:"status"`
+	Category     string     `json:"category"`
+	Visibility   string     `json:"visibility"`
+	UploadedByID *uuid.UUID `json:"uploadedBy,omitempty"`
+	StoragePath  string     `json:"storagePath"`
+	Version      int64      `json:"version"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
 }
 
+type caseOrgResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// caseClientResponse describes a client account: the member who opened the
+// case, or a collaborator together with their case role.
 type caseClientResponse struct {
 	ID          uuid.UUID `json:"id"`
 	CompanyName string    `json:"companyName"`
 	Email       string    `json:"email"`
+	Role        string    `json:"role,omitempty"`
 }
 
 type caseLawyerResponse struct {
-	ID          uuid.UUID `json:"id"`
-	CompanyName string    `json:"companyName"`
-	Email       string    `json:"email"`
-	AssignedAt  time.Time `json:"assignedAt"`
-	Notes       string    `json:"notes,omitempty"`
+	ID           uuid.UUID  `json:"id"`
+	CompanyName  string     `json:"companyName"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"statusReason,omitempty"`
+	FirmID       *uuid.UUID `json:"firmId,omitempty"`
+	AssignedAt   time.Time  `json:"assignedAt"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+	DeclinedAt   *time.Time `json:"declinedAt,omitempty"`
+	WithdrawnAt  *time.Time `json:"withdrawnAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+	RemovedAt    *time.Time `json:"removedAt,omitempty"`
+	RemovedBy    *uuid.UUID `json:"removedBy,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
 }
 
-func NewCaseHandler(db *gorm.DB, auth *AuthHandler) *CaseHandler {
-	return &CaseHandler{db: db, auth: auth}
+type caseFirmResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"statusReason,omitempty"`
+	AssignedAt   time.Time  `json:"assignedAt"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+	DeclinedAt   *time.Time `json:"declinedAt,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
 }
 
-func (h *CaseHandler) RegisterRoutes(router *gin.RouterGroup) {
-	cases := router.Group("/cases")
-	{
-		cases.GET("", h.handleListCases)
-		cases.POST("", h.handleCreateCase)
-		cases.GET("/:id", h.handleGetCase)
-		cases.DELETE("/:id", h.handleDeleteCase)
-		cases.POST("/:id/assign", h.handleAssignLawyer)
-		cases.POST("/:id/documents", h.handleAttachDocument)
-		cases.POST("/:id/documents/upload", h.handleUploadDocument)
-		cases.DELETE("/:id/documents/:documentId", h.handleDeleteDocument)
-		cases.GET("/:id/documents/:documentId/download", h.handleDownloadDocument)
+func NewCaseHandler(db *gorm.DB, cfg config.Config, mail mailer.Mailer) *CaseHandler {
+	return &CaseHandler{
+		db:         db,
+		mailer:     mail,
+		uploadDir:  cfg.UploadDir,
+		appBaseURL: cfg.AppBaseURL,
+		workflows:  cfg.CaseWorkflows,
 	}
 }
 
-func (h *CaseHandler) handleCreateCase(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
+func (h *CaseHandler) HandleCreateCase(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req createCaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case payload"})
 		return
 	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can create cases"})
+
+	priority, ok := normaliseCasePriority(defaultString(req.Priority, models.CasePriorityMedium))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case priority"})
 		return
 	}
 
-	var req createCaseRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case payload"})
+	flow := h.workflows.For(req.MatterType)
+	status := flow.Initial()
+	if strings.TrimSpace(req.Status) != "" {
+		if requested, ok := flow.Status(req.Status); !ok || requested != status {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "New cases start in " + status})
+			return
+		}
+	}
+
+	membership, ok := h.resolveCaseOrganization(ctx, user, req.OrganizationID)
+	if !ok {
 		return
 	}
 
 	caseModel := models.Case{
-		UserID:     user.ID,
-		Name:       strings.TrimSpace(req.Name),
-		Priority:   defaultString(req.Priority, "Medium"),
-		Status:     defaultString(req.Status, "Draft"),
-		MatterType: strings.TrimSpace(req.MatterType),
-		Owner:      strings.TrimSpace(req.Owner),
-		Summary:    strings.TrimSpace(req.Summary),
-		AIFocus:    strings.TrimSpace(req.AIFocus),
+		OrganizationID: membership.OrganizationID,
+		UserID:         user.ID,
+		Name:           strings.TrimSpace(req.Name),
+		Priority:       priority,
+		Status:         status,
+		MatterType:     strings.TrimSpace(req.MatterType),
+		Owner:          strings.TrimSpace(req.Owner),
+		Summary:        strings.TrimSpace(req.Summary),
+		AIFocus:        strings.TrimSpace(req.AIFocus),
 	}
 
 	if len(req.AIContext) > 0 {
@@ -181,61 +260,45 @@ func (h *CaseHandler) handleCreateCase(ctx *gin.Context) {
 	}
 
 	documents := make([]models.CaseDocument, 0, len(req.Documents)+len(req.PersonalDocuments))
+	addDocument := func(payload caseDocumentPayload, category string) bool {
+		document, ok := toCaseDocumentModel(payload, category)
+		if !ok {
+			return false
+		}
+		document.UploadedByID = &user.ID
+		documents = append(documents, document)
+		return true
+	}
 	for _, doc := range req.Documents {
-		documents = append(documents, toCaseDocumentModel(doc, "case"))
+		if !addDocument(doc, "case") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document visibility"})
+			return
+		}
 	}
 	for _, doc := range req.PersonalDocuments {
-		documents = append(documents, toCaseDocumentModel(doc, "personal"))
+		if !addDocument(doc, "personal") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document visibility"})
+			return
+		}
 	}
 	caseModel.Documents = documents
+	caseModel.StatusHistory = []models.CaseStatusChange{{ToStatus: status, ChangedByID: &user.ID}}
 
-	if err := h.db.Create(&caseModel).Error; err != nil {
+	if err := h.db.Omit("Organization").Create(&caseModel).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create case"})
 		return
 	}
+	caseModel.Organization = membership.Organization
 
-	ctx.JSON(http.StatusCreated, toCaseResponse(&caseModel, false))
-}
-
-func (h *CaseHandler) handleListCases(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
-		return
-	}
-
-	query := h.db.Model(&models.Case{}).
-		Preload("Documents").
-		Preload("Assignments.Lawyer").
-		Preload("User").
-		Order("cases.created_at DESC")
-
-	if user.Role == models.UserRoleLawyer {
-		query = query.Joins("JOIN case_assignments ON case_assignments.case_id = cases.id").
-			Where("case_assignments.lawyer_id = ?", user.ID).
-			Distinct("cases.id")
-	} else {
-		query = query.Where("cases.user_id = ?", user.ID)
-	}
-
-	var cases []models.Case
-	if err := query.Find(&cases).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch cases"})
-		return
-	}
-
-	payload := make([]caseResponse, 0, len(cases))
-	for i := range cases {
-		payload = append(payload, toCaseResponse(&cases[i], user.Role == models.UserRoleLawyer))
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{"cases": payload})
+	subject := policy.Subject{UserID: user.ID, OrgRole: membership.Role}
+	resp := h.toCaseResponse(&caseModel, subject)
+	resp.NextStatuses = h.nextCaseStatuses(&caseModel, subject, user.Role)
+	ctx.Header("ETag", versionETag(caseModel.Version))
+	ctx.JSON(http.StatusCreated, resp)
 }
 
-func (h *CaseHandler) handleGetCase(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
-		return
-	}
+func (h *CaseHandler) HandleGetCase(ctx *gin.Context) {
+	user := currentUser(ctx)
 
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
@@ -243,18 +306,24 @@ func (h *CaseHandler) handleGetCase(ctx *gin.Context) {
 		return
 	}
 
+	h.respondWithCase(ctx, caseID, user, http.StatusOK)
+}
+
+// respondWithCase loads the case with everything its response shows and
+// writes it as seen by user, with its ETag. A 409 or 412 status reports the
+// case as it stands after someone else changed it.
+func (h *CaseHandler) respondWithCase(ctx *gin.Context, caseID uuid.UUID, user *models.User, status int) {
 	query := h.db.Model(&models.Case{}).
 		Preload("Documents").
 		Preload("Assignments.Lawyer").
+		Preload("FirmAssignments.Firm").
+		Preload("Collaborators.User").
+		Preload("Invitations", "accepted_at IS NULL AND expires_at > ?", time.Now().UTC()).
 		Preload("User").
-		Where("cases.id = ?", caseID)
-
-	if user.Role == models.UserRoleLawyer {
-		query = query.Joins("JOIN case_assignments ON case_assignments.case_id = cases.id").
-			Where("case_assignments.lawyer_id = ?", user.ID)
-	} else {
-		query = query.Where("cases.user_id = ?", user.ID)
-	}
+		Preload("Organization").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("case_status_changes.created_at") }).
+		Preload("StatusHistory.ChangedBy").
+		Where("cases.id = ? AND cases.id IN (?)", caseID, visibleCases(h.db, user.ID))
 
 	var caseModel models.Case
 	if err := query.First(&caseModel).Error; err != nil {
@@ -266,18 +335,25 @@ func (h *CaseHandler) handleGetCase(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"case": toCaseResponse(&caseModel, user.Role == models.UserRoleLawyer)})
-}
-
-func (h *CaseHandler) handleDeleteCase(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
+	access, err := loadCaseAccess(h.db, user.ID, caseModel.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch case"})
 		return
 	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can delete cases"})
-		return
+
+	subject := access.subject(&caseModel)
+	resp := h.toCaseResponse(&caseModel, subject)
+	resp.NextStatuses = h.nextCaseStatuses(&caseModel, subject, user.Role)
+	body := gin.H{"case": resp}
+	if status == http.StatusPreconditionFailed || status == http.StatusConflict {
+		body["error"] = "Case was changed since you loaded it"
 	}
+	ctx.Header("ETag", versionETag(caseModel.Version))
+	ctx.JSON(status, body)
+}
+
+func (h *CaseHandler) HandleDeleteCase(ctx *gin.Context) {
+	user := currentUser(ctx)
 
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
@@ -285,36 +361,43 @@ func (h *CaseHandler) handleDeleteCase(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.db.Where("id = ? AND user_id = ?", caseID, user.ID).Delete(&models.Case{}).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to delete case"})
+	caseModel, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionDelete)
+	if !ok {
 		return
 	}
 
-	ctx.Status(http.StatusNoContent)
-}
-
-func (h *CaseHandler) handleAssignLawyer(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
+	ifMatch, ok := requireIfMatch(ctx)
 	if !ok {
 		return
 	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can assign lawyers"})
+	if !ifMatchVersion(ifMatch, caseModel.Version) {
+		h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
+		return
+	}
+
+	result := h.db.Where("version = ?", caseModel.Version).Delete(caseModel)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to delete case"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		h.respondWithCase(ctx, caseID, user, http.StatusPreconditionFailed)
 		return
 	}
 
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *CaseHandler) HandleAssignLawyer(ctx *gin.Context) {
+	user := currentUser(ctx)
+
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
 		return
 	}
 
-	if err := h.ensureCaseBelongsToUser(caseID, user.ID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
 		return
 	}
 
@@ -330,6 +413,11 @@ func (h *CaseHandler) handleAssignLawyer(ctx *gin.Context) {
 		return
 	}
 
+	role, ok := h.resolveLawyerCaseRole(ctx, caseID, lawyerID, req.Role)
+	if !ok {
+		return
+	}
+
 	var lawyer models.User
 	if err := h.db.Where("id = ? AND role = ?", lawyerID, models.UserRoleLawyer).First(&lawyer).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -339,6 +427,10 @@ func (h *CaseHandler) handleAssignLawyer(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyer"})
 		return
 	}
+	if !lawyer.Assignable() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is not approved for assignments"})
+		return
+	}
 
 	notes := strings.TrimSpace(req.Notes)
 	var assignment models.CaseAssignment
@@ -351,6 +443,7 @@ func (h *CaseHandler) handleAssignLawyer(ctx *gin.Context) {
 			assignment = models.CaseAssignment{
 				CaseID:   caseID,
 				LawyerID: lawyerID,
+				Role:     defaultString(role, models.CaseRoleCounsel),
 				Notes:    notes,
 			}
 			if err := h.db.Create(&assignment).Error; err != nil {
@@ -361,54 +454,329 @@ func (h *CaseHandler) handleAssignLawyer(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to assign lawyer"})
 			return
 		}
-	} else if notes != assignment.Notes {
+	} else if notes != assignment.Notes || (role != "" && role != assignment.Role) || !assignment.Active() {
 		assignment.Notes = notes
+		assignment.Role = defaultString(role, assignment.Role)
+		if !assignment.Active() {
+			reproposeAssignment(&assignment)
+		}
 		if err := h.db.Save(&assignment).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update assignment"})
 			return
 		}
 	}
 
-	assignment.Lawyer = lawyer
-
-	resp := caseLawyerResponse{
-		ID:          lawyer.ID,
-		CompanyName: lawyer.CompanyName,
-		Email:       lawyer.Email,
-		AssignedAt:  assignment.CreatedAt,
-		Notes:       assignment.Notes,
-	}
-
 	status := http.StatusCreated
 	if !created {
 		status = http.StatusOK
 	}
 
-	ctx.JSON(status, gin.H{"assignment": resp})
+	ctx.JSON(status, gin.H{"assignment": toCaseLawyerResponse(&assignment, &lawyer)})
+}
+
+// HandleAssignFirm proposes engaging a firm on the case. Once one of the
+// firm's admins accepts, they staff it with their lawyers. Engaging a firm
+// that declined proposes the engagement again.
+func (h *CaseHandler) HandleAssignFirm(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	if _, _, ok := h.authorizeCase(ctx, caseID, user, policy.ActionManage); !ok {
+		return
+	}
+
+	var req assignFirmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	firmID, err := uuid.Parse(strings.TrimSpace(req.FirmID))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid firm id"})
+		return
+	}
+
+	var firm models.Firm
+	if err := h.db.First(&firm, "id = ?", firmID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Firm not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch firm"})
+		return
+	}
+
+	notes := strings.TrimSpace(req.Notes)
+	var assignment models.CaseFirmAssignment
+	err = h.db.Where("case_id = ? AND firm_id = ?", caseID, firmID).First(&assignment).Error
+
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusCreated
+		assignment = models.CaseFirmAssignment{
+			CaseID:       caseID,
+			FirmID:       firmID,
+			AssignedByID: user.ID,
+			Notes:        notes,
+		}
+		if err := h.db.Create(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to assign firm"})
+			return
+		}
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to assign firm"})
+		return
+	case assignment.Status == models.AssignmentDeclined || notes != assignment.Notes:
+		if assignment.Status == models.AssignmentDeclined {
+			assignment.Status = models.AssignmentProposed
+			assignment.StatusReason = ""
+			assignment.RespondedByID = nil
+			assignment.AcceptedAt = nil
+			assignment.DeclinedAt = nil
+			assignment.AssignedByID = user.ID
+		}
+		assignment.Notes = notes
+		if err := h.db.Save(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update assignment"})
+			return
+		}
+	}
+
+	ctx.JSON(status, gin.H{"assignment": toCaseFirmResponse(&assignment, &firm)})
+}
+
+// HandleAcceptFirmEngagement lets an admin of a firm proposed for the case
+// take it on, which opens the case's documents to the firm's admins and lets
+// them staff it.
+func (h *CaseHandler) HandleAcceptFirmEngagement(ctx *gin.Context) {
+	h.transitionFirmEngagement(ctx, models.AssignmentAccepted)
+}
+
+func (h *CaseHandler) HandleDeclineFirmEngagement(ctx *gin.Context) {
+	h.transitionFirmEngagement(ctx, models.AssignmentDeclined)
+}
+
+// transitionFirmEngagement answers the proposal engaging the caller's firm
+// on the :id case with status and an optional reason. Like
+// transitionAssignment, the update is conditional on the engagement still
+// being proposed.
+func (h *CaseHandler) transitionFirmEngagement(ctx *gin.Context, status string) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	var req assignmentStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	var engagement models.CaseFirmAssignment
+	err = h.db.Preload("Firm").Where("case_id = ? AND firm_id IN (?)", caseID, administeredFirms(h.db, user.ID)).
+		Order("CASE WHEN status = 'proposed' THEN 0 ELSE 1 END").First(&engagement).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch assignment"})
+		return
+	}
+	if engagement.Status != models.AssignmentProposed {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment is " + engagement.Status, "status": engagement.Status})
+		return
+	}
+
+	now := time.Now().UTC()
+	reason := truncateString(strings.TrimSpace(req.Reason), 512)
+	updates := map[string]any{"status": status, "status_reason": reason, "responded_by_id": user.ID}
+	if status == models.AssignmentAccepted {
+		updates["accepted_at"] = now
+		engagement.AcceptedAt = &now
+	} else {
+		updates["declined_at"] = now
+		engagement.DeclinedAt = &now
+	}
+
+	result := h.db.Model(&models.CaseFirmAssignment{}).
+		Where("id = ? AND status = ?", engagement.ID, models.AssignmentProposed).
+		Updates(updates)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update assignment"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Assignment changed, reload and try again"})
+		return
+	}
+	engagement.Status = status
+	engagement.StatusReason = reason
+	engagement.RespondedByID = &user.ID
+
+	recordAudit(h.db, ctx, auditActionFirmEngagementStatus, &user.ID, nil, map[string]any{
+		"caseId": caseID.String(),
+		"firmId": engagement.FirmID.String(),
+		"from":   models.AssignmentProposed,
+		"to":     status,
+		"reason": reason,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"assignment": toCaseFirmResponse(&engagement, &engagement.Firm)})
 }
 
-func (h *CaseHandler) handleAttachDocument(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
+// HandleStaffCase lets an admin of a firm engaged on the case assign one of
+// the firm's lawyers to it.
+func (h *CaseHandler) HandleStaffCase(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	firmID, ok := h.requireEngagedFirmAdmin(ctx, caseID, user.ID)
+	if !ok {
+		return
+	}
+
+	var req assignLawyerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	lawyerID, err := uuid.Parse(strings.TrimSpace(req.LawyerID))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+		return
+	}
+
+	role, ok := h.resolveLawyerCaseRole(ctx, caseID, lawyerID, req.Role)
 	if !ok {
 		return
 	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can attach documents"})
+
+	var member models.FirmMembership
+	if err := h.db.Preload("User").Where("firm_id = ? AND user_id = ?", firmID, lawyerID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Lawyer is not a member of your firm"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyer"})
+		return
+	}
+	if !member.User.Assignable() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Lawyer is not approved for assignments"})
+		return
+	}
+
+	notes := strings.TrimSpace(req.Notes)
+	var assignment models.CaseAssignment
+	err = h.db.Where("case_id = ? AND lawyer_id = ?", caseID, lawyerID).First(&assignment).Error
+
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status = http.StatusCreated
+		assignment = models.CaseAssignment{
+			CaseID:   caseID,
+			LawyerID: lawyerID,
+			FirmID:   &firmID,
+			Role:     defaultString(role, models.CaseRoleCounsel),
+			Notes:    notes,
+		}
+		if err := h.db.Create(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to staff case"})
+			return
+		}
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to staff case"})
 		return
+	case notes != assignment.Notes || (role != "" && role != assignment.Role) || !assignment.Active():
+		assignment.Notes = notes
+		assignment.Role = defaultString(role, assignment.Role)
+		if !assignment.Active() {
+			reproposeAssignment(&assignment)
+		}
+		if err := h.db.Save(&assignment).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update assignment"})
+			return
+		}
 	}
 
+	ctx.JSON(status, gin.H{"assignment": toCaseLawyerResponse(&assignment, &member.User)})
+}
+
+// HandleUnstaffCase removes a lawyer the caller's firm staffed on the case,
+// with an optional reason. Lawyers the client assigned directly are left
+// alone.
+func (h *CaseHandler) HandleUnstaffCase(ctx *gin.Context) {
+	user := currentUser(ctx)
+
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
 		return
 	}
 
-	if err := h.ensureCaseBelongsToUser(caseID, user.ID); err != nil {
+	lawyerID, err := uuid.Parse(ctx.Param("lawyerId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+		return
+	}
+
+	firmID, ok := h.requireEngagedFirmAdmin(ctx, caseID, user.ID)
+	if !ok {
+		return
+	}
+
+	var req assignmentStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment payload"})
+		return
+	}
+
+	var assignment models.CaseAssignment
+	if err := h.db.Where("case_id = ? AND lawyer_id = ? AND firm_id = ?", caseID, lawyerID, firmID).
+		First(&assignment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update staffing"})
+		return
+	}
+
+	if !h.removeAssignment(ctx, &assignment, user, req.Reason) {
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *CaseHandler) HandleAttachDocument(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	_, subject, ok := h.authorizeCase(ctx, caseID, user, policy.ActionUpload)
+	if !ok {
 		return
 	}
 
@@ -418,26 +786,28 @@ func (h *CaseHandler) handleAttachDocument(ctx *gin.Context) {
 		return
 	}
 
-	document := toCaseDocumentModel(caseDocumentPayload(req), defaultString(req.Category, "case"))
+	document, ok := toCaseDocumentModel(caseDocumentPayload(req), defaultString(req.Category, "case"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document visibility"})
+		return
+	}
+	if !h.permitVisibility(ctx, subject, document.Visibility) {
+		return
+	}
 	document.CaseID = caseID
+	document.UploadedByID = &user.ID
 
 	if err := h.db.Create(&document).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to attach document"})
 		return
 	}
 
+	ctx.Header("ETag", versionETag(document.Version))
 	ctx.JSON(http.StatusCreated, gin.H{"document": h.toDocumentResponse(&document)})
 }
 
-func (h *CaseHandler) handleDeleteDocument(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
-		return
-	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can delete documents"})
-		return
-	}
+func (h *CaseHandler) HandleDeleteDocument(ctx *gin.Context) {
+	user := currentUser(ctx)
 
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
@@ -451,29 +821,29 @@ func (h *CaseHandler) handleDeleteDocument(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.ensureCaseBelongsToUser(caseID, user.ID); err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+	document, _, ok := h.authorizeDocument(ctx, caseID, documentID, user, policy.ActionDelete)
+	if !ok {
 		return
 	}
 
-	var document models.CaseDocument
-	if err := h.db.Where("id = ? AND case_id = ?", documentID, caseID).First(&document).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load document"})
+	ifMatch, ok := requireIfMatch(ctx)
+	if !ok {
+		return
+	}
+	if !ifMatchVersion(ifMatch, document.Version) {
+		h.respondWithDocumentConflict(ctx, document.ID)
 		return
 	}
 
-	if err := h.db.Delete(&document).Error; err != nil {
+	result := h.db.Where("version = ?", document.Version).Delete(document)
+	if result.Error != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to delete document"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		h.respondWithDocumentConflict(ctx, document.ID)
+		return
+	}
 
 	if document.FilePath != "" {
 		_ = os.Remove(document.FilePath)
@@ -482,28 +852,136 @@ func (h *CaseHandler) handleDeleteDocument(ctx *gin.Context) {
 	ctx.Status(http.StatusNoContent)
 }
 
-func (h *CaseHandler) handleUploadDocument(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
+// HandleUpdateDocument edits a document's details. Only participants who can
+// see restricted documents may change its visibility.
+func (h *CaseHandler) HandleUpdateDocument(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	documentID, err := uuid.Parse(ctx.Param("documentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document id"})
+		return
+	}
+
+	var req updateDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document payload"})
+		return
+	}
+
+	document, subject, ok := h.authorizeDocument(ctx, caseID, documentID, user, policy.ActionEdit)
 	if !ok {
 		return
 	}
-	if user.Role != models.UserRoleClient {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only client workspaces can upload documents"})
+
+	ifMatch, ok := requireIfMatch(ctx)
+	if !ok {
+		return
+	}
+	if !ifMatchVersion(ifMatch, document.Version) {
+		h.respondWithDocumentConflict(ctx, document.ID)
 		return
 	}
 
-	caseID, err := uuid.Parse(ctx.Param("id"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Document name is required"})
+			return
+		}
+		document.Title = name
+	}
+	if req.Description != nil {
+		document.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Status != nil {
+		document.Status = strings.TrimSpace(*req.Status)
+	}
+	if req.Category != nil {
+		document.Category = strings.ToLower(defaultString(*req.Category, "case"))
+	}
+	if req.Visibility != nil {
+		visibility, ok := documentVisibility(*req.Visibility, document.Category)
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document visibility"})
+			return
+		}
+		if visibility != document.Visibility && !policy.SeesRestricted(subject) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Your access to this case does not permit restricted documents"})
+			return
+		}
+		document.Visibility = visibility
+	}
+
+	result := h.db.Model(document).Where("version = ?", document.Version).Updates(map[string]any{
+		"title":       document.Title,
+		"description": document.Description,
+		"status":      document.Status,
+		"category":    document.Category,
+		"visibility":  document.Visibility,
+		"version":     gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update document"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		h.respondWithDocumentConflict(ctx, document.ID)
+		return
+	}
+	document.Version++
+
+	ctx.Header("ETag", versionETag(document.Version))
+	ctx.JSON(http.StatusOK, gin.H{"document": h.toDocumentResponse(document)})
+}
 
-	if err := h.ensureCaseBelongsToUser(caseID, user.ID); err != nil {
+// respondWithDocumentConflict answers a failed precondition with the
+// document as it stands now, or 404 if it was deleted meanwhile.
+func (h *CaseHandler) respondWithDocumentConflict(ctx *gin.Context, documentID uuid.UUID) {
+	var document models.CaseDocument
+	if err := h.db.Where("id = ?", documentID).First(&document).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load document"})
+		return
+	}
+
+	ctx.Header("ETag", versionETag(document.Version))
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":    "Document was changed since you loaded it",
+		"document": h.toDocumentResponse(&document),
+	})
+}
+
+func (h *CaseHandler) HandleUploadDocument(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	caseID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case id"})
+		return
+	}
+
+	_, subject, ok := h.authorizeCase(ctx, caseID, user, policy.ActionUpload)
+	if !ok {
+		return
+	}
+
+	category := strings.ToLower(defaultString(ctx.PostForm("category"), "case"))
+	visibility, ok := documentVisibility(ctx.PostForm("visibility"), category)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document visibility"})
+		return
+	}
+	if !h.permitVisibility(ctx, subject, visibility) {
 		return
 	}
 
@@ -532,18 +1010,17 @@ func (h *CaseHandler) handleUploadDocument(ctx *gin.Context) {
 		return
 	}
 
-	category := defaultString(ctx.PostForm("category"), "case")
 	document := models.CaseDocument{
-		CaseID:      caseID,
-		Title:       fileHeader.Filename,
-		Owner:       strings.TrimSpace(ctx.PostForm("owner")),
-		Description: strings.TrimSpace(ctx.PostForm("description")),
-		Status:      strings.TrimSpace(ctx.PostForm("status")),
-		Category:    strings.ToLower(strings.TrimSpace(category)),
-		FilePath:    destination,
-	}
-	if document.Category == "" {
-		document.Category = "case"
+		CaseID:       caseID,
+		Title:        fileHeader.Filename,
+		Owner:        strings.TrimSpace(ctx.PostForm("owner")),
+		Description:  strings.TrimSpace(ctx.PostForm("description")),
+		Status:       strings.TrimSpace(ctx.PostForm("status")),
+		Category:     category,
+		Visibility:   visibility,
+		UploadedByID: &user.ID,
+		FilePath:     destination,
+		ContentText:  extractDocumentText(destination),
 	}
 
 	if err := h.db.Create(&document).Error; err != nil {
@@ -552,14 +1029,12 @@ func (h *CaseHandler) handleUploadDocument(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", versionETag(document.Version))
 	ctx.JSON(http.StatusCreated, gin.H{"document": h.toDocumentResponse(&document)})
 }
 
-func (h *CaseHandler) handleDownloadDocument(ctx *gin.Context) {
-	_, user, ok := h.auth.requireSession(ctx)
-	if !ok {
-		return
-	}
+func (h *CaseHandler) HandleDownloadDocument(ctx *gin.Context) {
+	user := currentUser(ctx)
 
 	caseID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
@@ -573,44 +1048,8 @@ func (h *CaseHandler) handleDownloadDocument(ctx *gin.Context) {
 		return
 	}
 
-	var document models.CaseDocument
-	switch user.Role {
-	case models.UserRoleClient:
-		if err := h.ensureCaseBelongsToUser(caseID, user.ID); err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
-			return
-		}
-		if err := h.db.Where("id = ? AND case_id = ?", documentID, caseID).First(&document).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load document"})
-			return
-		}
-	case models.UserRoleLawyer:
-		if err := h.ensureCaseAssignedToLawyer(caseID, user.ID); err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
-			return
-		}
-		if err := h.db.Where("id = ? AND case_id = ?", documentID, caseID).First(&document).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load document"})
-			return
-		}
-	default:
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+	document, _, ok := h.authorizeDocument(ctx, caseID, documentID, user, policy.ActionDownload)
+	if !ok {
 		return
 	}
 
@@ -627,33 +1066,91 @@ func (h *CaseHandler) handleDownloadDocument(ctx *gin.Context) {
 	ctx.FileAttachment(document.FilePath, sanitizeFilename(document.Title))
 }
 
-func (h *CaseHandler) ensureCaseBelongsToUser(caseID, userID uuid.UUID) error {
-	var count int64
-	if err := h.db.Model(&models.Case{}).Where("id = ? AND user_id = ?", caseID, userID).Count(&count).Error; err != nil {
-		return err
+// resolveCaseOrganization picks the organization a new case is opened in:
+// the requested one, or the caller's only organization when none is given.
+// Viewers cannot open cases.
+func (h *CaseHandler) resolveCaseOrganization(ctx *gin.Context, user *models.User, requested string) (*models.OrganizationMembership, bool) {
+	var orgID uuid.UUID
+	if requested = strings.TrimSpace(requested); requested != "" {
+		parsed, err := uuid.Parse(requested)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization id"})
+			return nil, false
+		}
+		orgID = parsed
+	} else {
+		var memberships []models.OrganizationMembership
+		if err := h.db.Where("user_id = ?", user.ID).Limit(2).Find(&memberships).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch organizations"})
+			return nil, false
+		}
+		if len(memberships) != 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "organizationId is required"})
+			return nil, false
+		}
+		orgID = memberships[0].OrganizationID
+	}
+
+	membership, err := loadMembership(h.db, orgID, user.ID, models.OrgRoleMember)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		case errors.Is(err, errOrgRoleInsufficient):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Your organization role does not permit this action"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch organization"})
+		}
+		return nil, false
+	}
+	return membership, true
+}
+
+// requireEngagedFirmAdmin returns the firm lawyerID administers when that
+// firm accepted its engagement on caseID. A firm that has yet to accept
+// cannot staff the case.
+func (h *CaseHandler) requireEngagedFirmAdmin(ctx *gin.Context, caseID, lawyerID uuid.UUID) (uuid.UUID, bool) {
+	var engagement models.CaseFirmAssignment
+	err := h.db.Where("case_id = ? AND firm_id IN (?) AND status IN ?", caseID, administeredFirms(h.db, lawyerID), models.ActiveAssignmentStatuses).
+		Order("CASE WHEN status = 'accepted' THEN 0 ELSE 1 END").First(&engagement).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+			return uuid.Nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to validate case"})
+		return uuid.Nil, false
 	}
-	if count == 0 {
-		return gorm.ErrRecordNotFound
+	if engagement.Status != models.AssignmentAccepted {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Accept the firm's engagement before staffing the case", "status": engagement.Status})
+		return uuid.Nil, false
 	}
-	return nil
+	return engagement.FirmID, true
 }
 
-func toCaseDocumentModel(payload caseDocumentPayload, defaultCategory string) models.CaseDocument {
-	category := strings.TrimSpace(payload.Category)
-	if category == "" {
-		category = defaultCategory
+// toCaseDocumentModel builds a document from payload. It fails when the
+// requested visibility is not recognised.
+func toCaseDocumentModel(payload caseDocumentPayload, defaultCategory string) (models.CaseDocument, bool) {
+	category := strings.ToLower(defaultString(payload.Category, defaultCategory))
+	visibility, ok := documentVisibility(payload.Visibility, category)
+	if !ok {
+		return models.CaseDocument{}, false
 	}
 	return models.CaseDocument{
 		Title:       strings.TrimSpace(payload.Name),
 		Owner:       strings.TrimSpace(payload.Owner),
 		Description: strings.TrimSpace(payload.Description),
 		Status:      strings.TrimSpace(payload.Status),
-		Category:    strings.ToLower(category),
+		Category:    category,
+		Visibility:  visibility,
 		StoragePath: strings.TrimSpace(payload.StoragePath),
-	}
+	}, true
 }
 
-func toCaseResponse(model *models.Case, includeClient bool) caseResponse {
+// toCaseResponse renders model for subject, leaving out the documents the
+// policy hides from them. The opening member is shown to participants from
+// outside the organization.
+func (h *CaseHandler) toCaseResponse(model *models.Case, subject policy.Subject) caseResponse {
 	resp := caseResponse{
 		ID:         model.ID,
 		Name:       model.Name,
@@ -663,8 +1160,19 @@ func toCaseResponse(model *models.Case, includeClient bool) caseResponse {
 		Owner:      model.Owner,
 		Summary:    model.Summary,
 		AIFocus:    model.AIFocus,
-		CreatedAt:  model.CreatedAt,
-		UpdatedAt:  model.UpdatedAt,
+		Access: caseAccessResponse{
+			OrganizationRole: subject.OrgRole,
+			CaseRole:         subject.CaseRole,
+			Proposed:         subject.Proposed,
+			Permissions:      policy.CaseActions(subject, model),
+		},
+		Version:   model.Version,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+
+	if model.Organization.ID != uuid.Nil {
+		resp.Organization = &caseOrgResponse{ID: model.Organization.ID, Name: model.Organization.Name}
 	}
 
 	if len(model.AIContext) > 0 {
@@ -675,15 +1183,17 @@ func toCaseResponse(model *models.Case, includeClient bool) caseResponse {
 		resp.Metadata = map[string]any(model.Metadata)
 	}
 
-	if len(model.Documents) > 0 {
-		docs := make([]caseDocumentResponse, 0, len(model.Documents))
-		for i := range model.Documents {
-			doc := model.Documents[i]
-			docs = append(docs, h.toDocumentResponse(&doc))
+	if len(model.StatusHistory) > 0 {
+		resp.StatusHistory = toCaseStatusChangeResponses(model.StatusHistory)
+	}
+
+	resp.Documents = make([]caseDocumentResponse, 0, len(model.Documents))
+	for i := range model.Documents {
+		doc := model.Documents[i]
+		if !policy.Document(subject, &doc, policy.ActionView) {
+			continue
 		}
-		resp.Documents = docs
-	} else {
-		resp.Documents = []caseDocumentResponse{}
+		resp.Documents = append(resp.Documents, h.toDocumentResponse(&doc))
 	}
 
 	assignments := make([]caseLawyerResponse, 0, len(model.Assignments))
@@ -692,20 +1202,41 @@ func toCaseResponse(model *models.Case, includeClient bool) caseResponse {
 		if assignment.Lawyer.ID == uuid.Nil {
 			continue
 		}
-		assignments = append(assignments, caseLawyerResponse{
-			ID:          assignment.Lawyer.ID,
-			CompanyName: assignment.Lawyer.CompanyName,
-			Email:       assignment.Lawyer.Email,
-			AssignedAt:  assignment.CreatedAt,
-			Notes:       assignment.Notes,
-		})
+		assignments = append(assignments, toCaseLawyerResponse(&assignment, &assignment.Lawyer))
 	}
 	if assignments == nil {
 		assignments = []caseLawyerResponse{}
 	}
 	resp.AssignedLawyers = assignments
 
-	if includeClient && model.User.ID != uuid.Nil {
+	resp.AssignedFirms = make([]caseFirmResponse, 0, len(model.FirmAssignments))
+	for _, assignment := range model.FirmAssignments {
+		if assignment.Firm.ID == uuid.Nil {
+			continue
+		}
+		resp.AssignedFirms = append(resp.AssignedFirms, toCaseFirmResponse(&assignment, &assignment.Firm))
+	}
+
+	resp.Collaborators = make([]caseClientResponse, 0, len(model.Collaborators))
+	for _, collaborator := range model.Collaborators {
+		if collaborator.User.ID == uuid.Nil {
+			continue
+		}
+		resp.Collaborators = append(resp.Collaborators, caseClientResponse{
+			ID:          collaborator.User.ID,
+			CompanyName: collaborator.User.CompanyName,
+			Email:       collaborator.User.Email,
+			Role:        collaborator.Role,
+		})
+	}
+
+	if policy.Case(subject, model, policy.ActionManage) {
+		for i := range model.Invitations {
+			resp.PendingInvitations = append(resp.PendingInvitations, toCaseInvitationResponse(&model.Invitations[i]))
+		}
+	}
+
+	if subject.OrgRole == "" && model.User.ID != uuid.Nil {
 		resp.Client = &caseClientResponse{
 			ID:          model.User.ID,
 			CompanyName: model.User.CompanyName,
@@ -716,6 +1247,39 @@ func toCaseResponse(model *models.Case, includeClient bool) caseResponse {
 	return resp
 }
 
+func toCaseFirmResponse(assignment *models.CaseFirmAssignment, firm *models.Firm) caseFirmResponse {
+	return caseFirmResponse{
+		ID:           firm.ID,
+		Name:         firm.Name,
+		Status:       assignment.Status,
+		StatusReason: assignment.StatusReason,
+		AssignedAt:   assignment.CreatedAt,
+		AcceptedAt:   assignment.AcceptedAt,
+		DeclinedAt:   assignment.DeclinedAt,
+		Notes:        assignment.Notes,
+	}
+}
+
+func toCaseLawyerResponse(assignment *models.CaseAssignment, lawyer *models.User) caseLawyerResponse {
+	return caseLawyerResponse{
+		ID:           lawyer.ID,
+		CompanyName:  lawyer.CompanyName,
+		Email:        lawyer.Email,
+		Role:         assignment.Role,
+		Status:       assignment.Status,
+		StatusReason: assignment.StatusReason,
+		FirmID:       assignment.FirmID,
+		AssignedAt:   assignment.CreatedAt,
+		AcceptedAt:   assignment.AcceptedAt,
+		DeclinedAt:   assignment.DeclinedAt,
+		WithdrawnAt:  assignment.WithdrawnAt,
+		CompletedAt:  assignment.CompletedAt,
+		RemovedAt:    assignment.RemovedAt,
+		RemovedBy:    assignment.RemovedByID,
+		Notes:        assignment.Notes,
+	}
+}
+
 func (h *CaseHandler) toDocumentResponse(doc *models.CaseDocument) caseDocumentResponse {
 	downloadPath := doc.StoragePath
 	if doc.FilePath != "" {
@@ -723,16 +1287,19 @@ func (h *CaseHandler) toDocumentResponse(doc *models.CaseDocument) caseDocumentR
 	}
 
 	return caseDocumentResponse{
-		ID:          doc.ID,
-		CaseID:      doc.CaseID,
-		Name:        doc.Title,
-		Owner:       doc.Owner,
-		Description: doc.Description,
-		Status:      doc.Status,
-		Category:    doc.Category,
-		StoragePath: downloadPath,
-		CreatedAt:   doc.CreatedAt,
-		UpdatedAt:   doc.UpdatedAt,
+		ID:           doc.ID,
+		CaseID:       doc.CaseID,
+		Name:         doc.Title,
+		Owner:        doc.Owner,
+		Description:  doc.Description,
+		Status:       doc.Status,
+		Category:     doc.Category,
+		Visibility:   doc.Visibility,
+		UploadedByID: doc.UploadedByID,
+		StoragePath:  downloadPath,
+		Version:      doc.Version,
+		CreatedAt:    doc.CreatedAt,
+		UpdatedAt:    doc.UpdatedAt,
 	}
 }
 
@@ -740,17 +1307,6 @@ func (h *CaseHandler) documentDownloadPath(caseID, documentID uuid.UUID) string
 	return fmt.Sprintf("/api/v1/cases/%s/documents/%s/download", caseID.String(), documentID.String())
 }
 
-func (h *CaseHandler) ensureCaseAssignedToLawyer(caseID, lawyerID uuid.UUID) error {
-	var count int64
-	if err := h.db.Model(&models.CaseAssignment{}).Where("case_id = ? AND lawyer_id = ?", caseID, lawyerID).Count(&count).Error; err != nil {
-		return err
-	}
-	if count == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
-}
-
 func sanitizeFilename(name string) string {
 	base := filepath.Base(strings.TrimSpace(name))
 	if base == "" || base == "." {
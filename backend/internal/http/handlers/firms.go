@@ -0,0 +1,631 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	firmInvitationTTL           = 7 * 24 * time.Hour
+	auditActionFirmInvite       = "firm.invite"
+	auditActionFirmInviteRevoke = "firm.invite_revoke"
+	auditActionFirmJoin         = "firm.join"
+	auditActionFirmMemberRole   = "firm.member_role"
+	auditActionFirmMemberRemove = "firm.member_remove"
+)
+
+var (
+	errNotFirmAdmin  = errors.New("firm admin required")
+	errLastFirmAdmin = errors.New("firm needs an admin")
+	errLawyerInFirm  = errors.New("lawyer already belongs to a firm")
+)
+
+type firmRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type inviteFirmMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+}
+
+type updateFirmMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type firmResponse struct {
+	ID        uuid.UUID            `json:"id"`
+	Name      string               `json:"name"`
+	Role      string               `json:"role,omitempty"`
+	Members   []firmMemberResponse `json:"members,omitempty"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+type firmMemberResponse struct {
+	ID          uuid.UUID `json:"id"`
+	CompanyName string    `json:"companyName"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joinedAt"`
+}
+
+type firmInvitationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// HandleListFirms is the firm directory clients pick from when engaging a
+// firm. An optional q narrows it by name.
+func (h *AuthHandler) HandleListFirms(ctx *gin.Context) {
+	query := h.db.Model(&models.Firm{}).Order("name")
+	if q := strings.TrimSpace(ctx.Query("q")); q != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(q)+"%")
+	}
+
+	var firms []models.Firm
+	if err := query.Limit(100).Find(&firms).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch firms"})
+		return
+	}
+
+	payload := make([]firmResponse, 0, len(firms))
+	for i := range firms {
+		payload = append(payload, firmResponse{ID: firms[i].ID, Name: firms[i].Name, CreatedAt: firms[i].CreatedAt})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"firms": payload})
+}
+
+// HandleCreateFirm creates a firm with the calling lawyer as its admin.
+func (h *AuthHandler) HandleCreateFirm(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req firmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid firm payload"})
+		return
+	}
+
+	if inFirm, err := h.lawyerHasFirm(user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create firm"})
+		return
+	} else if inFirm {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "You already belong to a firm"})
+		return
+	}
+
+	firm := models.Firm{Name: truncateString(strings.TrimSpace(req.Name), 255), CreatedByID: user.ID}
+	membership := models.FirmMembership{UserID: user.ID, Role: models.FirmRoleAdmin}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&firm).Error; err != nil {
+			return err
+		}
+		membership.FirmID = firm.ID
+		return tx.Omit("Firm", "User").Create(&membership).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create firm"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"firm": firmResponse{
+		ID:        firm.ID,
+		Name:      firm.Name,
+		Role:      membership.Role,
+		CreatedAt: firm.CreatedAt,
+	}})
+}
+
+// HandleGetFirm returns a firm with its members. Only the firm's own lawyers
+// see the member list.
+func (h *AuthHandler) HandleGetFirm(ctx *gin.Context) {
+	membership, ok := h.requireFirmMember(ctx, false)
+	if !ok {
+		return
+	}
+
+	var members []models.FirmMembership
+	if err := h.db.Preload("User").Where("firm_id = ?", membership.FirmID).Order("created_at").Find(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch members"})
+		return
+	}
+
+	resp := firmResponse{
+		ID:        membership.Firm.ID,
+		Name:      membership.Firm.Name,
+		Role:      membership.Role,
+		Members:   make([]firmMemberResponse, 0, len(members)),
+		CreatedAt: membership.Firm.CreatedAt,
+	}
+	for i := range members {
+		resp.Members = append(resp.Members, toFirmMemberResponse(&members[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"firm": resp})
+}
+
+func (h *AuthHandler) HandleListFirmInvitations(ctx *gin.Context) {
+	membership, ok := h.requireFirmMember(ctx, true)
+	if !ok {
+		return
+	}
+
+	var invitations []models.FirmInvitation
+	if err := h.db.Preload("InvitedBy").
+		Where("firm_id = ? AND accepted_at IS NULL AND expires_at > ?", membership.FirmID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch invitations"})
+		return
+	}
+
+	payload := make([]firmInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		payload = append(payload, toFirmInvitationResponse(&invitations[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"invitations": payload})
+}
+
+// HandleInviteFirmMember emails a lawyer a single-use invitation to join the
+// firm; they become a member only once they accept it. Inviting the same
+// address again replaces its pending invitation.
+func (h *AuthHandler) HandleInviteFirmMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireFirmMember(ctx, true)
+	if !ok {
+		return
+	}
+
+	var req inviteFirmMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+	role, ok := normaliseFirmRole(defaultString(req.Role, models.FirmRoleAssociate))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported role"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var existing int64
+	if err := h.db.Model(&models.FirmMembership{}).
+		Joins("JOIN users ON users.id = firm_memberships.user_id").
+		Where("firm_memberships.firm_id = ? AND users.email = ?", membership.FirmID, email).
+		Count(&existing).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+	if existing > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Already a member of this firm"})
+		return
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	invitation := models.FirmInvitation{
+		FirmID:      membership.FirmID,
+		Email:       email,
+		Role:        role,
+		TokenHash:   hashToken(token),
+		InvitedByID: user.ID,
+		ExpiresAt:   time.Now().UTC().Add(firmInvitationTTL),
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("firm_id = ? AND email = ? AND accepted_at IS NULL", membership.FirmID, email).
+			Delete(&models.FirmInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	msg, err := mailer.Render(mailer.TemplateFirmInvitation, email, mailer.FirmInvitationData{
+		FirmName:    membership.Firm.Name,
+		InviterName: user.CompanyName,
+		Role:        role,
+		AcceptURL:   h.appURL("/firm-invitations/accept", url.Values{"token": {token}}),
+		ValidDays:   int(firmInvitationTTL.Hours() / 24),
+	})
+	if err == nil {
+		err = h.mailer.Send(ctx.Request.Context(), msg)
+	}
+	if err != nil {
+		log.Printf("auth: unable to send firm invitation to %s: %v", email, err)
+		_ = h.db.Delete(&invitation).Error
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Unable to send invitation email"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionFirmInvite, &user.ID, nil, map[string]any{
+		"firmId": membership.FirmID.String(),
+		"email":  email,
+		"role":   role,
+	})
+
+	invitation.InvitedBy = *user
+	ctx.JSON(http.StatusCreated, gin.H{"invitation": toFirmInvitationResponse(&invitation)})
+}
+
+func (h *AuthHandler) HandleRevokeFirmInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireFirmMember(ctx, true)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(ctx.Param("invitationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation id"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND firm_id = ? AND accepted_at IS NULL", invitationID, membership.FirmID).
+		Delete(&models.FirmInvitation{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke invitation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionFirmInviteRevoke, &user.ID, nil, map[string]any{
+		"firmId":       membership.FirmID.String(),
+		"invitationId": invitationID.String(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// HandleAcceptFirmInvitation redeems a firm invitation for the signed-in
+// lawyer, who must use the address it was sent to and must not already
+// belong to a firm.
+func (h *AuthHandler) HandleAcceptFirmInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req acceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+
+	tokenHash := hashToken(strings.TrimSpace(req.Token))
+	now := time.Now().UTC()
+
+	var member models.FirmMembership
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.FirmInvitation
+		if err := tx.Preload("Firm").Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvitationInvalid
+			}
+			return err
+		}
+		if invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+			return errInvitationInvalid
+		}
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return errInvitationEmail
+		}
+
+		var inFirm int64
+		if err := tx.Model(&models.FirmMembership{}).Where("user_id = ?", user.ID).Count(&inFirm).Error; err != nil {
+			return err
+		}
+		if inFirm > 0 {
+			return errLawyerInFirm
+		}
+
+		result := tx.Model(&models.FirmInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationInvalid
+		}
+
+		member = models.FirmMembership{FirmID: invitation.FirmID, UserID: user.ID, Role: invitation.Role}
+		if err := tx.Omit("Firm", "User").Create(&member).Error; err != nil {
+			return err
+		}
+		member.Firm = invitation.Firm
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalid):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or has expired"})
+		case errors.Is(err, errInvitationEmail):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "This invitation was sent to a different email address"})
+		case errors.Is(err, errLawyerInFirm):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "You already belong to a firm"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to accept invitation"})
+		}
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionFirmJoin, &user.ID, &user.ID, map[string]any{
+		"firmId": member.FirmID.String(),
+		"role":   member.Role,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"firm": firmResponse{
+		ID:        member.Firm.ID,
+		Name:      member.Firm.Name,
+		Role:      member.Role,
+		CreatedAt: member.Firm.CreatedAt,
+	}})
+}
+
+func (h *AuthHandler) HandleUpdateFirmMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireFirmMember(ctx, true)
+	if !ok {
+		return
+	}
+
+	var req updateFirmMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member payload"})
+		return
+	}
+	role, ok := normaliseFirmRole(req.Role)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported role"})
+		return
+	}
+
+	target, ok := h.loadFirmMember(ctx, membership.FirmID)
+	if !ok {
+		return
+	}
+
+	previous := target.Role
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(target).Update("role", role).Error; err != nil {
+			return err
+		}
+		return ensureFirmHasAdmin(tx, membership.FirmID)
+	})
+	if err != nil {
+		respondFirmMemberError(ctx, err, "Unable to update member")
+		return
+	}
+	target.Role = role
+
+	recordAudit(h.db, ctx, auditActionFirmMemberRole, &user.ID, &target.UserID, map[string]any{
+		"firmId": membership.FirmID.String(),
+		"from":   previous,
+		"to":     role,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"member": toFirmMemberResponse(target)})
+}
+
+// HandleRemoveFirmMember removes a lawyer from the firm; lawyers may also
+// leave on their own. Every active assignment the firm staffed the lawyer on
+// is ended as if removed from the case, with the optional reason, and the
+// lawyer is told about each one.
+func (h *AuthHandler) HandleRemoveFirmMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireFirmMember(ctx, false)
+	if !ok {
+		return
+	}
+
+	target, ok := h.loadFirmMember(ctx, membership.FirmID)
+	if !ok {
+		return
+	}
+	if target.UserID != user.ID && membership.Role != models.FirmRoleAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only firm admins can perform this action"})
+		return
+	}
+
+	var req assignmentStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member payload"})
+		return
+	}
+	reason := truncateString(strings.TrimSpace(req.Reason), 512)
+	if reason == "" {
+		reason = "No longer with " + membership.Firm.Name
+	}
+
+	var unstaffed []models.CaseAssignment
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(target).Error; err != nil {
+			return err
+		}
+		if err := ensureFirmHasAdmin(tx, membership.FirmID); err != nil {
+			return err
+		}
+
+		var assignments []models.CaseAssignment
+		if err := tx.Where("lawyer_id = ? AND firm_id = ? AND status IN ?", target.UserID, membership.FirmID, models.ActiveAssignmentStatuses).
+			Find(&assignments).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for i := range assignments {
+			removed, err := endAssignment(tx, &assignments[i], user.ID, reason, now)
+			if err != nil {
+				return err
+			}
+			if removed {
+				unstaffed = append(unstaffed, assignments[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondFirmMemberError(ctx, err, "Unable to remove member")
+		return
+	}
+
+	for i := range unstaffed {
+		announceAssignmentRemoval(ctx, h.db, h.mailer, &unstaffed[i], user, reason)
+	}
+	recordAudit(h.db, ctx, auditActionFirmMemberRemove, &user.ID, &target.UserID, map[string]any{
+		"firmId":         membership.FirmID.String(),
+		"casesUnstaffed": len(unstaffed),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// requireFirmMember loads the caller's membership in the :firmId firm,
+// optionally requiring the admin role. Outsiders get 404.
+func (h *AuthHandler) requireFirmMember(ctx *gin.Context, admin bool) (*models.FirmMembership, bool) {
+	firmID, err := uuid.Parse(ctx.Param("firmId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid firm id"})
+		return nil, false
+	}
+
+	membership, err := loadFirmMembership(h.db, firmID, currentUser(ctx).ID, admin)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Firm not found"})
+		case errors.Is(err, errNotFirmAdmin):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Only firm admins can perform this action"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch firm"})
+		}
+		return nil, false
+	}
+
+	return membership, true
+}
+
+func (h *AuthHandler) loadFirmMember(ctx *gin.Context, firmID uuid.UUID) (*models.FirmMembership, bool) {
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return nil, false
+	}
+
+	var member models.FirmMembership
+	if err := h.db.Preload("User").Where("firm_id = ? AND user_id = ?", firmID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch member"})
+		return nil, false
+	}
+
+	return &member, true
+}
+
+func (h *AuthHandler) lawyerHasFirm(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := h.db.Model(&models.FirmMembership{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// loadFirmMembership returns userID's membership in firmID with the firm
+// loaded. It fails with gorm.ErrRecordNotFound for outsiders and
+// errNotFirmAdmin when admin is required but the lawyer is an associate.
+func loadFirmMembership(db *gorm.DB, firmID, userID uuid.UUID, admin bool) (*models.FirmMembership, error) {
+	var membership models.FirmMembership
+	if err := db.Preload("Firm").Where("firm_id = ? AND user_id = ?", firmID, userID).First(&membership).Error; err != nil {
+		return nil, err
+	}
+	if admin && membership.Role != models.FirmRoleAdmin {
+		return &membership, errNotFirmAdmin
+	}
+	return &membership, nil
+}
+
+// ensureFirmHasAdmin fails with errLastFirmAdmin when a membership change
+// would leave the firm without an admin. Like ensureOrganizationHasOwner it
+// locks the firm row before counting.
+func ensureFirmHasAdmin(tx *gorm.DB, firmID uuid.UUID) error {
+	var firm models.Firm
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&firm, "id = ?", firmID).Error; err != nil {
+		return err
+	}
+	var admins int64
+	if err := tx.Model(&models.FirmMembership{}).
+		Where("firm_id = ? AND role = ?", firmID, models.FirmRoleAdmin).
+		Count(&admins).Error; err != nil {
+		return err
+	}
+	if admins == 0 {
+		return errLastFirmAdmin
+	}
+	return nil
+}
+
+func respondFirmMemberError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, errLastFirmAdmin) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A firm must keep at least one admin"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+func normaliseFirmRole(role string) (string, bool) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == models.FirmRoleAdmin || role == models.FirmRoleAssociate {
+		return role, true
+	}
+	return "", false
+}
+
+func toFirmMemberResponse(member *models.FirmMembership) firmMemberResponse {
+	return firmMemberResponse{
+		ID:          member.UserID,
+		CompanyName: member.User.CompanyName,
+		Email:       member.User.Email,
+		Role:        member.Role,
+		JoinedAt:    member.CreatedAt,
+	}
+}
+
+func toFirmInvitationResponse(invitation *models.FirmInvitation) firmInvitationResponse {
+	return firmInvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy.Email,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+// escapeLike escapes LIKE wildcards in user input.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,537 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	auditActionIdentityProviderSave         = "identity_provider.save"
+	auditActionIdentityProviderDelete       = "identity_provider.delete"
+	auditActionIdentityProviderDomainVerify = "identity_provider.domain_verify"
+
+	// A domain is verified by publishing domainVerificationValue followed by
+	// the claim's token as a TXT record at domainVerificationPrefix + domain.
+	domainVerificationPrefix  = "_lexiflow-challenge."
+	domainVerificationValue   = "lexiflow-domain-verification="
+	domainVerificationTimeout = 10 * time.Second
+)
+
+var providerSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+var errDomainVerifiedElsewhere = errors.New("domain is verified by another identity provider")
+
+// publicEmailDomains are webmail domains whose accounts belong to unrelated
+// people, so no organization can claim them for single sign-on.
+var publicEmailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true, "hotmail.co.uk": true,
+	"live.com": true, "msn.com": true, "yahoo.com": true, "yahoo.co.uk": true, "ymail.com": true,
+	"icloud.com": true, "me.com": true, "mac.com": true, "aol.com": true, "proton.me": true,
+	"protonmail.com": true, "pm.me": true, "gmx.com": true, "gmx.de": true, "gmx.net": true, "web.de": true,
+	"mail.com": true, "zoho.com": true, "fastmail.com": true, "hey.com": true, "tutanota.com": true,
+	"yandex.com": true, "yandex.ru": true, "mail.ru": true, "qq.com": true, "163.com": true, "126.com": true,
+}
+
+type identityProviderRequest struct {
+	Slug         string            `json:"slug" binding:"required"`
+	Name         string            `json:"name" binding:"required"`
+	Issuer       string            `json:"issuer" binding:"required"`
+	ClientID     string            `json:"clientId" binding:"required"`
+	ClientSecret *string           `json:"clientSecret"`
+	Scopes       []string          `json:"scopes"`
+	EmailDomains []string          `json:"emailDomains" binding:"required,min=1"`
+	RoleClaim    string            `json:"roleClaim"`
+	RoleMapping  map[string]string `json:"roleMapping"`
+	DefaultRole  string            `json:"defaultRole"`
+	Enabled      *bool             `json:"enabled"`
+}
+
+type identityProviderResponse struct {
+	ID              uuid.UUID                        `json:"id"`
+	OrganizationID  uuid.UUID                        `json:"organizationId"`
+	Slug            string                           `json:"slug"`
+	Name            string                           `json:"name"`
+	Issuer          string                           `json:"issuer"`
+	ClientID        string                           `json:"clientId"`
+	HasClientSecret bool                             `json:"hasClientSecret"`
+	Scopes          []string                         `json:"scopes"`
+	EmailDomains    []identityProviderDomainResponse `json:"emailDomains"`
+	RoleClaim       string                           `json:"roleClaim,omitempty"`
+	RoleMapping     datatypes.JSONMap                `json:"roleMapping,omitempty"`
+	DefaultRole     string                           `json:"defaultRole,omitempty"`
+	Enabled         bool                             `json:"enabled"`
+	RedirectURI     string                           `json:"redirectUri"`
+	LoginURL        string                           `json:"loginUrl"`
+	CreatedAt       time.Time                        `json:"createdAt"`
+	UpdatedAt       time.Time                        `json:"updatedAt"`
+}
+
+// identityProviderDomainResponse describes a domain claim and, until it is
+// verified, the TXT record that proves control of the domain.
+type identityProviderDomainResponse struct {
+	Domain      string     `json:"domain"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+	RecordName  string     `json:"recordName,omitempty"`
+	RecordValue string     `json:"recordValue,omitempty"`
+}
+
+// HandleListIdentityProviders lists the single sign-on providers of the
+// :orgId organization to its owners and admins.
+func (h *AuthHandler) HandleListIdentityProviders(ctx *gin.Context) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var providers []models.IdentityProvider
+	if err := h.db.Where("organization_id = ?", membership.OrganizationID).Order("slug").Find(&providers).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch identity providers"})
+		return
+	}
+
+	providerIDs := make([]uuid.UUID, 0, len(providers))
+	for _, provider := range providers {
+		providerIDs = append(providerIDs, provider.ID)
+	}
+	var domains []models.IdentityProviderDomain
+	if len(providerIDs) > 0 {
+		if err := h.db.Where("provider_id IN ?", providerIDs).Order("domain").Find(&domains).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch identity providers"})
+			return
+		}
+	}
+	domainsByProvider := map[uuid.UUID][]models.IdentityProviderDomain{}
+	for _, domain := range domains {
+		domainsByProvider[domain.ProviderID] = append(domainsByProvider[domain.ProviderID], domain)
+	}
+
+	payload := make([]identityProviderResponse, 0, len(providers))
+	for i := range providers {
+		payload = append(payload, h.toIdentityProviderResponse(&providers[i], domainsByProvider[providers[i].ID]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"identityProviders": payload})
+}
+
+func (h *AuthHandler) HandleCreateIdentityProvider(ctx *gin.Context) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	provider := models.IdentityProvider{OrganizationID: membership.OrganizationID}
+	requested, ok := h.bindIdentityProvider(ctx, &provider)
+	if !ok {
+		return
+	}
+
+	var domains []models.IdentityProviderDomain
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&provider).Error; err != nil {
+			return err
+		}
+		var err error
+		domains, err = syncProviderDomains(tx, provider.ID, requested)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to save identity provider"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionIdentityProviderSave, &currentUser(ctx).ID, nil, map[string]any{
+		"organizationId": provider.OrganizationID.String(),
+		"provider":       provider.Slug,
+	})
+	ctx.JSON(http.StatusCreated, gin.H{"identityProvider": h.toIdentityProviderResponse(&provider, domains)})
+}
+
+func (h *AuthHandler) HandleUpdateIdentityProvider(ctx *gin.Context) {
+	provider, ok := h.loadIdentityProvider(ctx)
+	if !ok {
+		return
+	}
+	requested, ok := h.bindIdentityProvider(ctx, provider)
+	if !ok {
+		return
+	}
+
+	var domains []models.IdentityProviderDomain
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(provider).Error; err != nil {
+			return err
+		}
+		var err error
+		domains, err = syncProviderDomains(tx, provider.ID, requested)
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to save identity provider"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionIdentityProviderSave, &currentUser(ctx).ID, nil, map[string]any{
+		"organizationId": provider.OrganizationID.String(),
+		"provider":       provider.Slug,
+	})
+	ctx.JSON(http.StatusOK, gin.H{"identityProvider": h.toIdentityProviderResponse(provider, domains)})
+}
+
+// HandleVerifyIdentityProviderDomain puts the provider's claim on
+// :domain into effect once the domain publishes the claim's TXT record.
+func (h *AuthHandler) HandleVerifyIdentityProviderDomain(ctx *gin.Context) {
+	provider, ok := h.loadIdentityProvider(ctx)
+	if !ok {
+		return
+	}
+	claim, ok := h.loadProviderDomain(ctx, provider)
+	if !ok {
+		return
+	}
+
+	if !claim.Verified() {
+		lookupCtx, cancel := context.WithTimeout(ctx.Request.Context(), domainVerificationTimeout)
+		records, err := h.lookupTXT(lookupCtx, domainVerificationPrefix+claim.Domain)
+		cancel()
+		if err != nil || !slices.Contains(records, domainVerificationValue+claim.VerificationToken) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error": "TXT record " + domainVerificationValue + claim.VerificationToken + " not found at " +
+					domainVerificationPrefix + claim.Domain,
+			})
+			return
+		}
+	}
+
+	h.verifyProviderDomain(ctx, provider, claim, "dns")
+}
+
+// HandleAdminVerifyIdentityProviderDomain lets a platform administrator
+// approve a domain claim whose owner cannot publish DNS records.
+func (h *AuthHandler) HandleAdminVerifyIdentityProviderDomain(ctx *gin.Context) {
+	providerID, err := uuid.Parse(ctx.Param("providerId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity provider id"})
+		return
+	}
+	var provider models.IdentityProvider
+	if err := h.db.First(&provider, "id = ?", providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Identity provider not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch identity provider"})
+		return
+	}
+	claim, ok := h.loadProviderDomain(ctx, &provider)
+	if !ok {
+		return
+	}
+
+	h.verifyProviderDomain(ctx, &provider, claim, "admin")
+}
+
+// verifyProviderDomain marks claim verified unless another provider already
+// holds the domain, and responds with the provider.
+func (h *AuthHandler) verifyProviderDomain(ctx *gin.Context, provider *models.IdentityProvider, claim *models.IdentityProviderDomain, method string) {
+	if !claim.Verified() {
+		now := time.Now().UTC()
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			var taken int64
+			if err := tx.Model(&models.IdentityProviderDomain{}).
+				Where("domain = ? AND provider_id <> ? AND verified_at IS NOT NULL", claim.Domain, provider.ID).
+				Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return errDomainVerifiedElsewhere
+			}
+			return tx.Model(claim).Update("verified_at", now).Error
+		})
+		if errors.Is(err, errDomainVerifiedElsewhere) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Email domain " + claim.Domain + " is already verified by another identity provider"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to verify email domain"})
+			return
+		}
+
+		recordAudit(h.db, ctx, auditActionIdentityProviderDomainVerify, &currentUser(ctx).ID, nil, map[string]any{
+			"organizationId": provider.OrganizationID.String(),
+			"provider":       provider.Slug,
+			"domain":         claim.Domain,
+			"method":         method,
+		})
+	}
+
+	var domains []models.IdentityProviderDomain
+	if err := h.db.Where("provider_id = ?", provider.ID).Order("domain").Find(&domains).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch identity provider"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"identityProvider": h.toIdentityProviderResponse(provider, domains)})
+}
+
+// loadProviderDomain loads provider's claim on the :domain domain.
+func (h *AuthHandler) loadProviderDomain(ctx *gin.Context, provider *models.IdentityProvider) (*models.IdentityProviderDomain, bool) {
+	var claim models.IdentityProviderDomain
+	err := h.db.First(&claim, "provider_id = ? AND domain = ?", provider.ID, strings.ToLower(ctx.Param("domain"))).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Email domain not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch email domain"})
+		return nil, false
+	}
+	return &claim, true
+}
+
+// HandleDeleteIdentityProvider removes the configuration and its account
+// links. Accounts it provisioned remain and can sign in with a password once
+// they set one.
+func (h *AuthHandler) HandleDeleteIdentityProvider(ctx *gin.Context) {
+	provider, ok := h.loadIdentityProvider(ctx)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&models.SSOLoginState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("provider_id = ?", provider.ID).Delete(&models.IdentityProviderDomain{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(provider).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to delete identity provider"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionIdentityProviderDelete, &currentUser(ctx).ID, nil, map[string]any{
+		"organizationId": provider.OrganizationID.String(),
+		"provider":       provider.Slug,
+	})
+	ctx.Status(http.StatusNoContent)
+}
+
+// loadIdentityProvider loads the :providerId provider of the :orgId
+// organization for one of its owners or admins.
+func (h *AuthHandler) loadIdentityProvider(ctx *gin.Context) (*models.IdentityProvider, bool) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return nil, false
+	}
+
+	providerID, err := uuid.Parse(ctx.Param("providerId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity provider id"})
+		return nil, false
+	}
+
+	var provider models.IdentityProvider
+	if err := h.db.First(&provider, "id = ? AND organization_id = ?", providerID, membership.OrganizationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Identity provider not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch identity provider"})
+		return nil, false
+	}
+	return &provider, true
+}
+
+// bindIdentityProvider validates the request body, copies it onto provider
+// and returns the requested email domains. An omitted clientSecret keeps the
+// stored one; an empty string clears it for public clients.
+func (h *AuthHandler) bindIdentityProvider(ctx *gin.Context, provider *models.IdentityProvider) ([]string, bool) {
+	var req identityProviderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity provider payload"})
+		return nil, false
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !providerSlugPattern.MatchString(slug) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Slug must be 2-63 lowercase letters, digits or hyphens"})
+		return nil, false
+	}
+
+	issuer := strings.TrimRight(strings.TrimSpace(req.Issuer), "/")
+	if parsed, err := url.Parse(issuer); err != nil || parsed.Host == "" ||
+		(parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.RawQuery != "" || parsed.Fragment != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Issuer must be an absolute http(s) URL"})
+		return nil, false
+	}
+
+	domains := make([]string, 0, len(req.EmailDomains))
+	for _, domain := range req.EmailDomains {
+		domain = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(domain, "@")))
+		if domain == "" || strings.ContainsAny(domain, " @/") || !strings.Contains(domain, ".") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email domain: " + domain})
+			return nil, false
+		}
+		if publicEmailDomains[domain] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Public email domains cannot be claimed: " + domain})
+			return nil, false
+		}
+		if !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
+
+	scopes := []string{"openid"}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{"email", "profile"}
+	}
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope != "" && scope != "openid" && !strings.ContainsAny(scope, " \"") {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	defaultRole := strings.ToLower(strings.TrimSpace(req.DefaultRole))
+	if defaultRole != "" && !isSSOAssignableRole(defaultRole) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "defaultRole must be client or lawyer"})
+		return nil, false
+	}
+	mapping := datatypes.JSONMap{}
+	for value, role := range req.RoleMapping {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !isSSOAssignableRole(role) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "roleMapping values must be client or lawyer"})
+			return nil, false
+		}
+		mapping[value] = role
+	}
+
+	if req.ClientSecret != nil {
+		provider.ClientSecretEncrypted = ""
+		if *req.ClientSecret != "" {
+			sealed, err := h.keyring.Seal(ssoClientSecretPurpose, []byte(*req.ClientSecret))
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to save identity provider"})
+				return nil, false
+			}
+			provider.ClientSecretEncrypted = sealed
+		}
+	}
+
+	var existing models.IdentityProvider
+	if err := h.db.Where("slug = ? AND id <> ?", slug, provider.ID).First(&existing).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An identity provider with this slug already exists"})
+		return nil, false
+	}
+	// Discovery routes an email domain to a single provider, so a domain
+	// belongs to whichever organization proved control of it. Unverified
+	// claims do not block anyone, or a squatter could lock the owner out.
+	for _, domain := range domains {
+		var taken int64
+		if err := h.db.Model(&models.IdentityProviderDomain{}).
+			Where("domain = ? AND provider_id <> ? AND verified_at IS NOT NULL", domain, provider.ID).
+			Count(&taken).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to save identity provider"})
+			return nil, false
+		}
+		if taken > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Email domain " + domain + " is already verified by another identity provider"})
+			return nil, false
+		}
+	}
+
+	provider.Slug = slug
+	provider.Name = truncateString(strings.TrimSpace(req.Name), 255)
+	provider.Issuer = issuer
+	provider.ClientID = strings.TrimSpace(req.ClientID)
+	provider.Scopes = strings.Join(scopes, " ")
+	provider.RoleClaim = strings.TrimSpace(req.RoleClaim)
+	provider.RoleMapping = mapping
+	provider.DefaultRole = defaultRole
+	provider.Enabled = req.Enabled == nil || *req.Enabled
+	return domains, true
+}
+
+// syncProviderDomains makes domains the provider's claims: claims on other
+// domains are dropped, new ones start unverified with a fresh token, and kept
+// ones retain their verification.
+func syncProviderDomains(tx *gorm.DB, providerID uuid.UUID, domains []string) ([]models.IdentityProviderDomain, error) {
+	if err := tx.Where("provider_id = ? AND domain NOT IN ?", providerID, domains).
+		Delete(&models.IdentityProviderDomain{}).Error; err != nil {
+		return nil, err
+	}
+	var existing []models.IdentityProviderDomain
+	if err := tx.Where("provider_id = ?", providerID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	claimed := map[string]bool{}
+	for _, claim := range existing {
+		claimed[claim.Domain] = true
+	}
+	for _, domain := range domains {
+		if claimed[domain] {
+			continue
+		}
+		token, err := generateSessionToken()
+		if err != nil {
+			return nil, err
+		}
+		claim := models.IdentityProviderDomain{ProviderID: providerID, Domain: domain, VerificationToken: token}
+		if err := tx.Create(&claim).Error; err != nil {
+			return nil, err
+		}
+		existing = append(existing, claim)
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].Domain < existing[j].Domain })
+	return existing, nil
+}
+
+func (h *AuthHandler) toIdentityProviderResponse(provider *models.IdentityProvider, domains []models.IdentityProviderDomain) identityProviderResponse {
+	domainPayload := make([]identityProviderDomainResponse, 0, len(domains))
+	for _, claim := range domains {
+		item := identityProviderDomainResponse{Domain: claim.Domain, Verified: claim.Verified(), VerifiedAt: claim.VerifiedAt}
+		if !claim.Verified() {
+			item.RecordName = domainVerificationPrefix + claim.Domain
+			item.RecordValue = domainVerificationValue + claim.VerificationToken
+		}
+		domainPayload = append(domainPayload, item)
+	}
+	return identityProviderResponse{
+		ID:              provider.ID,
+		OrganizationID:  provider.OrganizationID,
+		Slug:            provider.Slug,
+		Name:            provider.Name,
+		Issuer:          provider.Issuer,
+		ClientID:        provider.ClientID,
+		HasClientSecret: provider.ClientSecretEncrypted != "",
+		Scopes:          provider.ScopeList(),
+		EmailDomains:    domainPayload,
+		RoleClaim:       provider.RoleClaim,
+		RoleMapping:     provider.RoleMapping,
+		DefaultRole:     provider.DefaultRole,
+		Enabled:         provider.Enabled,
+		RedirectURI:     h.ssoURL(provider, "callback"),
+		LoginURL:        h.ssoURL(provider, "login"),
+		CreatedAt:       provider.CreatedAt,
+		UpdatedAt:       provider.UpdatedAt,
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	lawyerDirectoryPageSize    = 25
+	lawyerDirectoryMaxPageSize = 100
+	lawyerProfileMaxTags       = 20
+	auditActionLawyerProfile   = "lawyer.profile_update"
+)
+
+type lawyerProfileRequest struct {
+	Headline        string                `json:"headline"`
+	Bio             string                `json:"bio"`
+	PracticeAreas   []string              `json:"practiceAreas"`
+	Jurisdictions   []string              `json:"jurisdictions"`
+	Languages       []string              `json:"languages"`
+	BarAdmissions   []models.BarAdmission `json:"barAdmissions"`
+	YearsExperience int                   `json:"yearsExperience"`
+	HourlyRate      int                   `json:"hourlyRate"`
+	Currency        string                `json:"currency"`
+	Availability    string                `json:"availability"`
+}
+
+type lawyerProfileResponse struct {
+	ID              uuid.UUID             `json:"id"`
+	CompanyName     string                `json:"companyName"`
+	Email           string                `json:"email"`
+	Headline        string                `json:"headline"`
+	Bio             string                `json:"bio"`
+	PracticeAreas   []string              `json:"practiceAreas"`
+	Jurisdictions   []string              `json:"jurisdictions"`
+	Languages       []string              `json:"languages"`
+	BarAdmissions   []models.BarAdmission `json:"barAdmissions"`
+	YearsExperience int                   `json:"yearsExperience"`
+	HourlyRate      int                   `json:"hourlyRate,omitempty"`
+	Currency        string                `json:"currency"`
+	Availability    string                `json:"availability"`
+	Firm            *firmResponse         `json:"firm,omitempty"`
+	UpdatedAt       time.Time             `json:"updatedAt"`
+}
+
+// lawyerDirectoryTagFilters maps directory query parameters onto the profile
+// tag columns they match.
+var lawyerDirectoryTagFilters = []struct{ param, column string }{
+	{"practiceArea", "lawyer_profiles.practice_areas"},
+	{"jurisdiction", "lawyer_profiles.jurisdictions"},
+	{"language", "lawyer_profiles.languages"},
+}
+
+// lawyerDirectorySorts maps the sort query parameter onto an ordering.
+// Lawyers without a published rate sort last by rate.
+var lawyerDirectorySorts = map[string]string{
+	"name":       "users.company_name",
+	"experience": "lawyer_profiles.years_experience DESC, users.company_name",
+	"rate":       "lawyer_profiles.hourly_rate = 0, lawyer_profiles.hourly_rate, users.company_name",
+	"updated":    "lawyer_profiles.updated_at DESC",
+}
+
+// HandleListLawyers is the lawyer directory clients pick counsel from. It
+// lists approved, active lawyers who published a profile. Filters: q (name,
+// headline or bio), practiceArea, jurisdiction, language and admittedIn, each
+// repeatable and all required to match, minExperience, maxRate and
+// availability; sort is name, experience, rate or updated; limit and offset
+// page the results.
+func (h *AuthHandler) HandleListLawyers(ctx *gin.Context) {
+	query := h.db.Model(&models.LawyerProfile{}).
+		Joins("JOIN users ON users.id = lawyer_profiles.user_id").
+		Where("users.role = ? AND users.approval_status = ? AND users.suspended_at IS NULL",
+			models.UserRoleLawyer, models.ApprovalApproved)
+
+	if q := strings.TrimSpace(ctx.Query("q")); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		query = query.Where("users.company_name ILIKE ? OR lawyer_profiles.headline ILIKE ? OR lawyer_profiles.bio ILIKE ?",
+			pattern, pattern, pattern)
+	}
+	for _, filter := range lawyerDirectoryTagFilters {
+		if tags := normaliseTags(ctx.QueryArray(filter.param)); len(tags) > 0 {
+			query = query.Where(filter.column+" @> ?::jsonb", mustMarshalJSON(tags))
+		}
+	}
+	for _, jurisdiction := range normaliseTags(ctx.QueryArray("admittedIn")) {
+		query = query.Where("lawyer_profiles.bar_admissions @> ?::jsonb",
+			mustMarshalJSON([]models.BarAdmission{{Jurisdiction: jurisdiction}}))
+	}
+	if raw := ctx.Query("minExperience"); raw != "" {
+		years, err := strconv.Atoi(raw)
+		if err != nil || years < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid minExperience"})
+			return
+		}
+		query = query.Where("lawyer_profiles.years_experience >= ?", years)
+	}
+	if raw := ctx.Query("maxRate"); raw != "" {
+		rate, err := strconv.Atoi(raw)
+		if err != nil || rate < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maxRate"})
+			return
+		}
+		query = query.Where("lawyer_profiles.hourly_rate > 0 AND lawyer_profiles.hourly_rate <= ?", rate)
+	}
+	if raw := strings.TrimSpace(ctx.Query("availability")); raw != "" {
+		availability := strings.ToLower(raw)
+		if !slices.Contains(models.Availabilities, availability) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid availability"})
+			return
+		}
+		query = query.Where("lawyer_profiles.availability = ?", availability)
+	}
+
+	order, ok := lawyerDirectorySorts[defaultString(ctx.Query("sort"), "name")]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		return
+	}
+
+	limit, offset, ok := parsePage(ctx, lawyerDirectoryPageSize, lawyerDirectoryMaxPageSize)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyers"})
+		return
+	}
+
+	var profiles []models.LawyerProfile
+	if err := query.Preload("User").Order(order).Limit(limit).Offset(offset).Find(&profiles).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyers"})
+		return
+	}
+
+	payload, err := toLawyerProfileResponses(h.db, profiles)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyers"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"lawyers": payload, "total": total})
+}
+
+// HandleGetLawyer returns one directory entry.
+func (h *AuthHandler) HandleGetLawyer(ctx *gin.Context) {
+	lawyerID, err := uuid.Parse(ctx.Param("lawyerId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lawyer id"})
+		return
+	}
+
+	var profile models.LawyerProfile
+	if err := h.db.Preload("User").Where("user_id = ?", lawyerID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Lawyer not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyer"})
+		return
+	}
+	if !profile.User.Assignable() {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Lawyer not found"})
+		return
+	}
+
+	payload, err := toLawyerProfileResponses(h.db, []models.LawyerProfile{profile})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch lawyer"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"lawyer": payload[0]})
+}
+
+// HandleGetOwnLawyerProfile returns the calling lawyer's profile, which is
+// empty until they save one.
+func (h *AuthHandler) HandleGetOwnLawyerProfile(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	profile := models.LawyerProfile{UserID: user.ID, Currency: "USD", Availability: models.AvailabilityOpen}
+	if err := h.db.Where("user_id = ?", user.ID).First(&profile).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch profile"})
+		return
+	}
+	profile.User = *user
+
+	payload, err := toLawyerProfileResponses(h.db, []models.LawyerProfile{profile})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch profile"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profile": payload[0]})
+}
+
+// HandleUpdateOwnLawyerProfile replaces the calling lawyer's profile. The
+// profile shows in the directory once an administrator approved the lawyer.
+func (h *AuthHandler) HandleUpdateOwnLawyerProfile(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req lawyerProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile payload"})
+		return
+	}
+
+	availability := strings.ToLower(strings.TrimSpace(defaultString(req.Availability, models.AvailabilityOpen)))
+	if !slices.Contains(models.Availabilities, availability) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid availability"})
+		return
+	}
+	currency := strings.ToUpper(strings.TrimSpace(defaultString(req.Currency, "USD")))
+	if len(currency) != 3 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+		return
+	}
+	if req.YearsExperience < 0 || req.YearsExperience > 80 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid yearsExperience"})
+		return
+	}
+	if req.HourlyRate < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hourlyRate"})
+		return
+	}
+	if len(req.PracticeAreas) > lawyerProfileMaxTags || len(req.Jurisdictions) > lawyerProfileMaxTags ||
+		len(req.Languages) > lawyerProfileMaxTags || len(req.BarAdmissions) > lawyerProfileMaxTags {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Profiles list at most 20 entries of each kind"})
+		return
+	}
+	admissions, ok := normaliseBarAdmissions(req.BarAdmissions)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bar admission"})
+		return
+	}
+
+	var profile models.LawyerProfile
+	err := h.db.Where("user_id = ?", user.ID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update profile"})
+		return
+	}
+	profile.UserID = user.ID
+	profile.Headline = truncateString(strings.TrimSpace(req.Headline), 255)
+	profile.Bio = truncateString(strings.TrimSpace(req.Bio), 5000)
+	profile.PracticeAreas = datatypes.JSONType[[]string]{Data: normaliseTags(req.PracticeAreas)}
+	profile.Jurisdictions = datatypes.JSONType[[]string]{Data: normaliseTags(req.Jurisdictions)}
+	profile.Languages = datatypes.JSONType[[]string]{Data: normaliseTags(req.Languages)}
+	profile.BarAdmissions = datatypes.JSONType[[]models.BarAdmission]{Data: admissions}
+	profile.YearsExperience = req.YearsExperience
+	profile.HourlyRate = req.HourlyRate
+	profile.Currency = currency
+	profile.Availability = availability
+
+	if err := h.db.Omit("User").Save(&profile).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update profile"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionLawyerProfile, &user.ID, &user.ID, nil)
+
+	profile.User = *user
+	payload, err := toLawyerProfileResponses(h.db, []models.LawyerProfile{profile})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch profile"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profile": payload[0]})
+}
+
+// toLawyerProfileResponses renders profiles with their User loaded, looking up
+// the firm each lawyer belongs to in one query.
+func toLawyerProfileResponses(db *gorm.DB, profiles []models.LawyerProfile) ([]lawyerProfileResponse, error) {
+	userIDs := make([]uuid.UUID, 0, len(profiles))
+	for i := range profiles {
+		userIDs = append(userIDs, profiles[i].UserID)
+	}
+
+	var memberships []models.FirmMembership
+	if len(userIDs) > 0 {
+		if err := db.Preload("Firm").Where("user_id IN ?", userIDs).Find(&memberships).Error; err != nil {
+			return nil, err
+		}
+	}
+	firms := make(map[uuid.UUID]*firmResponse, len(memberships))
+	for i := range memberships {
+		firms[memberships[i].UserID] = &firmResponse{
+			ID:        memberships[i].Firm.ID,
+			Name:      memberships[i].Firm.Name,
+			CreatedAt: memberships[i].Firm.CreatedAt,
+		}
+	}
+
+	payload := make([]lawyerProfileResponse, 0, len(profiles))
+	for i := range profiles {
+		profile := &profiles[i]
+		payload = append(payload, lawyerProfileResponse{
+			ID:              profile.UserID,
+			CompanyName:     profile.User.CompanyName,
+			Email:           profile.User.Email,
+			Headline:        profile.Headline,
+			Bio:             profile.Bio,
+			PracticeAreas:   nonNilStrings(profile.PracticeAreas.Data),
+			Jurisdictions:   nonNilStrings(profile.Jurisdictions.Data),
+			Languages:       nonNilStrings(profile.Languages.Data),
+			BarAdmissions:   append([]models.BarAdmission{}, profile.BarAdmissions.Data...),
+			YearsExperience: profile.YearsExperience,
+			HourlyRate:      profile.HourlyRate,
+			Currency:        profile.Currency,
+			Availability:    profile.Availability,
+			Firm:            firms[profile.UserID],
+			UpdatedAt:       profile.UpdatedAt,
+		})
+	}
+	return payload, nil
+}
+
+// normaliseTags lower-cases, trims and de-duplicates directory tags, dropping
+// empty ones. Comma-separated values are split so query strings may use
+// either form.
+func normaliseTags(values []string) []string {
+	tags := make([]string, 0, len(values))
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = truncateString(strings.ToLower(strings.TrimSpace(tag)), 64)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// normaliseBarAdmissions cleans up bar admissions, which all need a
+// jurisdiction and, when given, a plausible year.
+func normaliseBarAdmissions(admissions []models.BarAdmission) ([]models.BarAdmission, bool) {
+	cleaned := make([]models.BarAdmission, 0, len(admissions))
+	for _, admission := range admissions {
+		jurisdiction := truncateString(strings.ToLower(strings.TrimSpace(admission.Jurisdiction)), 64)
+		if jurisdiction == "" || admission.Year < 0 || (admission.Year > 0 && (admission.Year < 1900 || admission.Year > time.Now().Year())) {
+			return nil, false
+		}
+		cleaned = append(cleaned, models.BarAdmission{
+			Jurisdiction: jurisdiction,
+			Number:       truncateString(strings.TrimSpace(admission.Number), 64),
+			Year:         admission.Year,
+		})
+	}
+	return cleaned, true
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func mustMarshalJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/totp"
+	"lexiflow/backend/internal/webauthn"
+)
+
+const (
+	mfaIssuer            = "LexiFlow"
+	mfaChallengeTTL      = 5 * time.Minute
+	mfaChallengeAttempts = 5
+	mfaTOTPSkew          = 1
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	totpSecretPurpose    = "totp-secret"
+)
+
+var (
+	errMFAChallengeInvalid = errors.New("mfa challenge invalid")
+	errMFACodeInvalid      = errors.New("mfa code invalid")
+)
+
+type mfaChallengeRequest struct {
+	ChallengeToken string                      `json:"challengeToken" binding:"required"`
+	Code           string                      `json:"code"`
+	RecoveryCode   string                      `json:"recoveryCode"`
+	Passkey        *webauthn.AssertionResponse `json:"passkey"`
+}
+
+type mfaCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type mfaDisableRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type mfaChallengeResponse struct {
+	MFARequired        bool     `json:"mfaRequired"`
+	EnrollmentRequired bool     `json:"enrollmentRequired"`
+	ChallengeToken     string   `json:"challengeToken"`
+	ChallengeExpiresAt string   `json:"challengeExpiresAt"`
+	Methods            []string `json:"methods"`
+}
+
+type totpEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+type mfaStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recoveryCodesRemaining"`
+	Passkeys               int64 `json:"passkeys"`
+}
+
+type mfaLoginResponse struct {
+	authSuccessResponse
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// mfaRequired reports whether policy forces a second factor for the user,
+// either individually or through their role.
+func (h *AuthHandler) mfaRequired(user *models.User) bool {
+	if user.MFARequired {
+		return true
+	}
+	for _, role := range h.mfaRequiredRoles {
+		if role == user.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// respondMFAChallenge parks a password-authenticated login until the second
+// factor is presented to /auth/login/mfa.
+func (h *AuthHandler) respondMFAChallenge(ctx *gin.Context, user *models.User, rememberMe bool) {
+	token, challenge, err := h.startMFAChallenge(ctx, user, rememberMe)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start two-factor challenge"})
+		return
+	}
+
+	// A registered passkey satisfies the requirement on its own; only users
+	// with no second factor at all must enroll an authenticator first.
+	passkeys, err := h.countPasskeys(user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start two-factor challenge"})
+		return
+	}
+	hasPasskeys := passkeys > 0
+	enrollmentRequired := !user.MFAEnabled && !hasPasskeys
+	methods := []string{}
+	if user.MFAEnabled {
+		methods = append(methods, "totp", "recovery_code")
+	}
+	if hasPasskeys {
+		methods = append(methods, "passkey")
+	}
+	if enrollmentRequired {
+		methods = []string{"totp"}
+	}
+
+	ctx.JSON(http.StatusAccepted, mfaChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: enrollmentRequired,
+		ChallengeToken:     token,
+		ChallengeExpiresAt: challenge.ExpiresAt.Format(time.RFC3339),
+		Methods:            methods,
+	})
+}
+
+// startMFAChallenge stores a pending second-factor challenge for user and
+// returns the raw token the client must present with the code.
+func (h *AuthHandler) startMFAChallenge(ctx *gin.Context, user *models.User, rememberMe bool) (string, *models.MFAChallenge, error) {
+	token, err := generateSessionToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().UTC()
+	_ = h.db.Where("expires_at < ?", now).Delete(&models.MFAChallenge{}).Error
+
+	challenge := models.MFAChallenge{
+		UserID:     user.ID,
+		TokenHash:  hashToken(token),
+		UserAgent:  truncateString(ctx.GetHeader("User-Agent"), 255),
+		IP:         truncateString(ctx.ClientIP(), 64),
+		RememberMe: rememberMe,
+		ExpiresAt:  now.Add(mfaChallengeTTL),
+	}
+	if err := h.db.Create(&challenge).Error; err != nil {
+		return "", nil, err
+	}
+
+	return token, &challenge, nil
+}
+
+func (h *AuthHandler) HandleCompleteMFALogin(ctx *gin.Context) {
+	var req mfaChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor payload"})
+		return
+	}
+
+	challenge, user, err := h.loadMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		h.respondChallengeError(ctx, err)
+		return
+	}
+
+	enrollmentRequired := false
+	if req.Passkey == nil && !user.MFAEnabled {
+		if enrollmentRequired, err = h.mfaEnrollmentRequired(user); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to verify authentication code"})
+			return
+		}
+		// A user whose only second factor is a passkey must present it;
+		// confirming a new authenticator would let a password alone in.
+		if !enrollmentRequired {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "This account signs in with a passkey", "methods": []string{"passkey"}})
+			return
+		}
+	}
+
+	// Second-factor guesses count against the same login budget as
+	// passwords so fresh challenges cannot be used to keep guessing.
+	subjects := throttleSubjects(ctx, loginAccountRule, loginIPRule, user.Email)
+	if !h.checkThrottle(ctx, throttleScopeLogin, subjects) {
+		return
+	}
+
+	var recoveryCodes []string
+	if req.Passkey != nil {
+		err = h.verifyPasskeySecondFactor(ctx, challenge, req.Passkey)
+	} else {
+		err = h.db.Transaction(func(tx *gorm.DB) error {
+			if !enrollmentRequired {
+				return h.verifySecondFactor(tx, user, req.Code, req.RecoveryCode)
+			}
+			// Policy forced enrollment: the first valid code confirms the
+			// authenticator registered through /auth/login/mfa/setup.
+			if err := h.confirmTOTP(tx, user, req.Code); err != nil {
+				return err
+			}
+			codes, err := h.issueRecoveryCodes(tx, user)
+			recoveryCodes = codes
+			return err
+		})
+	}
+	if err != nil {
+		if errors.Is(err, errMFACodeInvalid) {
+			h.recordChallengeFailure(challenge)
+			h.recordThrottleFailure(ctx, throttleScopeLogin, subjects, &user.ID)
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to verify authentication code"})
+		return
+	}
+
+	_ = h.db.Delete(challenge).Error
+	h.resetThrottle(throttleScopeLogin, subjects[0])
+
+	session, err := h.createSession(ctx, user, challenge.RememberMe)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create session"})
+		return
+	}
+	h.setSessionCookie(ctx, session)
+
+	ctx.JSON(http.StatusOK, mfaLoginResponse{
+		authSuccessResponse: h.authSuccess(user, session),
+		RecoveryCodes:       recoveryCodes,
+	})
+}
+
+func (h *AuthHandler) HandleChallengeTOTPSetup(ctx *gin.Context) {
+	var req mfaChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor payload"})
+		return
+	}
+
+	_, user, err := h.loadMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		h.respondChallengeError(ctx, err)
+		return
+	}
+	// Only a login with no second factor at all may enroll one; anyone with
+	// TOTP or a passkey has to present it instead.
+	enrollmentRequired, err := h.mfaEnrollmentRequired(user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start two-factor setup"})
+		return
+	}
+	if !enrollmentRequired {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A second factor is already set up for this account"})
+		return
+	}
+
+	h.respondTOTPEnrollment(ctx, user)
+}
+
+func (h *AuthHandler) HandleMFAStatus(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var remaining, passkeys int64
+	if err := h.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Count(&remaining).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load two-factor status"})
+		return
+	}
+	if err := h.db.Model(&models.Passkey{}).Where("user_id = ?", user.ID).Count(&passkeys).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load two-factor status"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"mfa": mfaStatusResponse{
+		Enabled:                user.MFAEnabled,
+		Required:               h.mfaRequired(user),
+		RecoveryCodesRemaining: remaining,
+		Passkeys:               passkeys,
+	}})
+}
+
+func (h *AuthHandler) HandleTOTPSetup(ctx *gin.Context) {
+	user := currentUser(ctx)
+	if user.MFAEnabled {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	h.respondTOTPEnrollment(ctx, user)
+}
+
+func (h *AuthHandler) HandleTOTPConfirm(ctx *gin.Context) {
+	user := currentUser(ctx)
+	if user.MFAEnabled {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Authentication code is required"})
+		return
+	}
+
+	var recoveryCodes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.confirmTOTP(tx, user, req.Code); err != nil {
+			return err
+		}
+		codes, err := h.issueRecoveryCodes(tx, user)
+		recoveryCodes = codes
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errMFACodeInvalid) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to enable two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"user":          toUserResponse(user),
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func (h *AuthHandler) HandleDisableMFA(ctx *gin.Context) {
+	user := currentUser(ctx)
+	if h.mfaRequired(user) && !h.hasPasskeys(user.ID) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for this account"})
+		return
+	}
+	if !user.MFAEnabled {
+		ctx.JSON(http.StatusOK, gin.H{"user": toUserResponse(user)})
+		return
+	}
+
+	var req mfaDisableRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor payload"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.verifySecondFactor(tx, user, req.Code, req.RecoveryCode); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.TOTPCredential{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		user.MFAEnabled = false
+		return tx.Model(user).Update("mfa_enabled", false).Error
+	})
+	if err != nil {
+		if errors.Is(err, errMFACodeInvalid) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to disable two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": toUserResponse(user)})
+}
+
+func (h *AuthHandler) HandleRegenerateRecoveryCodes(ctx *gin.Context) {
+	user := currentUser(ctx)
+	if !user.MFAEnabled {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor payload"})
+		return
+	}
+
+	var recoveryCodes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Only an authenticator code may mint new recovery codes.
+		if err := h.verifySecondFactor(tx, user, req.Code, ""); err != nil {
+			return err
+		}
+		codes, err := h.issueRecoveryCodes(tx, user)
+		recoveryCodes = codes
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errMFACodeInvalid) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to regenerate recovery codes"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": recoveryCodes})
+}
+
+func (h *AuthHandler) respondTOTPEnrollment(ctx *gin.Context, user *models.User) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start enrollment"})
+		return
+	}
+	sealed, err := h.keyring.Seal(totpSecretPurpose, []byte(secret))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start enrollment"})
+		return
+	}
+
+	// Restarting enrollment replaces any unconfirmed secret.
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.TOTPCredential{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.TOTPCredential{
+			UserID:          user.ID,
+			SecretEncrypted: sealed,
+		}).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start enrollment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"totp": totpEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURL: totp.URI(mfaIssuer, user.Email, secret),
+	}})
+}
+
+func (h *AuthHandler) loadMFAChallenge(token string) (*models.MFAChallenge, *models.User, error) {
+	var challenge models.MFAChallenge
+	if err := h.db.Preload("User").
+		Where("token_hash = ?", hashToken(strings.TrimSpace(token))).
+		First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errMFAChallengeInvalid
+		}
+		return nil, nil, err
+	}
+
+	if time.Now().UTC().After(challenge.ExpiresAt) || challenge.Attempts >= mfaChallengeAttempts || challenge.User.Suspended() {
+		_ = h.db.Delete(&challenge).Error
+		return nil, nil, errMFAChallengeInvalid
+	}
+
+	return &challenge, &challenge.User, nil
+}
+
+func (h *AuthHandler) respondChallengeError(ctx *gin.Context, err error) {
+	if errors.Is(err, errMFAChallengeInvalid) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor challenge expired, please sign in again"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to load two-factor challenge"})
+}
+
+func (h *AuthHandler) recordChallengeFailure(challenge *models.MFAChallenge) {
+	_ = h.db.Model(challenge).UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery
+// code. Successful TOTP steps are recorded so a code cannot be replayed.
+func (h *AuthHandler) verifySecondFactor(tx *gorm.DB, user *models.User, code, recoveryCode string) error {
+	if strings.TrimSpace(code) != "" {
+		var credential models.TOTPCredential
+		if err := tx.Where("user_id = ? AND confirmed_at IS NOT NULL", user.ID).First(&credential).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errMFACodeInvalid
+			}
+			return err
+		}
+		return h.acceptTOTPCode(tx, &credential, code)
+	}
+
+	if strings.TrimSpace(recoveryCode) != "" {
+		return redeemRecoveryCode(tx, user, recoveryCode)
+	}
+
+	return errMFACodeInvalid
+}
+
+// confirmTOTP checks a code against the pending credential and switches the
+// account over to requiring it.
+func (h *AuthHandler) confirmTOTP(tx *gorm.DB, user *models.User, code string) error {
+	var credential models.TOTPCredential
+	if err := tx.Where("user_id = ? AND confirmed_at IS NULL", user.ID).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errMFACodeInvalid
+		}
+		return err
+	}
+
+	if err := h.acceptTOTPCode(tx, &credential, code); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&credential).Update("confirmed_at", time.Now().UTC()).Error; err != nil {
+		return err
+	}
+
+	user.MFAEnabled = true
+	return tx.Model(user).Update("mfa_enabled", true).Error
+}
+
+func (h *AuthHandler) acceptTOTPCode(tx *gorm.DB, credential *models.TOTPCredential, code string) error {
+	secret, err := h.keyring.Open(totpSecretPurpose, credential.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now().UTC(), mfaTOTPSkew)
+	if !ok {
+		return errMFACodeInvalid
+	}
+
+	result := tx.Model(&models.TOTPCredential{}).
+		Where("id = ? AND last_used_step < ?", credential.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errMFACodeInvalid
+	}
+	return nil
+}
+
+func redeemRecoveryCode(tx *gorm.DB, user *models.User, recoveryCode string) error {
+	normalised := normaliseRecoveryCode(recoveryCode)
+
+	var codes []models.RecoveryCode
+	if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Find(&codes).Error; err != nil {
+		return err
+	}
+
+	for i := range codes {
+		if bcrypt.CompareHashAndPassword([]byte(codes[i].CodeHash), []byte(normalised)) != nil {
+			continue
+		}
+		result := tx.Model(&models.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", codes[i].ID).
+			Update("used_at", time.Now().UTC())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errMFACodeInvalid
+		}
+		return nil
+	}
+
+	return errMFACodeInvalid
+}
+
+// issueRecoveryCodes replaces the user's recovery codes and returns the new
+// plaintext values, which are shown exactly once.
+func (h *AuthHandler) issueRecoveryCodes(tx *gorm.DB, user *models.User) ([]string, error) {
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(normaliseRecoveryCode(code)), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{UserID: user.ID, CodeHash: string(hash)})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	var builder strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			builder.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return builder.String(), nil
+}
+
+func normaliseRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
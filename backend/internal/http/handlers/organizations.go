@@ -0,0 +1,637 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	orgInvitationTTL           = 7 * 24 * time.Hour
+	auditActionOrgInvite       = "organization.invite"
+	auditActionOrgInviteRevoke = "organization.invite_revoke"
+	auditActionOrgJoin         = "organization.join"
+	auditActionOrgMemberRole   = "organization.member_role"
+	auditActionOrgMemberRemove = "organization.member_remove"
+)
+
+var (
+	errOrgRoleInsufficient = errors.New("organization role insufficient")
+	errInvitationInvalid   = errors.New("invitation invalid")
+	errInvitationEmail     = errors.New("invitation addressed to another email")
+	errLastOwner           = errors.New("organization needs an owner")
+)
+
+type organizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type inviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type organizationResponse struct {
+	ID        uuid.UUID                    `json:"id"`
+	Name      string                       `json:"name"`
+	Role      string                       `json:"role"`
+	Members   []organizationMemberResponse `json:"members,omitempty"`
+	CreatedAt time.Time                    `json:"createdAt"`
+	UpdatedAt time.Time                    `json:"updatedAt"`
+}
+
+type organizationMemberResponse struct {
+	ID          uuid.UUID `json:"id"`
+	CompanyName string    `json:"companyName"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joinedAt"`
+}
+
+type organizationInvitationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invitedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (h *AuthHandler) HandleListOrganizations(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var memberships []models.OrganizationMembership
+	if err := h.db.Preload("Organization").
+		Where("user_id = ?", user.ID).
+		Order("created_at").
+		Find(&memberships).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch organizations"})
+		return
+	}
+
+	payload := make([]organizationResponse, 0, len(memberships))
+	for i := range memberships {
+		payload = append(payload, toOrganizationResponse(&memberships[i], nil))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"organizations": payload})
+}
+
+func (h *AuthHandler) HandleCreateOrganization(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req organizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization payload"})
+		return
+	}
+
+	var membership *models.OrganizationMembership
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		membership, err = createOrganization(tx, user, strings.TrimSpace(req.Name))
+		return err
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create organization"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"organization": toOrganizationResponse(membership, nil)})
+}
+
+func (h *AuthHandler) HandleGetOrganization(ctx *gin.Context) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationMembers(membership.OrganizationID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch members"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"organization": toOrganizationResponse(membership, members)})
+}
+
+func (h *AuthHandler) HandleUpdateOrganization(ctx *gin.Context) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req organizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization payload"})
+		return
+	}
+
+	name := truncateString(strings.TrimSpace(req.Name), 255)
+	if err := h.db.Model(&membership.Organization).Update("name", name).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to update organization"})
+		return
+	}
+	membership.Organization.Name = name
+
+	ctx.JSON(http.StatusOK, gin.H{"organization": toOrganizationResponse(membership, nil)})
+}
+
+// HandleUpdateMember changes a member's role. Admins manage members and
+// viewers; only owners can grant, change or revoke the owner and admin roles.
+func (h *AuthHandler) HandleUpdateMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req updateMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member payload"})
+		return
+	}
+	role, ok := normaliseOrgRole(req.Role)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported role", "supportedRoles": models.OrgRoles})
+		return
+	}
+
+	target, ok := h.loadOrgMember(ctx, membership.OrganizationID)
+	if !ok {
+		return
+	}
+	if !canManageOrgRole(membership.Role, target.Role) || !canManageOrgRole(membership.Role, role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only owners can manage owners and admins"})
+		return
+	}
+
+	previous := target.Role
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(target).Update("role", role).Error; err != nil {
+			return err
+		}
+		return ensureOrganizationHasOwner(tx, membership.OrganizationID)
+	})
+	if err != nil {
+		respondOrgMemberError(ctx, err, "Unable to update member")
+		return
+	}
+	target.Role = role
+
+	recordAudit(h.db, ctx, auditActionOrgMemberRole, &user.ID, &target.UserID, map[string]any{
+		"organizationId": membership.OrganizationID.String(),
+		"from":           previous,
+		"to":             role,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"member": toOrganizationMemberResponse(target)})
+}
+
+// HandleRemoveMember removes someone from the organization. Any member may
+// remove themselves; removing others follows the HandleUpdateMember rules.
+// Cases the member opened stay with the organization.
+func (h *AuthHandler) HandleRemoveMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	target, ok := h.loadOrgMember(ctx, membership.OrganizationID)
+	if !ok {
+		return
+	}
+	if target.UserID != user.ID &&
+		(!models.OrgRoleAtLeast(membership.Role, models.OrgRoleAdmin) || !canManageOrgRole(membership.Role, target.Role)) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your organization role does not permit this action"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(target).Error; err != nil {
+			return err
+		}
+		return ensureOrganizationHasOwner(tx, membership.OrganizationID)
+	})
+	if err != nil {
+		respondOrgMemberError(ctx, err, "Unable to remove member")
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionOrgMemberRemove, &user.ID, &target.UserID, map[string]any{
+		"organizationId": membership.OrganizationID.String(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) HandleListInvitations(ctx *gin.Context) {
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var invitations []models.OrganizationInvitation
+	if err := h.db.Preload("InvitedBy").
+		Where("organization_id = ? AND accepted_at IS NULL AND expires_at > ?", membership.OrganizationID, time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch invitations"})
+		return
+	}
+
+	payload := make([]organizationInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		payload = append(payload, toOrganizationInvitationResponse(&invitations[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"invitations": payload})
+}
+
+// HandleInviteMember emails a single-use invitation link. Inviting the same
+// address again replaces its pending invitation.
+func (h *AuthHandler) HandleInviteMember(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req inviteMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+	role, ok := normaliseOrgRole(defaultString(req.Role, models.OrgRoleMember))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported role", "supportedRoles": models.OrgRoles})
+		return
+	}
+	if !canManageOrgRole(membership.Role, role) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only owners can manage owners and admins"})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	var existing int64
+	if err := h.db.Model(&models.OrganizationMembership{}).
+		Joins("JOIN users ON users.id = organization_memberships.user_id").
+		Where("organization_memberships.organization_id = ? AND users.email = ?", membership.OrganizationID, email).
+		Count(&existing).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+	if existing > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Already a member of this organization"})
+		return
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	invitation := models.OrganizationInvitation{
+		OrganizationID: membership.OrganizationID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(token),
+		InvitedByID:    user.ID,
+		ExpiresAt:      time.Now().UTC().Add(orgInvitationTTL),
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ? AND email = ? AND accepted_at IS NULL", membership.OrganizationID, email).
+			Delete(&models.OrganizationInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&invitation).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create invitation"})
+		return
+	}
+
+	msg, err := mailer.Render(mailer.TemplateOrgInvitation, email, mailer.OrgInvitationData{
+		OrganizationName: membership.Organization.Name,
+		InviterName:      user.CompanyName,
+		Role:             role,
+		AcceptURL:        h.appURL("/invitations/accept", url.Values{"token": {token}}),
+		ValidDays:        int(orgInvitationTTL.Hours() / 24),
+	})
+	if err == nil {
+		err = h.mailer.Send(ctx.Request.Context(), msg)
+	}
+	if err != nil {
+		log.Printf("auth: unable to send organization invitation to %s: %v", email, err)
+		_ = h.db.Delete(&invitation).Error
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Unable to send invitation email"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionOrgInvite, &user.ID, nil, map[string]any{
+		"organizationId": membership.OrganizationID.String(),
+		"email":          email,
+		"role":           role,
+	})
+
+	invitation.InvitedBy = *user
+	ctx.JSON(http.StatusCreated, gin.H{"invitation": toOrganizationInvitationResponse(&invitation)})
+}
+
+func (h *AuthHandler) HandleRevokeInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+	membership, ok := h.requireOrgRole(ctx, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(ctx.Param("invitationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation id"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND organization_id = ? AND accepted_at IS NULL", invitationID, membership.OrganizationID).
+		Delete(&models.OrganizationInvitation{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke invitation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionOrgInviteRevoke, &user.ID, nil, map[string]any{
+		"organizationId": membership.OrganizationID.String(),
+		"invitationId":   invitationID.String(),
+	})
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// HandleAcceptInvitation redeems an invitation for the signed-in account,
+// which must use the address the invitation was sent to. Accepting when
+// already a member leaves the existing role unchanged.
+func (h *AuthHandler) HandleAcceptInvitation(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req acceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation payload"})
+		return
+	}
+
+	tokenHash := hashToken(strings.TrimSpace(req.Token))
+	now := time.Now().UTC()
+
+	var membership models.OrganizationMembership
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var invitation models.OrganizationInvitation
+		if err := tx.Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvitationInvalid
+			}
+			return err
+		}
+		if invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+			return errInvitationInvalid
+		}
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return errInvitationEmail
+		}
+
+		result := tx.Model(&models.OrganizationInvitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationInvalid
+		}
+
+		err := tx.Where("organization_id = ? AND user_id = ?", invitation.OrganizationID, user.ID).First(&membership).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			membership = models.OrganizationMembership{
+				OrganizationID: invitation.OrganizationID,
+				UserID:         user.ID,
+				Role:           invitation.Role,
+			}
+			err = tx.Create(&membership).Error
+		}
+		if err != nil {
+			return err
+		}
+		return tx.First(&membership.Organization, "id = ?", invitation.OrganizationID).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvitationInvalid):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invitation is invalid or has expired"})
+		case errors.Is(err, errInvitationEmail):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "This invitation was sent to a different email address"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to accept invitation"})
+		}
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionOrgJoin, &user.ID, &user.ID, map[string]any{
+		"organizationId": membership.OrganizationID.String(),
+		"role":           membership.Role,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"organization": toOrganizationResponse(&membership, nil)})
+}
+
+// requireOrgRole loads the caller's membership in the :orgId organization.
+// Non-members get 404 so organization ids cannot be probed.
+func (h *AuthHandler) requireOrgRole(ctx *gin.Context, required string) (*models.OrganizationMembership, bool) {
+	orgID, err := uuid.Parse(ctx.Param("orgId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization id"})
+		return nil, false
+	}
+
+	membership, err := loadMembership(h.db, orgID, currentUser(ctx).ID, required)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		case errors.Is(err, errOrgRoleInsufficient):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Your organization role does not permit this action"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch organization"})
+		}
+		return nil, false
+	}
+
+	return membership, true
+}
+
+func (h *AuthHandler) loadOrgMember(ctx *gin.Context, orgID uuid.UUID) (*models.OrganizationMembership, bool) {
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return nil, false
+	}
+
+	var member models.OrganizationMembership
+	if err := h.db.Preload("User").Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch member"})
+		return nil, false
+	}
+
+	return &member, true
+}
+
+func (h *AuthHandler) organizationMembers(orgID uuid.UUID) ([]models.OrganizationMembership, error) {
+	var members []models.OrganizationMembership
+	err := h.db.Preload("User").Where("organization_id = ?", orgID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// loadMembership returns userID's membership in orgID, with the organization
+// loaded. It fails with gorm.ErrRecordNotFound for non-members and
+// errOrgRoleInsufficient when the membership role is below required.
+func loadMembership(db *gorm.DB, orgID, userID uuid.UUID, required string) (*models.OrganizationMembership, error) {
+	var membership models.OrganizationMembership
+	if err := db.Preload("Organization").
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&membership).Error; err != nil {
+		return nil, err
+	}
+	if !models.OrgRoleAtLeast(membership.Role, required) {
+		return &membership, errOrgRoleInsufficient
+	}
+	return &membership, nil
+}
+
+// createOrganization creates an organization owned by user.
+func createOrganization(tx *gorm.DB, user *models.User, name string) (*models.OrganizationMembership, error) {
+	org := models.Organization{Name: truncateString(name, 255), CreatedByID: user.ID}
+	if err := tx.Create(&org).Error; err != nil {
+		return nil, err
+	}
+	membership := models.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         user.ID,
+		Role:           models.OrgRoleOwner,
+		Organization:   org,
+	}
+	if err := tx.Omit("Organization", "User").Create(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ensureOrganizationHasOwner fails with errLastOwner when a membership change
+// would leave the organization without an owner. It locks the organization
+// row first, so two owners demoting or removing each other concurrently are
+// counted one after the other and the second change is refused.
+func ensureOrganizationHasOwner(tx *gorm.DB, orgID uuid.UUID) error {
+	var org models.Organization
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&org, "id = ?", orgID).Error; err != nil {
+		return err
+	}
+	var owners int64
+	if err := tx.Model(&models.OrganizationMembership{}).
+		Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errLastOwner
+	}
+	return nil
+}
+
+func respondOrgMemberError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, errLastOwner) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "An organization must keep at least one owner"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// canManageOrgRole reports whether a member with role actor may grant or
+// change the target role: owners manage everyone, admins everyone below them.
+func canManageOrgRole(actor, target string) bool {
+	if actor == models.OrgRoleOwner {
+		return true
+	}
+	return actor == models.OrgRoleAdmin && !models.OrgRoleAtLeast(target, models.OrgRoleAdmin)
+}
+
+func normaliseOrgRole(role string) (string, bool) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	for _, candidate := range models.OrgRoles {
+		if candidate == role {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func toOrganizationResponse(membership *models.OrganizationMembership, members []models.OrganizationMembership) organizationResponse {
+	resp := organizationResponse{
+		ID:        membership.Organization.ID,
+		Name:      membership.Organization.Name,
+		Role:      membership.Role,
+		CreatedAt: membership.Organization.CreatedAt,
+		UpdatedAt: membership.Organization.UpdatedAt,
+	}
+	if members != nil {
+		resp.Members = make([]organizationMemberResponse, 0, len(members))
+		for i := range members {
+			resp.Members = append(resp.Members, toOrganizationMemberResponse(&members[i]))
+		}
+	}
+	return resp
+}
+
+func toOrganizationMemberResponse(member *models.OrganizationMembership) organizationMemberResponse {
+	return organizationMemberResponse{
+		ID:          member.UserID,
+		CompanyName: member.User.CompanyName,
+		Email:       member.User.Email,
+		Role:        member.Role,
+		JoinedAt:    member.CreatedAt,
+	}
+}
+
+func toOrganizationInvitationResponse(invitation *models.OrganizationInvitation) organizationInvitationResponse {
+	return organizationInvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy.Email,
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
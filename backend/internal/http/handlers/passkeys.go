@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/webauthn"
+)
+
+const (
+	defaultPasskeyName          = "Passkey"
+	auditActionPasskeyRegister  = "passkey.register"
+	auditActionPasskeyRemove    = "passkey.remove"
+	auditActionPasskeyCloneSign = "passkey.counter_regressed"
+)
+
+var errPasskeyInvalid = errors.New("passkey invalid")
+
+type passkeyRegistrationRequest struct {
+	Name       string                       `json:"name"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+type passkeyRenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type passkeyLoginOptionsRequest struct {
+	RememberMe bool `json:"rememberMe"`
+	// ChallengeToken asks for options to answer a pending two-factor
+	// challenge rather than to sign in from scratch.
+	ChallengeToken string `json:"challengeToken"`
+}
+
+type passkeyLoginRequest struct {
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+type passkeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	BackedUp   bool       `json:"backedUp"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (h *AuthHandler) HandleListPasskeys(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var passkeys []models.Passkey
+	if err := h.db.Where("user_id = ?", user.ID).Order("created_at").Find(&passkeys).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch passkeys"})
+		return
+	}
+
+	payload := make([]passkeyResponse, 0, len(passkeys))
+	for i := range passkeys {
+		payload = append(payload, toPasskeyResponse(&passkeys[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"passkeys": payload})
+}
+
+func (h *AuthHandler) HandlePasskeyRegistrationOptions(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var existing []models.Passkey
+	if err := h.db.Where("user_id = ?", user.ID).Find(&existing).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start passkey registration"})
+		return
+	}
+
+	challenge, err := h.startWebAuthnCeremony(models.WebAuthnChallenge{
+		Ceremony: models.WebAuthnCeremonyRegistration,
+		UserID:   &user.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start passkey registration"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": h.webauthn.CreationOptions(
+		challenge, user.ID[:], user.Email, user.CompanyName, passkeyDescriptors(existing))})
+}
+
+func (h *AuthHandler) HandleRegisterPasskey(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	var req passkeyRegistrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey payload"})
+		return
+	}
+
+	ceremony, challenge, err := h.consumeWebAuthnCeremony(req.Credential.Response.ClientDataJSON, models.WebAuthnCeremonyRegistration)
+	if err != nil || ceremony.UserID == nil || *ceremony.UserID != user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Passkey registration expired, please try again"})
+		return
+	}
+
+	credential, err := h.webauthn.VerifyRegistration(&req.Credential, challenge)
+	if err != nil {
+		log.Printf("auth: passkey registration rejected for %s: %v", user.Email, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Passkey could not be verified"})
+		return
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(credential.ID)
+	var duplicate models.Passkey
+	if err := h.db.Where("credential_id = ?", credentialID).First(&duplicate).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "This passkey is already registered"})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+
+	passkey := models.Passkey{
+		UserID:         user.ID,
+		Name:           truncateString(name, 255),
+		CredentialID:   credentialID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		Transports:     truncateString(strings.Join(req.Credential.Response.Transports, " "), 255),
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+	}
+	if err := h.db.Create(&passkey).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to save passkey"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionPasskeyRegister, &user.ID, &user.ID, map[string]any{"passkeyId": passkey.ID.String()})
+	ctx.JSON(http.StatusCreated, gin.H{"passkey": toPasskeyResponse(&passkey)})
+}
+
+func (h *AuthHandler) HandleRenamePasskey(ctx *gin.Context) {
+	passkey, ok := h.loadOwnPasskey(ctx)
+	if !ok {
+		return
+	}
+
+	var req passkeyRenameRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	passkey.Name = truncateString(strings.TrimSpace(req.Name), 255)
+	if err := h.db.Model(passkey).Update("name", passkey.Name).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to rename passkey"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"passkey": toPasskeyResponse(passkey)})
+}
+
+func (h *AuthHandler) HandleDeletePasskey(ctx *gin.Context) {
+	user := currentUser(ctx)
+	passkey, ok := h.loadOwnPasskey(ctx)
+	if !ok {
+		return
+	}
+
+	// Removing the last second factor is refused while policy requires one.
+	if h.mfaRequired(user) && !user.MFAEnabled {
+		var count int64
+		if err := h.db.Model(&models.Passkey{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to remove passkey"})
+			return
+		}
+		if count <= 1 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is required for this account; enroll another method first"})
+			return
+		}
+	}
+
+	if err := h.db.Delete(passkey).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to remove passkey"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionPasskeyRemove, &user.ID, &user.ID, map[string]any{"passkeyId": passkey.ID.String()})
+	ctx.Status(http.StatusNoContent)
+}
+
+// HandlePasskeyLoginOptions starts a sign-in. Without a challenge token any
+// discoverable passkey may answer; with one, only the passkeys of the user
+// whose password sign-in is awaiting a second factor.
+func (h *AuthHandler) HandlePasskeyLoginOptions(ctx *gin.Context) {
+	var req passkeyLoginOptionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey payload"})
+		return
+	}
+
+	ceremony := models.WebAuthnChallenge{
+		Ceremony:   models.WebAuthnCeremonyLogin,
+		RememberMe: req.RememberMe,
+	}
+	var allow []webauthn.CredentialDescriptor
+
+	if req.ChallengeToken != "" {
+		mfaChallenge, user, err := h.loadMFAChallenge(req.ChallengeToken)
+		if err != nil {
+			h.respondChallengeError(ctx, err)
+			return
+		}
+		var passkeys []models.Passkey
+		if err := h.db.Where("user_id = ?", user.ID).Find(&passkeys).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start passkey sign-in"})
+			return
+		}
+		if len(passkeys) == 0 {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "No passkeys are registered for this account"})
+			return
+		}
+		allow = passkeyDescriptors(passkeys)
+		ceremony.UserID = &user.ID
+		ceremony.MFAChallengeID = &mfaChallenge.ID
+		ceremony.RememberMe = mfaChallenge.RememberMe
+	}
+
+	challenge, err := h.startWebAuthnCeremony(ceremony)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start passkey sign-in"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"publicKey": h.webauthn.RequestOptions(challenge, allow)})
+}
+
+// HandlePasskeyLogin signs in with a passkey alone. Passkeys require user
+// verification on the authenticator, so the login already combines
+// possession with a PIN or biometric and satisfies any two-factor policy.
+func (h *AuthHandler) HandlePasskeyLogin(ctx *gin.Context) {
+	var req passkeyLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey payload"})
+		return
+	}
+
+	subjects := throttleSubjects(ctx, loginAccountRule, loginIPRule, "")
+	if !h.checkThrottle(ctx, throttleScopeLogin, subjects) {
+		return
+	}
+
+	ceremony, challenge, err := h.consumeWebAuthnCeremony(req.Credential.Response.ClientDataJSON, models.WebAuthnCeremonyLogin)
+	if err != nil || ceremony.MFAChallengeID != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey sign-in expired, please try again"})
+		return
+	}
+
+	passkey, err := h.verifyPasskeyAssertion(ctx, &req.Credential, ceremony, challenge)
+	if err != nil {
+		if errors.Is(err, errPasskeyInvalid) {
+			h.recordThrottleFailure(ctx, throttleScopeLogin, subjects, nil)
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey could not be verified"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to verify passkey"})
+		return
+	}
+	user := &passkey.User
+
+	if user.Suspended() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return
+	}
+
+	if !user.Verified {
+		if err := h.sendVerificationCode(ctx.Request.Context(), user); err != nil {
+			log.Printf("auth: unable to send verification email to %s: %v", user.Email, err)
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
+			return
+		}
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error":             "Email not verified",
+			"verificationSent":  true,
+			"verificationValid": int(verificationCodeTTL.Minutes()),
+		})
+		return
+	}
+
+	h.resetThrottle(throttleScopeLogin, throttleSubjects(ctx, loginAccountRule, loginIPRule, user.Email)[0])
+
+	session, err := h.createSession(ctx, user, ceremony.RememberMe)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to create session"})
+		return
+	}
+	h.setSessionCookie(ctx, session)
+
+	ctx.JSON(http.StatusOK, h.authSuccess(user, session))
+}
+
+// verifyPasskeySecondFactor answers a pending two-factor challenge with a
+// passkey assertion obtained from HandlePasskeyLoginOptions.
+func (h *AuthHandler) verifyPasskeySecondFactor(ctx *gin.Context, mfaChallenge *models.MFAChallenge, resp *webauthn.AssertionResponse) error {
+	ceremony, challenge, err := h.consumeWebAuthnCeremony(resp.Response.ClientDataJSON, models.WebAuthnCeremonyLogin)
+	if err != nil || ceremony.MFAChallengeID == nil || *ceremony.MFAChallengeID != mfaChallenge.ID {
+		return errMFACodeInvalid
+	}
+	if _, err := h.verifyPasskeyAssertion(ctx, resp, ceremony, challenge); err != nil {
+		if errors.Is(err, errPasskeyInvalid) {
+			return errMFACodeInvalid
+		}
+		return err
+	}
+	return nil
+}
+
+// verifyPasskeyAssertion checks an assertion against the ceremony it answers
+// and advances the credential's signature counter.
+func (h *AuthHandler) verifyPasskeyAssertion(ctx *gin.Context, resp *webauthn.AssertionResponse, ceremony *models.WebAuthnChallenge, challenge string) (*models.Passkey, error) {
+	rawID, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(resp.RawID, "="))
+	if err != nil || len(rawID) == 0 {
+		return nil, errPasskeyInvalid
+	}
+
+	var passkey models.Passkey
+	if err := h.db.Preload("User").
+		Where("credential_id = ?", base64.RawURLEncoding.EncodeToString(rawID)).
+		First(&passkey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errPasskeyInvalid
+		}
+		return nil, err
+	}
+
+	if ceremony.UserID != nil && *ceremony.UserID != passkey.UserID {
+		return nil, errPasskeyInvalid
+	}
+	if resp.Response.UserHandle != "" {
+		handle, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(resp.Response.UserHandle, "="))
+		if err != nil || !bytes.Equal(handle, passkey.UserID[:]) {
+			return nil, errPasskeyInvalid
+		}
+	}
+
+	result, err := h.webauthn.VerifyAssertion(resp, challenge, passkey.PublicKey, passkey.SignCount)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrCloned) {
+			recordAudit(h.db, ctx, auditActionPasskeyCloneSign, &passkey.UserID, &passkey.UserID, map[string]any{
+				"passkeyId": passkey.ID.String(),
+			})
+		}
+		log.Printf("auth: passkey assertion rejected for %s: %v", passkey.User.Email, err)
+		return nil, errPasskeyInvalid
+	}
+
+	// The conditional update stops two concurrent assertions from both
+	// advancing from the same counter value.
+	now := time.Now().UTC()
+	update := h.db.Model(&models.Passkey{}).
+		Where("id = ? AND sign_count = ?", passkey.ID, passkey.SignCount).
+		Updates(map[string]interface{}{
+			"sign_count":   result.SignCount,
+			"backed_up":    result.BackedUp,
+			"last_used_at": now,
+		})
+	if update.Error != nil {
+		return nil, update.Error
+	}
+	if update.RowsAffected == 0 {
+		return nil, errPasskeyInvalid
+	}
+	passkey.SignCount = result.SignCount
+	passkey.LastUsedAt = &now
+
+	return &passkey, nil
+}
+
+func (h *AuthHandler) startWebAuthnCeremony(ceremony models.WebAuthnChallenge) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	_ = h.db.Where("expires_at < ?", now).Delete(&models.WebAuthnChallenge{}).Error
+
+	ceremony.ChallengeHash = hashToken(challenge)
+	ceremony.ExpiresAt = now.Add(webauthn.Timeout)
+	if err := h.db.Create(&ceremony).Error; err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeWebAuthnCeremony finds the pending ceremony for the challenge in
+// clientDataJSON and deletes it, so each challenge is answered at most once.
+func (h *AuthHandler) consumeWebAuthnCeremony(clientDataJSON, kind string) (*models.WebAuthnChallenge, string, error) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var ceremony models.WebAuthnChallenge
+	if err := h.db.Where("challenge_hash = ? AND ceremony = ?", hashToken(challenge), kind).First(&ceremony).Error; err != nil {
+		return nil, "", err
+	}
+
+	result := h.db.Where("id = ?", ceremony.ID).Delete(&models.WebAuthnChallenge{})
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().UTC().After(ceremony.ExpiresAt) {
+		return nil, "", errPasskeyInvalid
+	}
+	return &ceremony, challenge, nil
+}
+
+func (h *AuthHandler) loadOwnPasskey(ctx *gin.Context) (*models.Passkey, bool) {
+	user := currentUser(ctx)
+
+	passkeyID, err := uuid.Parse(ctx.Param("passkeyId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey id"})
+		return nil, false
+	}
+
+	var passkey models.Passkey
+	if err := h.db.Where("id = ? AND user_id = ?", passkeyID, user.ID).First(&passkey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch passkey"})
+		return nil, false
+	}
+	return &passkey, true
+}
+
+// hasPasskeys reports whether the user has registered any passkey, which
+// counts as an enrolled second factor.
+func (h *AuthHandler) hasPasskeys(userID uuid.UUID) bool {
+	count, err := h.countPasskeys(userID)
+	if err != nil {
+		log.Printf("auth: unable to count passkeys: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+func (h *AuthHandler) countPasskeys(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := h.db.Model(&models.Passkey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// mfaEnrollmentRequired reports whether a pending login for user has to
+// enroll an authenticator before it can finish, which is only the case when
+// the user has no second factor at all. Errors must be treated as a refusal.
+func (h *AuthHandler) mfaEnrollmentRequired(user *models.User) (bool, error) {
+	if user.MFAEnabled {
+		return false, nil
+	}
+	count, err := h.countPasskeys(user.ID)
+	return count == 0, err
+}
+
+func passkeyDescriptors(passkeys []models.Passkey) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         passkey.CredentialID,
+			Transports: strings.Fields(passkey.Transports),
+		})
+	}
+	return descriptors
+}
+
+func toPasskeyResponse(passkey *models.Passkey) passkeyResponse {
+	return passkeyResponse{
+		ID:         passkey.ID,
+		Name:       passkey.Name,
+		BackedUp:   passkey.BackedUp,
+		LastUsedAt: passkey.LastUsedAt,
+		CreatedAt:  passkey.CreatedAt,
+	}
+}
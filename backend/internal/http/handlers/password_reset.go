@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+var errResetTokenInvalid = errors.New("reset token invalid")
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func (h *AuthHandler) HandleForgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password reset request"})
+		return
+	}
+
+	// Always answer the same way so the endpoint cannot be used to discover
+	// which addresses have accounts.
+	accepted := gin.H{"status": "If an account exists for that email, a reset link has been sent"}
+
+	email := strings.ToLower(req.Email)
+	var user models.User
+	if err := h.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("auth: password reset lookup failed: %v", err)
+		}
+		ctx.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	if err := h.sendPasswordReset(ctx.Request.Context(), &user, truncateString(ctx.ClientIP(), 64)); err != nil {
+		log.Printf("auth: unable to send password reset to %s: %v", user.Email, err)
+	}
+
+	ctx.JSON(http.StatusAccepted, accepted)
+}
+
+func (h *AuthHandler) HandleResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password reset payload"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to reset password"})
+		return
+	}
+
+	tokenHash := hashToken(strings.TrimSpace(req.Token))
+	now := time.Now().UTC()
+
+	var user models.User
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var token models.PasswordResetToken
+		if err := tx.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errResetTokenInvalid
+			}
+			return err
+		}
+		if token.UsedAt != nil || now.After(token.ExpiresAt) {
+			return errResetTokenInvalid
+		}
+
+		// Consume the token with a conditional update so two concurrent
+		// requests cannot both redeem it.
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenInvalid
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+
+		// Following the emailed link proves ownership of the address.
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash": string(hash),
+			"verified":      true,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", user.ID).Delete(&models.Session{}).Error
+	})
+	if err != nil {
+		if errors.Is(err, errResetTokenInvalid) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to reset password"})
+		return
+	}
+
+	h.clearSessionCookie(ctx)
+	ctx.JSON(http.StatusOK, gin.H{"user": toUserResponse(&user)})
+}
+
+// sendPasswordReset invalidates any outstanding reset tokens for the user,
+// stores the hash of a fresh one and emails the raw token as a link.
+func (h *AuthHandler) sendPasswordReset(ctx context.Context, user *models.User, requestedIP string) error {
+	token, err := generateSessionToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		// Prune redeemed tokens once they are well past expiry.
+		if err := tx.Where("expires_at < ?", now.Add(-24*time.Hour)).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:      user.ID,
+			TokenHash:   hashToken(token),
+			RequestedIP: requestedIP,
+			ExpiresAt:   now.Add(passwordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplatePasswordReset, user.Email, mailer.PasswordResetData{
+		CompanyName:  user.CompanyName,
+		ResetURL:     h.appURL("/reset-password", url.Values{"token": {token}}),
+		ValidMinutes: int(passwordResetTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, msg)
+}
+
+// hashToken returns the hex SHA-256 digest used to store high-entropy bearer
+// secrets without keeping the secret itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag is the entity tag of a case or document at version.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// requireIfMatch reads the If-Match header that mutations of versioned
+// resources must send, answering 428 when it is missing.
+func requireIfMatch(ctx *gin.Context) (string, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		ctx.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the current ETag is required"})
+		return "", false
+	}
+	return header, true
+}
+
+// ifMatchVersion reports whether an If-Match header matches version. "*"
+// matches any version; weak tags never match, as If-Match compares strongly.
+func ifMatchVersion(header string, version int64) bool {
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
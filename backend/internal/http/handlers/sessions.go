@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+)
+
+const (
+	auditActionSessionRevoke       = "session.revoke"
+	auditActionSessionRevokeOthers = "session.revoke_others"
+	auditActionSessionRevokeAll    = "session.revoke_all"
+)
+
+type sessionResponse struct {
+	ID           uuid.UUID `json:"id"`
+	UserAgent    string    `json:"userAgent"`
+	IP           string    `json:"ip"`
+	Current      bool      `json:"current"`
+	RememberMe   bool      `json:"rememberMe"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastActivity time.Time `json:"lastActivity"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+func (h *AuthHandler) HandleListSessions(ctx *gin.Context) {
+	current, user := currentSession(ctx), currentUser(ctx)
+
+	var sessions []models.Session
+	if err := h.db.Where("user_id = ? AND expires_at > ?", user.ID, time.Now().UTC()).
+		Order("last_activity DESC").
+		Find(&sessions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch sessions"})
+		return
+	}
+
+	payload := make([]sessionResponse, 0, len(sessions))
+	for i := range sessions {
+		payload = append(payload, toSessionResponse(&sessions[i], current.ID))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": payload})
+}
+
+func (h *AuthHandler) HandleGetSession(ctx *gin.Context) {
+	current, user := currentSession(ctx), currentUser(ctx)
+
+	session, ok := h.loadOwnSession(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"session": toSessionResponse(session, current.ID)})
+}
+
+func (h *AuthHandler) HandleRevokeSession(ctx *gin.Context) {
+	current, user := currentSession(ctx), currentUser(ctx)
+
+	session, ok := h.loadOwnSession(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(session).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke session"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionSessionRevoke, &user.ID, &user.ID, map[string]any{
+		"sessionId": session.ID.String(),
+	})
+
+	if session.ID == current.ID {
+		h.clearSessionCookie(ctx)
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) HandleRevokeOtherSessions(ctx *gin.Context) {
+	current, user := currentSession(ctx), currentUser(ctx)
+
+	result := h.db.Where("user_id = ? AND id <> ?", user.ID, current.ID).Delete(&models.Session{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke sessions"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionSessionRevokeOthers, &user.ID, &user.ID, map[string]any{
+		"revoked": result.RowsAffected,
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"revoked": result.RowsAffected})
+}
+
+func (h *AuthHandler) HandleAdminRevokeSessions(ctx *gin.Context) {
+	admin := currentUser(ctx)
+
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return
+	}
+
+	var target models.User
+	if err := h.db.First(&target, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch user"})
+		return
+	}
+
+	result := h.db.Where("user_id = ?", target.ID).Delete(&models.Session{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to revoke sessions"})
+		return
+	}
+
+	recordAudit(h.db, ctx, auditActionSessionRevokeAll, &admin.ID, &target.ID, map[string]any{
+		"revoked": result.RowsAffected,
+	})
+
+	if target.ID == admin.ID {
+		h.clearSessionCookie(ctx)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"revoked": result.RowsAffected})
+}
+
+func (h *AuthHandler) loadOwnSession(ctx *gin.Context, userID uuid.UUID) (*models.Session, bool) {
+	sessionID, err := uuid.Parse(ctx.Param("sessionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+		return nil, false
+	}
+
+	var session models.Session
+	if err := h.db.Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to fetch session"})
+		return nil, false
+	}
+
+	return &session, true
+}
+
+func toSessionResponse(session *models.Session, currentID uuid.UUID) sessionResponse {
+	return sessionResponse{
+		ID:           session.ID,
+		UserAgent:    session.UserAgent,
+		IP:           session.IP,
+		Current:      session.ID == currentID,
+		RememberMe:   session.RememberMe,
+		CreatedAt:    session.CreatedAt,
+		LastActivity: session.LastActivity,
+		ExpiresAt:    session.ExpiresAt,
+	}
+}
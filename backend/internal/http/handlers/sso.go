@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lexiflow/backend/internal/models"
+	"lexiflow/backend/internal/oidc"
+)
+
+const (
+	ssoLoginTTL             = 10 * time.Minute
+	ssoStateCookieName      = "lexiflow_sso_state"
+	ssoStateCookiePath      = "/api/v1/auth/sso"
+	ssoVerifierPurpose      = "sso-code-verifier"
+	ssoClientSecretPurpose  = "sso-client-secret"
+	ssoDefaultRedirect      = "/dashboard"
+	auditActionSSOLogin     = "sso.login"
+	auditActionSSOLink      = "sso.link"
+	auditActionSSOProvision = "sso.provision"
+)
+
+// ssoError is reported back to the app as the ssoError query parameter when a
+// callback cannot be completed.
+type ssoError string
+
+const (
+	ssoErrorIdP          ssoError = "idp_error"
+	ssoErrorState        ssoError = "invalid_state"
+	ssoErrorToken        ssoError = "invalid_token"
+	ssoErrorEmail        ssoError = "email_not_allowed"
+	ssoErrorNotMember    ssoError = "not_a_member"
+	ssoErrorRoleUnmapped ssoError = "role_not_mapped"
+	ssoErrorSuspended    ssoError = "account_suspended"
+	ssoErrorServer       ssoError = "server_error"
+)
+
+func (e ssoError) Error() string { return string(e) }
+
+type ssoDiscoverRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// HandleSSODiscover tells the login page whether an email address belongs to
+// an organization that signs in through its own identity provider. Only
+// verified domain claims count.
+func (h *AuthHandler) HandleSSODiscover(ctx *gin.Context) {
+	var req ssoDiscoverRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SSO lookup"})
+		return
+	}
+
+	email := strings.ToLower(req.Email)
+
+	var provider models.IdentityProvider
+	err := h.db.Joins("JOIN identity_provider_domains d ON d.provider_id = identity_providers.id").
+		Where("identity_providers.enabled = ? AND d.domain = ? AND d.verified_at IS NOT NULL", true, emailDomain(email)).
+		First(&provider).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "No single sign-on provider for this email domain"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to look up single sign-on"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"provider": provider.Slug,
+		"name":     provider.Name,
+		"loginUrl": h.ssoURL(&provider, "login") + "?" + url.Values{"loginHint": {email}}.Encode(),
+	})
+}
+
+// HandleSSOLogin starts an authorization code flow with PKCE by redirecting
+// the browser to the organization's identity provider.
+func (h *AuthHandler) HandleSSOLogin(ctx *gin.Context) {
+	var provider models.IdentityProvider
+	if err := h.db.Where("slug = ? AND enabled = ?", ctx.Param("provider"), true).First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on provider not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start single sign-on"})
+		return
+	}
+
+	meta, err := h.oidc.Discover(ctx.Request.Context(), provider.Issuer)
+	if err != nil {
+		log.Printf("sso: discovery for %s failed: %v", provider.Slug, err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	state, errState := oidc.RandomString(32)
+	nonce, errNonce := oidc.RandomString(32)
+	verifier, challenge, errPKCE := oidc.NewPKCE()
+	if errState != nil || errNonce != nil || errPKCE != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start single sign-on"})
+		return
+	}
+	sealedVerifier, err := h.keyring.Seal(ssoVerifierPurpose, []byte(verifier))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start single sign-on"})
+		return
+	}
+
+	now := time.Now().UTC()
+	_ = h.db.Where("expires_at < ?", now).Delete(&models.SSOLoginState{}).Error
+
+	remember, _ := strconv.ParseBool(ctx.Query("rememberMe"))
+	loginState := models.SSOLoginState{
+		ProviderID:            provider.ID,
+		StateHash:             hashToken(state),
+		Nonce:                 nonce,
+		CodeVerifierEncrypted: sealedVerifier,
+		RedirectPath:          safeRedirectPath(ctx.Query("redirect")),
+		RememberMe:            remember,
+		ExpiresAt:             now.Add(ssoLoginTTL),
+	}
+	if err := h.db.Create(&loginState).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to start single sign-on"})
+		return
+	}
+
+	// Binding the state to this browser stops an attacker from completing
+	// their own login in a victim's browser. Lax is required because the
+	// callback arrives as a cross-site top-level navigation from the IdP.
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(ssoStateCookieName, state, int(ssoLoginTTL.Seconds()), ssoStateCookiePath,
+		h.sessions.CookieDomain, h.sessions.CookieSecure, true)
+
+	ctx.Redirect(http.StatusFound, meta.AuthCodeURL(oidc.AuthRequest{
+		ClientID:      provider.ClientID,
+		RedirectURI:   h.ssoURL(&provider, "callback"),
+		Scopes:        provider.ScopeList(),
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: challenge,
+		LoginHint:     ctx.Query("loginHint"),
+	}))
+}
+
+// HandleSSOCallback completes the flow: it redeems the code, verifies the ID
+// token, finds, links or provisions the account and signs it in. Because the
+// browser arrives here by redirect, every outcome is a redirect back to the
+// app rather than a JSON body.
+func (h *AuthHandler) HandleSSOCallback(ctx *gin.Context) {
+	state := ctx.Query("state")
+	cookieState, _ := ctx.Cookie(ssoStateCookieName)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(ssoStateCookieName, "", -1, ssoStateCookiePath, h.sessions.CookieDomain, h.sessions.CookieSecure, true)
+
+	if state == "" || cookieState != state {
+		h.redirectSSOError(ctx, ssoErrorState)
+		return
+	}
+
+	loginState, err := h.consumeSSOState(state, ctx.Param("provider"))
+	if err != nil {
+		h.redirectSSOError(ctx, ssoErrorState)
+		return
+	}
+	provider := &loginState.Provider
+
+	if idpError := ctx.Query("error"); idpError != "" {
+		log.Printf("sso: %s returned error %q: %s", provider.Slug, idpError, ctx.Query("error_description"))
+		h.redirectSSOError(ctx, ssoErrorIdP)
+		return
+	}
+
+	claims, err := h.exchangeSSOCode(ctx, provider, loginState, ctx.Query("code"))
+	if err != nil {
+		log.Printf("sso: %s token validation failed: %v", provider.Slug, err)
+		h.redirectSSOError(ctx, ssoErrorToken)
+		return
+	}
+
+	user, outcome, err := h.resolveSSOUser(provider, claims)
+	if err != nil {
+		var reason ssoError
+		if !errors.As(err, &reason) {
+			log.Printf("sso: %s account resolution failed: %v", provider.Slug, err)
+			reason = ssoErrorServer
+		}
+		h.redirectSSOError(ctx, reason)
+		return
+	}
+
+	if user.Suspended() {
+		h.redirectSSOError(ctx, ssoErrorSuspended)
+		return
+	}
+
+	switch outcome {
+	case auditActionSSOLink, auditActionSSOProvision:
+		recordAudit(h.db, ctx, outcome, &user.ID, &user.ID, map[string]any{"provider": provider.Slug})
+	}
+	recordAudit(h.db, ctx, auditActionSSOLogin, &user.ID, &user.ID, map[string]any{"provider": provider.Slug})
+
+	// The IdP replaces the password, not the account's own second factor.
+	if user.MFAEnabled || h.mfaRequired(user) {
+		token, _, err := h.startMFAChallenge(ctx, user, loginState.RememberMe)
+		if err != nil {
+			h.redirectSSOError(ctx, ssoErrorServer)
+			return
+		}
+		// As with password logins, only users with no second factor at all
+		// may enroll one now; a passkey holder has to present the passkey.
+		enrollmentRequired, err := h.mfaEnrollmentRequired(user)
+		if err != nil {
+			h.redirectSSOError(ctx, ssoErrorServer)
+			return
+		}
+		query := url.Values{"mfaChallenge": {token}, "redirect": {loginState.RedirectPath}}
+		if enrollmentRequired {
+			query.Set("enrollmentRequired", "true")
+		}
+		ctx.Redirect(http.StatusFound, h.appURL("/login", query))
+		return
+	}
+
+	session, err := h.createSession(ctx, user, loginState.RememberMe)
+	if err != nil {
+		h.redirectSSOError(ctx, ssoErrorServer)
+		return
+	}
+	h.setSessionCookie(ctx, session)
+
+	ctx.Redirect(http.StatusFound, h.appURL(loginState.RedirectPath, nil))
+}
+
+// consumeSSOState loads and deletes the pending login for state so that each
+// authorization response can be used once.
+func (h *AuthHandler) consumeSSOState(state, slug string) (*models.SSOLoginState, error) {
+	var loginState models.SSOLoginState
+	if err := h.db.Preload("Provider").Where("state_hash = ?", hashToken(state)).First(&loginState).Error; err != nil {
+		return nil, err
+	}
+
+	result := h.db.Where("id = ?", loginState.ID).Delete(&models.SSOLoginState{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ssoErrorState
+	}
+
+	if time.Now().UTC().After(loginState.ExpiresAt) || loginState.Provider.Slug != slug || !loginState.Provider.Enabled {
+		return nil, ssoErrorState
+	}
+	return &loginState, nil
+}
+
+func (h *AuthHandler) exchangeSSOCode(ctx *gin.Context, provider *models.IdentityProvider, loginState *models.SSOLoginState, code string) (*oidc.Claims, error) {
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+
+	verifier, err := h.keyring.Open(ssoVerifierPurpose, loginState.CodeVerifierEncrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	clientSecret := ""
+	if provider.ClientSecretEncrypted != "" {
+		secret, err := h.keyring.Open(ssoClientSecretPurpose, provider.ClientSecretEncrypted)
+		if err != nil {
+			return nil, err
+		}
+		clientSecret = string(secret)
+	}
+
+	rawToken, err := h.oidc.Exchange(ctx.Request.Context(), provider.Issuer, provider.ClientID, clientSecret,
+		h.ssoURL(provider, "callback"), code, string(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	return h.oidc.Verify(ctx.Request.Context(), provider.Issuer, provider.ClientID, rawToken, loginState.Nonce)
+}
+
+// resolveSSOUser maps a verified ID token to a member of the provider's
+// organization. A known (provider, subject) pair signs in its linked user
+// while they remain a member and their address is in one of the provider's
+// verified domains. Otherwise the IdP must assert a verified address in one
+// of those domains: an existing account with that
+// address is linked if it is a member, and a new one is provisioned with a
+// role mapped from the configured claim and joins the organization. Accounts
+// outside the organization are never linked, so a provider cannot take over
+// accounts of other organizations, and roles of existing accounts are never
+// changed.
+func (h *AuthHandler) resolveSSOUser(provider *models.IdentityProvider, claims *oidc.Claims) (*models.User, string, error) {
+	var user models.User
+	outcome := auditActionSSOLogin
+	now := time.Now().UTC()
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Preload("User").Where("provider_id = ? AND subject = ?", provider.ID, claims.Subject).First(&identity).Error
+		if err == nil {
+			user = identity.User
+			if err := requireOrganizationMember(tx, provider.OrganizationID, user.ID); err != nil {
+				return err
+			}
+			if err := requireVerifiedDomain(tx, provider.ID, user.Email); err != nil {
+				return err
+			}
+			return tx.Model(&identity).Updates(map[string]interface{}{
+				"email":         truncateString(strings.ToLower(claims.Email), 255),
+				"last_login_at": now,
+			}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		email := strings.ToLower(strings.TrimSpace(claims.Email))
+		if email == "" || !claims.EmailVerified {
+			return ssoErrorEmail
+		}
+		if err := requireVerifiedDomain(tx, provider.ID, email); err != nil {
+			return err
+		}
+
+		err = tx.Where("email = ?", email).First(&user).Error
+		switch {
+		case err == nil:
+			if err := requireOrganizationMember(tx, provider.OrganizationID, user.ID); err != nil {
+				return err
+			}
+			outcome = auditActionSSOLink
+			if !user.Verified {
+				if err := tx.Model(&user).Update("verified", true).Error; err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			role := mapSSORole(provider, claims)
+			if role == "" {
+				return ssoErrorRoleUnmapped
+			}
+			outcome = auditActionSSOProvision
+			user = models.User{
+				CompanyName: provider.Name,
+				Email:       email,
+				// No password: the account signs in through the IdP until the
+				// user sets one with the password reset flow.
+				PasswordHash: "",
+				Subscription: models.PlanStarter,
+				Verified:     true,
+				Role:         role,
+			}
+			// Lawyers provisioned through an IdP are vetted like
+			// self-registered ones.
+			if role == models.UserRoleLawyer {
+				user.ApprovalStatus = models.ApprovalPending
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.OrganizationMembership{
+				OrganizationID: provider.OrganizationID,
+				UserID:         user.ID,
+				Role:           models.OrgRoleMember,
+			}).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:      user.ID,
+			ProviderID:  provider.ID,
+			Subject:     claims.Subject,
+			Email:       email,
+			LastLoginAt: now,
+		}).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, outcome, nil
+}
+
+// requireOrganizationMember fails with ssoErrorNotMember unless userID
+// belongs to orgID.
+func requireOrganizationMember(tx *gorm.DB, orgID, userID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.OrganizationMembership{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ssoErrorNotMember
+	}
+	return nil
+}
+
+// requireVerifiedDomain fails with ssoErrorEmail unless providerID holds a
+// verified claim on the domain of email.
+func requireVerifiedDomain(tx *gorm.DB, providerID uuid.UUID, email string) error {
+	var count int64
+	if err := tx.Model(&models.IdentityProviderDomain{}).
+		Where("provider_id = ? AND domain = ? AND verified_at IS NOT NULL", providerID, emailDomain(email)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ssoErrorEmail
+	}
+	return nil
+}
+
+// emailDomain returns the lowercased domain part of email.
+func emailDomain(email string) string {
+	return strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+}
+
+// mapSSORole returns the role for a provisioned account: the first claim
+// value with a mapping, else the provider's default. Administrator access is
+// never granted through SSO.
+func mapSSORole(provider *models.IdentityProvider, claims *oidc.Claims) string {
+	if provider.RoleClaim != "" {
+		for _, value := range claims.Strings(provider.RoleClaim) {
+			if role, ok := provider.RoleMapping[value].(string); ok && isSSOAssignableRole(role) {
+				return role
+			}
+		}
+	}
+	if isSSOAssignableRole(provider.DefaultRole) {
+		return provider.DefaultRole
+	}
+	return ""
+}
+
+func isSSOAssignableRole(role string) bool {
+	return role == models.UserRoleClient || role == models.UserRoleLawyer
+}
+
+func (h *AuthHandler) ssoURL(provider *models.IdentityProvider, action string) string {
+	return h.apiBaseURL + ssoStateCookiePath + "/" + url.PathEscape(provider.Slug) + "/" + action
+}
+
+func (h *AuthHandler) redirectSSOError(ctx *gin.Context, reason ssoError) {
+	ctx.Redirect(http.StatusFound, h.appURL("/login", url.Values{"ssoError": {string(reason)}}))
+}
+
+// safeRedirectPath only accepts app-relative paths so the login cannot be
+// turned into an open redirect.
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n") {
+		return ssoDefaultRedirect
+	}
+	return truncateString(path, 512)
+}
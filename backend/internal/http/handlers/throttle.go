@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lexiflow/backend/internal/throttle"
+)
+
+const (
+	throttleScopeLogin       = "login"
+	throttleScopeVerifyEmail = "verify-email"
+	// Every resend counts, successful or not: it sends email.
+	throttleScopeResendVerification = "resend-verification"
+)
+
+var (
+	loginAccountRule = throttle.Rule{
+		Kind:         "account",
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   15 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	loginIPRule = throttle.Rule{
+		Kind:         "ip",
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		LockoutAfter: 100,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+	// Six-digit codes are small enough to enumerate, so verification locks
+	// out much sooner than password login.
+	verifyAccountRule = throttle.Rule{
+		Kind:         "account",
+		FreeAttempts: 2,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 5,
+		LockoutFor:   30 * time.Minute,
+		ResetAfter:   time.Hour,
+	}
+	verifyIPRule = throttle.Rule{
+		Kind:         "ip",
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		LockoutAfter: 50,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+	resendAccountRule = throttle.Rule{
+		Kind:         "account",
+		FreeAttempts: 2,
+		BaseDelay:    30 * time.Second,
+		MaxDelay:     10 * time.Minute,
+		LockoutAfter: 10,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+	resendIPRule = throttle.Rule{
+		Kind:         "ip",
+		FreeAttempts: 10,
+		BaseDelay:    5 * time.Second,
+		MaxDelay:     10 * time.Minute,
+		LockoutAfter: 50,
+		LockoutFor:   time.Hour,
+		ResetAfter:   time.Hour,
+	}
+)
+
+func throttleSubjects(ctx *gin.Context, accountRule, ipRule throttle.Rule, accountKey string) []throttle.Subject {
+	return []throttle.Subject{
+		{Rule: accountRule, Key: accountKey},
+		{Rule: ipRule, Key: ctx.ClientIP()},
+	}
+}
+
+// checkThrottle answers 429 and returns false when any subject is still
+// backing off.
+func (h *AuthHandler) checkThrottle(ctx *gin.Context, scope string, subjects []throttle.Subject) bool {
+	wait, err := h.limiter.Check(scope, subjects...)
+	if err != nil {
+		log.Printf("auth: throttle check failed: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to process request"})
+		return false
+	}
+	if wait > 0 {
+		respondTooManyAttempts(ctx, wait)
+		return false
+	}
+	return true
+}
+
+// recordThrottleFailure counts a failed attempt and audits any lockout it
+// triggers. The subject user is attached to the audit record when known.
+func (h *AuthHandler) recordThrottleFailure(ctx *gin.Context, scope string, subjects []throttle.Subject, userID *uuid.UUID) throttle.Result {
+	result, err := h.limiter.Fail(scope, subjects...)
+	if err != nil {
+		log.Printf("auth: unable to record failed attempt: %v", err)
+		return result
+	}
+	for _, locked := range result.LockedOut {
+		recordAudit(h.db, ctx, auditActionLockout, nil, userID, map[string]any{
+			"scope":       scope,
+			"kind":        locked.Rule.Kind,
+			"key":         locked.Key,
+			"lockedUntil": time.Now().UTC().Add(locked.Rule.LockoutFor).Format(time.RFC3339),
+		})
+	}
+	return result
+}
+
+func (h *AuthHandler) resetThrottle(scope string, subjects ...throttle.Subject) {
+	if err := h.limiter.Reset(scope, subjects...); err != nil {
+		log.Printf("auth: unable to reset throttle: %v", err)
+	}
+}
+
+func respondTooManyAttempts(ctx *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	ctx.Header("Retry-After", fmt.Sprint(seconds))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "Too many attempts, please try again later",
+		"retryAfter": seconds,
+	})
+}
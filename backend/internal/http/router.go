@@ -8,15 +8,18 @@ import (
 	"gorm.io/gorm"
 	"lexiflow/backend/internal/config"
 	"lexiflow/backend/internal/http/handlers"
+	"lexiflow/backend/internal/mailer"
+	"lexiflow/backend/internal/models"
 )
 
-func NewServer(cfg config.Config, db *gorm.DB) *gin.Engine {
+func NewServer(cfg config.Config, db *gorm.DB, mail mailer.Mailer) *gin.Engine {
 	r := gin.Default()
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = cfg.CorsOrigins
 	corsConfig.AllowCredentials = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token", "If-Match"}
+	corsConfig.ExposeHeaders = []string{"ETag"}
 	r.Use(cors.New(corsConfig))
 
 	r.GET("/healthz", func(ctx *gin.Context) {
@@ -24,10 +27,172 @@ func NewServer(cfg config.Config, db *gorm.DB) *gin.Engine {
 	})
 
 	api := r.Group("/api/v1")
-	authHandler := handlers.NewAuthHandler(db)
-	authHandler.RegisterRoutes(api)
-	caseHandler := handlers.NewCaseHandler(db, authHandler, cfg.UploadDir)
-	caseHandler.RegisterRoutes(api)
+	authHandler := handlers.NewAuthHandler(db, cfg, mail)
+	caseHandler := handlers.NewCaseHandler(db, cfg, mail)
+
+	// Public: no session required.
+	public := api.Group("/auth")
+	{
+		public.POST("/register", authHandler.HandleRegister)
+		public.POST("/login", authHandler.HandleLogin)
+		public.POST("/login/mfa", authHandler.HandleCompleteMFALogin)
+		public.POST("/login/mfa/setup", authHandler.HandleChallengeTOTPSetup)
+		public.POST("/verify-email", authHandler.HandleVerifyEmail)
+		public.POST("/verify-email/resend", authHandler.HandleResendVerification)
+		public.POST("/password/forgot", authHandler.HandleForgotPassword)
+		public.POST("/password/reset", authHandler.HandleResetPassword)
+		public.POST("/logout", authHandler.HandleLogout)
+
+		public.POST("/passkeys/login/options", authHandler.HandlePasskeyLoginOptions)
+		public.POST("/passkeys/login", authHandler.HandlePasskeyLogin)
+
+		public.POST("/sso/discover", authHandler.HandleSSODiscover)
+		public.GET("/sso/:provider/login", authHandler.HandleSSOLogin)
+		public.GET("/sso/:provider/callback", authHandler.HandleSSOCallback)
+	}
+
+	// Authenticated: any signed-in account managing itself.
+	authenticated := api.Group("", authHandler.Authenticate())
+	account := authenticated.Group("/auth")
+	{
+		account.GET("/me", authHandler.HandleCurrentUser)
+		account.POST("/subscription", authHandler.HandleUpdateSubscription)
+
+		account.GET("/mfa", authHandler.HandleMFAStatus)
+		account.POST("/mfa/totp/setup", authHandler.HandleTOTPSetup)
+		account.POST("/mfa/totp/confirm", authHandler.HandleTOTPConfirm)
+		account.POST("/mfa/disable", authHandler.HandleDisableMFA)
+		account.POST("/mfa/recovery-codes", authHandler.HandleRegenerateRecoveryCodes)
+
+		account.GET("/sessions", authHandler.HandleListSessions)
+		account.GET("/sessions/:sessionId", authHandler.HandleGetSession)
+		account.DELETE("/sessions/:sessionId", authHandler.HandleRevokeSession)
+		account.POST("/sessions/revoke-others", authHandler.HandleRevokeOtherSessions)
+
+		account.GET("/passkeys", authHandler.HandleListPasskeys)
+		account.POST("/passkeys/registration/options", authHandler.HandlePasskeyRegistrationOptions)
+		account.POST("/passkeys", authHandler.HandleRegisterPasskey)
+		account.PATCH("/passkeys/:passkeyId", authHandler.HandleRenamePasskey)
+		account.DELETE("/passkeys/:passkeyId", authHandler.HandleDeletePasskey)
+
+		account.GET("/api-keys", authHandler.HandleListAPIKeys)
+		account.POST("/api-keys", authHandler.HandleCreateAPIKey)
+		account.DELETE("/api-keys/:keyId", authHandler.HandleRevokeAPIKey)
+	}
+
+	// Client workspaces: verified clients managing the organizations they
+	// belong to. Membership roles are enforced by the handlers.
+	organizations := authenticated.Group("/organizations", handlers.RequireVerified(), handlers.RequireRole(models.UserRoleClient))
+	{
+		organizations.GET("", authHandler.HandleListOrganizations)
+		organizations.POST("", authHandler.HandleCreateOrganization)
+		organizations.POST("/invitations/accept", authHandler.HandleAcceptInvitation)
+		organizations.GET("/:orgId", authHandler.HandleGetOrganization)
+		organizations.PATCH("/:orgId", authHandler.HandleUpdateOrganization)
+		organizations.PATCH("/:orgId/members/:userId", authHandler.HandleUpdateMember)
+		organizations.DELETE("/:orgId/members/:userId", authHandler.HandleRemoveMember)
+		organizations.GET("/:orgId/invitations", authHandler.HandleListInvitations)
+		organizations.POST("/:orgId/invitations", authHandler.HandleInviteMember)
+		organizations.DELETE("/:orgId/invitations/:invitationId", authHandler.HandleRevokeInvitation)
+		organizations.GET("/:orgId/identity-providers", authHandler.HandleListIdentityProviders)
+		organizations.POST("/:orgId/identity-providers", authHandler.HandleCreateIdentityProvider)
+		organizations.PUT("/:orgId/identity-providers/:providerId", authHandler.HandleUpdateIdentityProvider)
+		organizations.DELETE("/:orgId/identity-providers/:providerId", authHandler.HandleDeleteIdentityProvider)
+		organizations.POST("/:orgId/identity-providers/:providerId/domains/:domain/verify", authHandler.HandleVerifyIdentityProviderDomain)
+	}
+
+	// Law firms: verified lawyers managing their firm; the directory is open
+	// to clients choosing counsel.
+	firms := authenticated.Group("/firms", handlers.RequireVerified(), handlers.RequireRole(models.UserRoleClient, models.UserRoleLawyer))
+	{
+		firms.GET("", authHandler.HandleListFirms)
+	}
+	lawyerFirms := firms.Group("", handlers.RequireRole(models.UserRoleLawyer))
+	{
+		lawyerFirms.POST("", authHandler.HandleCreateFirm)
+		lawyerFirms.POST("/invitations/accept", authHandler.HandleAcceptFirmInvitation)
+		lawyerFirms.GET("/:firmId", authHandler.HandleGetFirm)
+		lawyerFirms.GET("/:firmId/invitations", authHandler.HandleListFirmInvitations)
+		lawyerFirms.POST("/:firmId/invitations", authHandler.HandleInviteFirmMember)
+		lawyerFirms.DELETE("/:firmId/invitations/:invitationId", authHandler.HandleRevokeFirmInvitation)
+		lawyerFirms.PATCH("/:firmId/members/:userId", authHandler.HandleUpdateFirmMember)
+		lawyerFirms.DELETE("/:firmId/members/:userId", authHandler.HandleRemoveFirmMember)
+	}
+
+	// Lawyer directory: clients choosing counsel browse it, and lawyers keep
+	// their own profile up to date.
+	directory := authenticated.Group("/lawyers", handlers.RequireVerified(), handlers.RequireRole(models.UserRoleClient, models.UserRoleLawyer))
+	{
+		directory.GET("", authHandler.HandleListLawyers)
+		directory.GET("/me", handlers.RequireRole(models.UserRoleLawyer), authHandler.HandleGetOwnLawyerProfile)
+		directory.PUT("/me", handlers.RequireRole(models.UserRoleLawyer), authHandler.HandleUpdateOwnLawyerProfile)
+		directory.GET("/:lawyerId", authHandler.HandleGetLawyer)
+	}
+
+	// Platform administrators.
+	admin := authenticated.Group("/admin", handlers.RequireRole(models.UserRoleAdmin))
+	{
+		admin.GET("/stats", authHandler.HandleAdminStats)
+		admin.GET("/users", authHandler.HandleAdminListUsers)
+		admin.GET("/users/:userId", authHandler.HandleAdminGetUser)
+		admin.POST("/users/:userId/approve", authHandler.HandleAdminApproveLawyer)
+		admin.POST("/users/:userId/reject", authHandler.HandleAdminRejectLawyer)
+		admin.POST("/users/:userId/suspend", authHandler.HandleAdminSuspendUser)
+		admin.POST("/users/:userId/reactivate", authHandler.HandleAdminReactivateUser)
+		admin.POST("/users/:userId/verify", authHandler.HandleAdminVerifyUser)
+		admin.DELETE("/users/:userId/sessions", authHandler.HandleAdminRevokeSessions)
+		admin.POST("/identity-providers/:providerId/domains/:domain/verify", authHandler.HandleAdminVerifyIdentityProviderDomain)
+
+	}
+
+	// Case participants: verified clients and lawyers, signed in or using an
+	// API key with the matching scope. What each may do on a given case is
+	// decided by the case policy.
+	cases := api.Group("/cases", authHandler.AuthenticateWithAPIKeys(), handlers.RequireVerified())
+	participants := cases.Group("", handlers.RequireRole(models.UserRoleClient, models.UserRoleLawyer))
+	{
+		participants.GET("", handlers.RequireScope(models.ScopeCasesRead), caseHandler.HandleListCases)
+		participants.GET("/search", handlers.RequireScope(models.ScopeCasesRead), caseHandler.HandleSearch)
+		participants.GET("/:id", handlers.RequireScope(models.ScopeCasesRead), caseHandler.HandleGetCase)
+		participants.PATCH("/:id", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleUpdateCase)
+		participants.POST("/:id/status", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleChangeCaseStatus)
+		participants.POST("/:id/documents", handlers.RequireScope(models.ScopeDocumentsWrite), caseHandler.HandleAttachDocument)
+		participants.POST("/:id/documents/upload", handlers.RequireScope(models.ScopeDocumentsWrite), caseHandler.HandleUploadDocument)
+		participants.PATCH("/:id/documents/:documentId", handlers.RequireScope(models.ScopeDocumentsWrite), caseHandler.HandleUpdateDocument)
+		participants.DELETE("/:id/documents/:documentId", handlers.RequireScope(models.ScopeDocumentsWrite), caseHandler.HandleDeleteDocument)
+		participants.GET("/:id/documents/:documentId/download", handlers.RequireScope(models.ScopeDocumentsRead), caseHandler.HandleDownloadDocument)
+		participants.DELETE("/:id/collaborators/:userId", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleRemoveCollaborator)
+	}
+
+	// Lawyers answering their assignments and firm admins staffing cases.
+	lawyers := cases.Group("", handlers.RequireRole(models.UserRoleLawyer))
+	{
+		lawyers.POST("/invitations/accept", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAcceptCaseInvitation)
+		lawyers.POST("/:id/assignment/accept", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAcceptAssignment)
+		lawyers.POST("/:id/assignment/decline", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleDeclineAssignment)
+		lawyers.POST("/:id/assignment/withdraw", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleWithdrawAssignment)
+		lawyers.POST("/:id/assignment/complete", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleCompleteAssignment)
+		lawyers.POST("/:id/firm-engagement/accept", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAcceptFirmEngagement)
+		lawyers.POST("/:id/firm-engagement/decline", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleDeclineFirmEngagement)
+		lawyers.POST("/:id/staff", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleStaffCase)
+		lawyers.DELETE("/:id/staff/:lawyerId", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleUnstaffCase)
+	}
+
+	// Case owners: verified clients.
+	clients := cases.Group("", handlers.RequireRole(models.UserRoleClient))
+	{
+		clients.POST("", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleCreateCase)
+		clients.DELETE("/:id", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleDeleteCase)
+		clients.POST("/:id/collaborators", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAddCollaborator)
+		clients.GET("/:id/invitations", handlers.RequireScope(models.ScopeCasesRead), caseHandler.HandleListCaseInvitations)
+		clients.DELETE("/:id/invitations/:invitationId", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleRevokeCaseInvitation)
+		clients.DELETE("/:id/assign/:lawyerId", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleRemoveAssignment)
+		clients.POST("/:id/lead-counsel", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleTransferLead)
+		clients.POST("/:id/assign", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAssignLawyer)
+		clients.POST("/:id/assign-firm", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleAssignFirm)
+		clients.POST("/:id/invitations", handlers.RequireScope(models.ScopeCasesWrite), caseHandler.HandleInviteLawyer)
+		clients.GET("/:id/recommended-lawyers", handlers.RequireScope(models.ScopeCasesRead), caseHandler.HandleRecommendLawyers)
+	}
 
 	return r
 }
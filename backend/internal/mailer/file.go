@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileMailer writes each message as an .eml file into a maildir-style
+// directory so local development can inspect outbound mail without a relay.
+// Files are written to tmp/ and renamed into new/ once complete.
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o775); err != nil {
+			return nil, fmt.Errorf("unable to create mail directory: %w", err)
+		}
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	body, err := buildMIME(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.%d_%d.lexiflow.eml", now.Unix(), now.UnixNano(), m.seq.Add(1))
+	tmpPath := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, body, 0o640); err != nil {
+		return fmt.Errorf("unable to write message: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(m.dir, "new", name)); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("unable to deliver message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// LogMailer records messages in memory and writes a one-line summary to the
+// standard logger. It is intended for tests and CI where no mail should leave
+// the process; Messages returns everything sent so far.
+type LogMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	m.messages = append(m.messages, msg)
+	m.mu.Unlock()
+
+	log.Printf("mail: to=%s subject=%q", msg.To, msg.Subject)
+	return nil
+}
+
+func (m *LogMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Message, len(m.messages))
+	copy(out, m.messages)
+	return out
+}
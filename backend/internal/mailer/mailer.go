@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"lexiflow/backend/internal/config"
+)
+
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// Message is a rendered email ready to hand to a transport.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the transport selected in the configuration.
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Transport {
+	case TransportSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be provided for the smtp mail transport")
+		}
+		return NewSMTPMailer(cfg), nil
+	case TransportFile, "":
+		return NewFileMailer(cfg.Dir, cfg.From)
+	case TransportLog:
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail transport %q", cfg.Transport)
+	}
+}
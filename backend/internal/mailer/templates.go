@@ -0,0 +1,142 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	TemplateVerification      = "verification"
+	TemplatePasswordReset     = "password_reset"
+	TemplateOrgInvitation     = "organization_invitation"
+	TemplateFirmInvitation    = "firm_invitation"
+	TemplateCaseInvitation    = "case_invitation"
+	TemplateAssignmentRemoved = "assignment_removed"
+	TemplateLeadCounsel       = "lead_counsel_changed"
+)
+
+// VerificationData feeds the verification template.
+type VerificationData struct {
+	CompanyName  string
+	Code         string
+	ValidMinutes int
+	VerifyURL    string
+}
+
+// PasswordResetData feeds the password reset template.
+type PasswordResetData struct {
+	CompanyName  string
+	ResetURL     string
+	ValidMinutes int
+}
+
+// OrgInvitationData feeds the organization invitation template.
+type OrgInvitationData struct {
+	OrganizationName string
+	InviterName      string
+	Role             string
+	AcceptURL        string
+	ValidDays        int
+}
+
+// FirmInvitationData feeds the firm invitation template.
+type FirmInvitationData struct {
+	FirmName    string
+	InviterName string
+	Role        string
+	AcceptURL   string
+	ValidDays   int
+}
+
+// CaseInvitationData feeds the case invitation template.
+type CaseInvitationData struct {
+	CaseName    string
+	ClientName  string
+	InviterName string
+	Role        string
+	AcceptURL   string
+	ValidDays   int
+}
+
+// AssignmentRemovedData feeds the template telling a lawyer they were taken
+// off a case.
+type AssignmentRemovedData struct {
+	CaseName   string
+	ClientName string
+	RemovedBy  string
+	Reason     string
+}
+
+// LeadCounselData feeds the template telling a lawyer that lead counsel on a
+// case changed hands. Promoted is set for the new lead; Role is the
+// recipient's role on the case after the change.
+type LeadCounselData struct {
+	CaseName   string
+	ClientName string
+	ChangedBy  string
+	NewLead    string
+	Role       string
+	Reason     string
+	Promoted   bool
+	CasesURL   string
+}
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = mustParseTemplates()
+
+// Render executes the named template pair and returns a message addressed to
+// the recipient. Text templates define "subject" and "body"; HTML templates
+// define "content", which is wrapped in the shared layout.
+func Render(name, to string, data any) (Message, error) {
+	set, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := set.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return Message{}, err
+	}
+	if err := set.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+func mustParseTemplates() map[string]templateSet {
+	entries, err := fs.Glob(templateFS, "templates/*.txt.tmpl")
+	if err != nil {
+		panic(err)
+	}
+
+	sets := make(map[string]templateSet, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(strings.TrimPrefix(entry, "templates/"), ".txt.tmpl")
+		sets[name] = templateSet{
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, entry)),
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html.tmpl", "templates/"+name+".html.tmpl")),
+		}
+	}
+	return sets
+}
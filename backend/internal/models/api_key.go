@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeCasesRead      = "cases:read"
+	ScopeCasesWrite     = "cases:write"
+	ScopeDocumentsRead  = "documents:read"
+	ScopeDocumentsWrite = "documents:write"
+)
+
+// APIKeyScopes lists every scope an API key may be granted.
+var APIKeyScopes = []string{ScopeCasesRead, ScopeCasesWrite, ScopeDocumentsRead, ScopeDocumentsWrite}
+
+// APIKey is a named personal access token for integrations. Only a keyed hash
+// of the secret is stored; Prefix keeps enough of it to recognise the key in
+// listings.
+type APIKey struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"size:255;not null"`
+	Prefix     string    `gorm:"size:16;not null"`
+	TokenHash  string    `gorm:"size:64;uniqueIndex;not null"`
+	Scopes     string    `gorm:"size:512;not null"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	LastUsedAt *time.Time
+	LastUsedIP string `gorm:"size:64"`
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (k *APIKey) BeforeCreate(_ *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// ScopeList returns the granted scopes.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
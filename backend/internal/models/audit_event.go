@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AuditEvent is an append-only record of security-relevant activity. ActorID
+// is the user who acted, when known; SubjectID is the user affected.
+type AuditEvent struct {
+	ID        uuid.UUID         `gorm:"type:uuid;primaryKey"`
+	Action    string            `gorm:"size:64;not null;index"`
+	ActorID   *uuid.UUID        `gorm:"type:uuid;index"`
+	SubjectID *uuid.UUID        `gorm:"type:uuid;index"`
+	IP        string            `gorm:"size:64"`
+	UserAgent string            `gorm:"size:255"`
+	Details   datatypes.JSONMap `gorm:"type:jsonb"`
+	CreatedAt time.Time         `gorm:"index"`
+}
+
+func (e *AuditEvent) BeforeCreate(_ *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthThrottle counts recent authentication failures for one subject (an
+// account or a client IP) within a scope such as login or email verification.
+// Rows live in PostgreSQL so every backend replica sees the same counters.
+type AuthThrottle struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Scope         string     `gorm:"size:32;not null;uniqueIndex:idx_auth_throttle_subject,priority:1"`
+	Kind          string     `gorm:"size:16;not null;uniqueIndex:idx_auth_throttle_subject,priority:2"`
+	Key           string     `gorm:"size:255;not null;uniqueIndex:idx_auth_throttle_subject,priority:3"`
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt *time.Time `gorm:"index"`
+	BlockedUntil  *time.Time
+	LockedOut     bool `gorm:"not null;default:false"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (t *AuthThrottle) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
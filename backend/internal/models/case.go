@@ -8,68 +8,137 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	CasePriorityHigh   = "High"
+	CasePriorityMedium = "Medium"
+	CasePriorityLow    = "Low"
+)
+
+var CasePriorities = []string{CasePriorityHigh, CasePriorityMedium, CasePriorityLow}
+
+// Case statuses of the default workflow, in lifecycle order. Matter types may
+// be configured with workflows of their own.
+const (
+	CaseStatusDraft    = "Draft"
+	CaseStatusIntake   = "Intake"
+	CaseStatusActive   = "Active"
+	CaseStatusOnHold   = "On Hold"
+	CaseStatusClosed   = "Closed"
+	CaseStatusArchived = "Archived"
+)
+
+// Full-text search vectors of cases and documents. The search indexes are
+// built on these expressions, so queries must use them verbatim for the
+// indexes to apply. Case vectors weight the name over the matter type, the
+// summary and the text of stakeholders and tasks in the metadata; document
+// vectors weight the title over the description and the extracted text.
+const (
+	CaseSearchVector = `(setweight(to_tsvector('english', coalesce(name, '')), 'A') || ` +
+		`setweight(to_tsvector('english', coalesce(matter_type, '')), 'B') || ` +
+		`setweight(to_tsvector('english', coalesce(summary, '')), 'C') || ` +
+		`setweight(jsonb_to_tsvector('english', coalesce(metadata -> 'stakeholders', '[]'::jsonb) || ` +
+		`coalesce(metadata -> 'tasks', '[]'::jsonb), '["string"]'), 'D'))`
+	DocumentSearchVector = `(setweight(to_tsvector('english', coalesce(title, '')), 'A') || ` +
+		`setweight(to_tsvector('english', coalesce(description, '')), 'B') || ` +
+		`setweight(to_tsvector('english', coalesce(content_text, '')), 'C'))`
+)
+
+// Case belongs to an organization; UserID records the member who opened it.
+// Version counts edits to the case's own fields and backs its ETag.
 type Case struct {
-	ID          uuid.UUID         `gorm:"type:uuid;primaryKey"`
-	UserID      uuid.UUID         `gorm:"type:uuid;not null;index"`
-	Name        string            `gorm:"size:255;not null"`
-	Priority    string            `gorm:"size:32;not null;default:Medium"`
-	Status      string            `gorm:"size:32;not null;default:Draft"`
-	MatterType  string            `gorm:"size:255"`
-	Owner       string            `gorm:"size:255"`
-	Summary     string            `gorm:"type:text"`
-	AIFocus     string            `gorm:"size:255"`
-	AIContext   datatypes.JSONMap `gorm:"type:jsonb"`
-	Metadata    datatypes.JSONMap `gorm:"type:jsonb"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	User        User             `gorm:"constraint:OnDelete:CASCADE;"`
-	Documents   []CaseDocument   `gorm:"constraint:OnDelete:CASCADE;"`
-	Assignments []CaseAssignment `gorm:"constraint:OnDelete:CASCADE;"`
+	ID              uuid.UUID         `gorm:"type:uuid;primaryKey"`
+	OrganizationID  uuid.UUID         `gorm:"type:uuid;not null;index"`
+	UserID          uuid.UUID         `gorm:"type:uuid;not null;index"`
+	Name            string            `gorm:"size:255;not null"`
+	Priority        string            `gorm:"size:32;not null;default:Medium"`
+	Status          string            `gorm:"size:32;not null;default:Draft"`
+	MatterType      string            `gorm:"size:255"`
+	Owner           string            `gorm:"size:255"`
+	Summary         string            `gorm:"type:text"`
+	AIFocus         string            `gorm:"size:255"`
+	AIContext       datatypes.JSONMap `gorm:"type:jsonb"`
+	Metadata        datatypes.JSONMap `gorm:"type:jsonb"`
+	Version         int64             `gorm:"not null;default:1"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Organization    Organization         `gorm:"constraint:OnDelete:CASCADE;"`
+	User            User                 `gorm:"constraint:OnDelete:CASCADE;"`
+	Documents       []CaseDocument       `gorm:"constraint:OnDelete:CASCADE;"`
+	Assignments     []CaseAssignment     `gorm:"constraint:OnDelete:CASCADE;"`
+	FirmAssignments []CaseFirmAssignment `gorm:"constraint:OnDelete:CASCADE;"`
+	Collaborators   []CaseCollaborator   `gorm:"constraint:OnDelete:CASCADE;"`
+	Invitations     []CaseInvitation     `gorm:"constraint:OnDelete:CASCADE;"`
+	StatusHistory   []CaseStatusChange   `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 func (c *Case) BeforeCreate(_ *gorm.DB) error {
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
 	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
 	return nil
 }
 
-type CaseDocument struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CaseID      uuid.UUID `gorm:"type:uuid;not null;index"`
-	Title       string    `gorm:"size:255;not null"`
-	Owner       string    `gorm:"size:255"`
-	Description string    `gorm:"type:text"`
-	Status      string    `gorm:"size:64"`
-	Category    string    `gorm:"size:64;not null;default:case"`
-	StoragePath string    `gorm:"size:512"`
-	FilePath    string    `gorm:"size:1024"`
+// CaseStatusChange records one move of a case through its workflow, the
+// first with an empty FromStatus when the case was opened. ChangedByID is nil
+// once the account that made the change is deleted.
+type CaseStatusChange struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CaseID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FromStatus  string     `gorm:"size:32"`
+	ToStatus    string     `gorm:"size:32;not null"`
+	Reason      string     `gorm:"size:512"`
+	ChangedByID *uuid.UUID `gorm:"type:uuid;index"`
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Case        Case `gorm:"constraint:OnDelete:CASCADE;"`
+	Case        Case  `gorm:"constraint:OnDelete:CASCADE;"`
+	ChangedBy   *User `gorm:"constraint:OnDelete:SET NULL;"`
 }
 
-func (d *CaseDocument) BeforeCreate(_ *gorm.DB) error {
-	if d.ID == uuid.Nil {
-		d.ID = uuid.New()
+func (c *CaseStatusChange) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
 	}
 	return nil
 }
 
-type CaseAssignment struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CaseID    uuid.UUID `gorm:"type:uuid;not null;index:idx_case_lawyer,priority:1"`
-	LawyerID  uuid.UUID `gorm:"type:uuid;not null;index:idx_case_lawyer,priority:2"`
-	Notes     string    `gorm:"size:512"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Case      Case `gorm:"constraint:OnDelete:CASCADE;"`
-	Lawyer    User `gorm:"constraint:OnDelete:CASCADE;"`
+const (
+	DocumentVisibilityShared     = "shared"
+	DocumentVisibilityRestricted = "restricted"
+)
+
+// CaseDocument is a file or reference attached to a case. Shared documents
+// are visible to everyone on the case; restricted ones only to the owning
+// organization and lead counsel. UploadedByID is nil for documents that
+// predate upload tracking. ContentText holds text extracted from the uploaded
+// file for search, when it could be read. Version counts edits and backs the
+// ETag.
+type CaseDocument struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CaseID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Title        string     `gorm:"size:255;not null"`
+	Owner        string     `gorm:"size:255"`
+	Description  string     `gorm:"type:text"`
+	Status       string     `gorm:"size:64"`
+	Category     string     `gorm:"size:64;not null;default:case"`
+	Visibility   string     `gorm:"size:16;not null;default:shared"`
+	UploadedByID *uuid.UUID `gorm:"type:uuid"`
+	StoragePath  string     `gorm:"size:512"`
+	FilePath     string     `gorm:"size:1024"`
+	ContentText  string     `gorm:"type:text"`
+	Version      int64      `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Case         Case `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
-func (a *CaseAssignment) BeforeCreate(_ *gorm.DB) error {
-	if a.ID == uuid.Nil {
-		a.ID = uuid.New()
+func (d *CaseDocument) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	if d.Version == 0 {
+		d.Version = 1
 	}
 	return nil
 }
@@ -7,20 +7,76 @@ import (
 	"gorm.io/gorm"
 )
 
+// Per-case roles. Lawyers hold one of the counsel roles through their
+// assignment; client accounts outside the owning organization are added as
+// collaborators or observers.
+const (
+	CaseRoleLeadCounsel  = "lead_counsel"
+	CaseRoleCounsel      = "counsel"
+	CaseRoleReviewer     = "reviewer"
+	CaseRoleObserver     = "observer"
+	CaseRoleCollaborator = "collaborator"
+)
+
+// LawyerCaseRoles lists the roles an assigned lawyer may hold.
+var LawyerCaseRoles = []string{CaseRoleLeadCounsel, CaseRoleCounsel, CaseRoleReviewer, CaseRoleObserver}
+
+// ClientCaseRoles lists the roles a collaborating client account may hold.
+var ClientCaseRoles = []string{CaseRoleCollaborator, CaseRoleObserver}
+
+// Assignment statuses. A lawyer accepts or declines a proposed assignment
+// and later withdraws from or completes an accepted one; the client or the
+// staffing firm may remove an active assignment at any time.
+const (
+	AssignmentProposed  = "proposed"
+	AssignmentAccepted  = "accepted"
+	AssignmentDeclined  = "declined"
+	AssignmentWithdrawn = "withdrawn"
+	AssignmentCompleted = "completed"
+	AssignmentRemoved   = "removed"
+)
+
+// ActiveAssignmentStatuses lists the statuses in which an assignment still
+// gives the lawyer access to the case.
+var ActiveAssignmentStatuses = []string{AssignmentProposed, AssignmentAccepted}
+
+// CaseAssignment gives a lawyer access to a case with one of the
+// LawyerCaseRoles. FirmID is set when the lawyer was staffed by a firm engaged
+// on the case rather than assigned by the client directly. The lawyer only
+// works on the case, documents included, once they accepted; ended
+// assignments are kept as a record and give no access.
 type CaseAssignment struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CaseID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_case_lawyer"`
-	LawyerID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_case_lawyer"`
-	Notes     string    `gorm:"size:512"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Case      Case `gorm:"constraint:OnDelete:CASCADE;"`
-	Lawyer    User `gorm:"constraint:OnDelete:CASCADE;"`
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CaseID       uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_case_lawyer"`
+	LawyerID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_case_lawyer"`
+	FirmID       *uuid.UUID `gorm:"type:uuid;index"`
+	Role         string     `gorm:"size:32;not null;default:counsel"`
+	Status       string     `gorm:"size:16;not null;default:proposed;index"`
+	StatusReason string     `gorm:"size:512"`
+	Notes        string     `gorm:"size:512"`
+	AcceptedAt   *time.Time
+	DeclinedAt   *time.Time
+	WithdrawnAt  *time.Time
+	CompletedAt  *time.Time
+	RemovedAt    *time.Time
+	RemovedByID  *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Case         Case `gorm:"constraint:OnDelete:CASCADE;"`
+	Lawyer       User `gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 func (a *CaseAssignment) BeforeCreate(_ *gorm.DB) error {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
+	if a.Status == "" {
+		a.Status = AssignmentProposed
+	}
 	return nil
 }
+
+// Active reports whether the assignment still gives access to the case.
+func (a *CaseAssignment) Active() bool {
+	return a.Status == AssignmentProposed || a.Status == AssignmentAccepted
+}
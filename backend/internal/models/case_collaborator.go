@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CaseCollaborator gives a client account outside the case's organization
+// access to that one case with one of the ClientCaseRoles.
+type CaseCollaborator struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CaseID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_case_collaborator"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_case_collaborator;index"`
+	Role      string    `gorm:"size:32;not null"`
+	AddedByID uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Case      Case `gorm:"constraint:OnDelete:CASCADE;"`
+	User      User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+func (c *CaseCollaborator) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CaseInvitation is an emailed offer of a role on a case to a lawyer who may
+// not have an account yet. It stands in for the assignment until it is
+// redeemed; only the hash of the invitation token is stored.
+type CaseInvitation struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CaseID       uuid.UUID `gorm:"type:uuid;not null;index"`
+	Email        string    `gorm:"size:255;not null;index"`
+	Role         string    `gorm:"size:32;not null"`
+	Notes        string    `gorm:"size:512"`
+	TokenHash    string    `gorm:"size:64;uniqueIndex;not null"`
+	InvitedByID  uuid.UUID `gorm:"type:uuid;not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	AcceptedAt   *time.Time
+	AcceptedByID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Case         Case `gorm:"constraint:OnDelete:CASCADE;"`
+	InvitedBy    User `gorm:"foreignKey:InvitedByID;constraint:OnDelete:CASCADE"`
+}
+
+func (i *CaseInvitation) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
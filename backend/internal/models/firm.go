@@ -0,0 +1,108 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	FirmRoleAdmin     = "admin"
+	FirmRoleAssociate = "associate"
+)
+
+// Firm groups lawyer accounts. Clients can engage a whole firm on a case, and
+// the firm's admins decide which of its lawyers work on it.
+type Firm struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"size:255;not null"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (f *Firm) BeforeCreate(_ *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+// FirmMembership places a lawyer in a firm. A lawyer belongs to at most one
+// firm.
+type FirmMembership struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FirmID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	Role      string    `gorm:"size:16;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Firm      Firm `gorm:"constraint:OnDelete:CASCADE"`
+	User      User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (m *FirmMembership) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// FirmInvitation is an emailed offer to join a firm. The lawyer joins only
+// by redeeming it, so a firm cannot take on a lawyer without their consent.
+// Only the hash of the invitation token is stored; AcceptedAt marks it
+// redeemed.
+type FirmInvitation struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FirmID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	Email       string    `gorm:"size:255;not null;index"`
+	Role        string    `gorm:"size:16;not null"`
+	TokenHash   string    `gorm:"size:64;uniqueIndex;not null"`
+	InvitedByID uuid.UUID `gorm:"type:uuid;not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	AcceptedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Firm        Firm `gorm:"constraint:OnDelete:CASCADE"`
+	InvitedBy   User `gorm:"foreignKey:InvitedByID;constraint:OnDelete:CASCADE"`
+}
+
+func (i *FirmInvitation) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// CaseFirmAssignment engages a firm on a case. Like a lawyer's assignment it
+// starts out proposed: the firm's admins may view the case to decide on it,
+// and once one of them accepts they work on it as counsel and staff it.
+// Lawyers the firm staffs on the case get CaseAssignment rows carrying the
+// firm's id. A declined engagement gives no access.
+type CaseFirmAssignment struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	CaseID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_case_firm"`
+	FirmID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_case_firm;index"`
+	AssignedByID  uuid.UUID  `gorm:"type:uuid;not null"`
+	Status        string     `gorm:"size:16;not null;default:proposed;index"`
+	StatusReason  string     `gorm:"size:512"`
+	Notes         string     `gorm:"size:512"`
+	RespondedByID *uuid.UUID `gorm:"type:uuid"`
+	AcceptedAt    *time.Time
+	DeclinedAt    *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Case          Case `gorm:"constraint:OnDelete:CASCADE;"`
+	Firm          Firm `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+func (a *CaseFirmAssignment) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	if a.Status == "" {
+		a.Status = AssignmentProposed
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// IdentityProvider is an organization's OpenID Connect configuration. Slug
+// names the organization in SSO login URLs; the email domains whose accounts
+// the provider may sign in, provision or link are its
+// IdentityProviderDomains. The provider only ever signs in members of
+// OrganizationID.
+type IdentityProvider struct {
+	ID                    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OrganizationID        uuid.UUID `gorm:"type:uuid;not null;index"`
+	Slug                  string    `gorm:"size:64;uniqueIndex;not null"`
+	Name                  string    `gorm:"size:255;not null"`
+	Issuer                string    `gorm:"size:512;not null"`
+	ClientID              string    `gorm:"size:255;not null"`
+	ClientSecretEncrypted string    `gorm:"size:1024"`
+	Scopes                string    `gorm:"size:255;not null"`
+	// RoleClaim names the ID token claim (a string or list of strings) that
+	// RoleMapping translates into account roles; DefaultRole applies when no
+	// value matches, and an empty DefaultRole refuses the login.
+	RoleClaim    string            `gorm:"size:128"`
+	RoleMapping  datatypes.JSONMap `gorm:"type:jsonb"`
+	DefaultRole  string            `gorm:"size:32"`
+	Enabled      bool              `gorm:"not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Organization Organization `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (p *IdentityProvider) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// ScopeList returns the scopes requested at login.
+func (p *IdentityProvider) ScopeList() []string {
+	return strings.Fields(p.Scopes)
+}
+
+// IdentityProviderDomain is an email domain an identity provider claims. A
+// claim only takes effect once the domain's owner proves control of it by
+// publishing VerificationToken in DNS, or a platform administrator approves
+// it; until VerifiedAt is set discovery ignores the domain and the provider
+// can neither link nor provision accounts with it. Any number of providers
+// may claim a domain, but only one can hold it verified.
+type IdentityProviderDomain struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ProviderID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_identity_provider_domain"`
+	Domain            string    `gorm:"size:253;not null;uniqueIndex:idx_identity_provider_domain;uniqueIndex:idx_identity_provider_verified_domain,where:verified_at IS NOT NULL"`
+	VerificationToken string    `gorm:"size:64;not null"`
+	VerifiedAt        *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Provider          IdentityProvider `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
+}
+
+func (d *IdentityProviderDomain) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Verified reports whether the claim is in effect.
+func (d *IdentityProviderDomain) Verified() bool {
+	return d.VerifiedAt != nil
+}
+
+// UserIdentity links a user to the subject an identity provider knows them
+// by, so later logins survive email changes at the IdP.
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string    `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`
+	Email       string    `gorm:"size:255;not null"`
+	LastLoginAt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	User        User             `gorm:"constraint:OnDelete:CASCADE"`
+	Provider    IdentityProvider `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
+}
+
+func (i *UserIdentity) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// SSOLoginState holds an in-flight authorization request between the redirect
+// to the IdP and its callback. The state value itself is only kept as a hash;
+// the PKCE verifier is encrypted with the application keyring.
+type SSOLoginState struct {
+	ID                    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ProviderID            uuid.UUID `gorm:"type:uuid;not null;index"`
+	StateHash             string    `gorm:"size:64;uniqueIndex;not null"`
+	Nonce                 string    `gorm:"size:64;not null"`
+	CodeVerifierEncrypted string    `gorm:"size:255;not null"`
+	RedirectPath          string    `gorm:"size:512"`
+	RememberMe            bool      `gorm:"not null;default:false"`
+	ExpiresAt             time.Time `gorm:"not null;index"`
+	CreatedAt             time.Time
+	Provider              IdentityProvider `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE"`
+}
+
+func (s *SSOLoginState) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Lawyer availability for new matters.
+const (
+	AvailabilityOpen        = "available"
+	AvailabilityLimited     = "limited"
+	AvailabilityUnavailable = "unavailable"
+)
+
+var Availabilities = []string{AvailabilityOpen, AvailabilityLimited, AvailabilityUnavailable}
+
+// BarAdmission records a jurisdiction whose bar admitted the lawyer.
+type BarAdmission struct {
+	Jurisdiction string `json:"jurisdiction"`
+	Number       string `json:"number,omitempty"`
+	Year         int    `json:"year,omitempty"`
+}
+
+// LawyerProfile describes a lawyer's practice for the directory clients pick
+// counsel from. PracticeAreas, Jurisdictions and Languages hold lower-case
+// tags so the directory can match them exactly. HourlyRate is in whole units
+// of Currency; zero means the lawyer did not publish a rate.
+type LawyerProfile struct {
+	ID              uuid.UUID                          `gorm:"type:uuid;primaryKey"`
+	UserID          uuid.UUID                          `gorm:"type:uuid;not null;uniqueIndex"`
+	Headline        string                             `gorm:"size:255"`
+	Bio             string                             `gorm:"type:text"`
+	PracticeAreas   datatypes.JSONType[[]string]       `gorm:"type:jsonb"`
+	Jurisdictions   datatypes.JSONType[[]string]       `gorm:"type:jsonb"`
+	Languages       datatypes.JSONType[[]string]       `gorm:"type:jsonb"`
+	BarAdmissions   datatypes.JSONType[[]BarAdmission] `gorm:"type:jsonb"`
+	YearsExperience int                                `gorm:"not null;default:0;index"`
+	HourlyRate      int                                `gorm:"not null;default:0"`
+	Currency        string                             `gorm:"size:3;not null;default:USD"`
+	Availability    string                             `gorm:"size:16;not null;default:available;index"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	User            User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+func (p *LawyerProfile) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.Availability == "" {
+		p.Availability = AvailabilityOpen
+	}
+	return nil
+}
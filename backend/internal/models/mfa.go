@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TOTPCredential holds a user's authenticator secret, encrypted with the
+// application keyring. It only counts as a second factor once ConfirmedAt is
+// set by a successful code check.
+type TOTPCredential struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	SecretEncrypted string    `gorm:"size:255;not null"`
+	ConfirmedAt     *time.Time
+	LastUsedStep    int64 `gorm:"not null;default:0"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	User            User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (c *TOTPCredential) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// RecoveryCode is a bcrypt-hashed single-use fallback for a lost
+// authenticator.
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash  string    `gorm:"size:255;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	User      User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (c *RecoveryCode) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// MFAChallenge is issued after a correct password when a second factor is
+// still required. Only the hash of the challenge token is stored.
+type MFAChallenge struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash  string    `gorm:"size:64;uniqueIndex;not null"`
+	Attempts   int       `gorm:"not null;default:0"`
+	UserAgent  string    `gorm:"size:255"`
+	IP         string    `gorm:"size:64"`
+	RememberMe bool      `gorm:"not null;default:false"`
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (c *MFAChallenge) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+	OrgRoleViewer = "viewer"
+)
+
+// OrgRoles lists organization roles from most to least privileged.
+var OrgRoles = []string{OrgRoleOwner, OrgRoleAdmin, OrgRoleMember, OrgRoleViewer}
+
+// OrgRoleAtLeast reports whether role grants everything required does.
+// Unknown roles grant nothing.
+func OrgRoleAtLeast(role, required string) bool {
+	rank := func(r string) int {
+		for i, candidate := range OrgRoles {
+			if candidate == r {
+				return len(OrgRoles) - i
+			}
+		}
+		return 0
+	}
+	return rank(role) > 0 && rank(role) >= rank(required)
+}
+
+// Organization is a client workspace. Cases belong to the organization, so
+// every member sees and works on them according to their membership role.
+type Organization struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name        string    `gorm:"size:255;not null"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (o *Organization) BeforeCreate(_ *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// OrganizationMembership grants a user one of the organization roles.
+type OrganizationMembership struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_member"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_org_member;index"`
+	Role           string    `gorm:"size:16;not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE"`
+	User           User         `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (m *OrganizationMembership) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// OrganizationInvitation is an emailed offer to join an organization. Only
+// the hash of the invitation token is stored; AcceptedAt marks it redeemed.
+type OrganizationInvitation struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Email          string    `gorm:"size:255;not null;index"`
+	Role           string    `gorm:"size:16;not null"`
+	TokenHash      string    `gorm:"size:64;uniqueIndex;not null"`
+	InvitedByID    uuid.UUID `gorm:"type:uuid;not null"`
+	ExpiresAt      time.Time `gorm:"not null;index"`
+	AcceptedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Organization   Organization `gorm:"constraint:OnDelete:CASCADE"`
+	InvitedBy      User         `gorm:"foreignKey:InvitedByID;constraint:OnDelete:CASCADE"`
+}
+
+func (i *OrganizationInvitation) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// Passkey is a WebAuthn credential registered by a user. CredentialID is the
+// base64url credential id and PublicKey the COSE-encoded key it signs with.
+type Passkey struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name           string    `gorm:"size:255;not null"`
+	CredentialID   string    `gorm:"size:1400;uniqueIndex;not null"`
+	PublicKey      []byte    `gorm:"not null"`
+	SignCount      uint32    `gorm:"not null;default:0"`
+	Transports     string    `gorm:"size:255"`
+	BackupEligible bool      `gorm:"not null;default:false"`
+	BackedUp       bool      `gorm:"not null;default:false"`
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	User           User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (p *Passkey) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// WebAuthnChallenge is a pending registration or login ceremony, found by
+// the hash of the challenge the client signs. UserID is empty for a
+// username-less login; MFAChallengeID ties a login to a password sign-in that
+// is waiting for its second factor.
+type WebAuthnChallenge struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	ChallengeHash  string     `gorm:"size:64;uniqueIndex;not null"`
+	Ceremony       string     `gorm:"size:32;not null"`
+	UserID         *uuid.UUID `gorm:"type:uuid;index"`
+	MFAChallengeID *uuid.UUID `gorm:"type:uuid"`
+	RememberMe     bool       `gorm:"not null;default:false"`
+	ExpiresAt      time.Time  `gorm:"not null;index"`
+	CreatedAt      time.Time
+}
+
+func (c *WebAuthnChallenge) BeforeCreate(_ *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
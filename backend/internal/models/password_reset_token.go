@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken stores only the SHA-256 hash of the emailed token. A token
+// is consumed by setting UsedAt and can never be redeemed twice.
+type PasswordResetToken struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash   string     `gorm:"size:64;uniqueIndex;not null"`
+	RequestedIP string     `gorm:"size:64"`
+	ExpiresAt   time.Time  `gorm:"not null;index"`
+	UsedAt      *time.Time `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	User        User `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+func (t *PasswordResetToken) BeforeCreate(_ *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
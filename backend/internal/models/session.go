@@ -10,14 +10,18 @@ import (
 type Session struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
 	UserID       uuid.UUID `gorm:"type:uuid;not null;index"`
-	Token        string    `gorm:"size:255;uniqueIndex;not null"`
+	TokenHash    string    `gorm:"size:64;uniqueIndex;not null"`
 	UserAgent    string    `gorm:"size:255"`
 	IP           string    `gorm:"size:64"`
+	RememberMe   bool      `gorm:"not null;default:false"`
 	LastActivity time.Time `gorm:"not null;index"`
 	ExpiresAt    time.Time `gorm:"not null"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	User         User `gorm:"constraint:OnDelete:CASCADE"`
+	// Token is the raw bearer secret for the request that created or
+	// presented the session. Only its keyed hash is persisted.
+	Token string `gorm:"-"`
 }
 
 func (s *Session) BeforeCreate(_ *gorm.DB) error {
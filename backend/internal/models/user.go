@@ -10,6 +10,23 @@ import (
 const (
 	UserRoleClient = "client"
 	UserRoleLawyer = "lawyer"
+	// UserRoleAdmin cannot be chosen at registration; it is granted directly
+	// in the database.
+	UserRoleAdmin = "admin"
+)
+
+// Lawyer accounts are vetted by an administrator before clients can assign
+// them. Other accounts are approved on creation.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+const (
+	PlanStarter = "starter"
+	PlanGrowth  = "growth"
+	PlanElite   = "elite"
 )
 
 type User struct {
@@ -20,8 +37,16 @@ type User struct {
 	Subscription string    `gorm:"size:50;not null;default:starter"`
 	Verified     bool      `gorm:"not null;default:false"`
 	Role         string    `gorm:"size:32;not null;default:client"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	MFAEnabled   bool      `gorm:"not null;default:false"`
+	MFARequired  bool      `gorm:"not null;default:false"`
+	// ApprovalStatus is one of the Approval constants.
+	ApprovalStatus string `gorm:"size:16;not null;default:approved;index"`
+	// SuspendedAt is set while an administrator has suspended the account;
+	// suspended accounts cannot sign in or use API keys.
+	SuspendedAt      *time.Time `gorm:"index"`
+	SuspensionReason string     `gorm:"size:512"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 func (u *User) BeforeCreate(_ *gorm.DB) error {
@@ -31,5 +56,19 @@ func (u *User) BeforeCreate(_ *gorm.DB) error {
 	if u.Role == "" {
 		u.Role = UserRoleClient
 	}
+	if u.ApprovalStatus == "" {
+		u.ApprovalStatus = ApprovalApproved
+	}
 	return nil
 }
+
+// Suspended reports whether an administrator has suspended the account.
+func (u *User) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// Assignable reports whether the account can be assigned to cases: an
+// approved, active lawyer.
+func (u *User) Assignable() bool {
+	return u.Role == UserRoleLawyer && u.ApprovalStatus == ApprovalApproved && !u.Suspended()
+}
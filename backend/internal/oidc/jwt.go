@@ -0,0 +1,262 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefreshGap stops a stream of tokens with unknown key ids from turning
+// into a stream of JWKS fetches.
+const jwksRefreshGap = time.Minute
+
+// Claims are the ID token claims the application uses. Raw keeps everything
+// so deployments can map custom claims such as groups to roles.
+type Claims struct {
+	Issuer          string
+	Subject         string
+	Audience        []string
+	AuthorizedParty string
+	Expiry          time.Time
+	IssuedAt        time.Time
+	Nonce           string
+	Email           string
+	EmailVerified   bool
+	Name            string
+	Raw             map[string]any
+}
+
+func (c *Claims) hasAudience(clientID string) bool {
+	for _, aud := range c.Audience {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// Strings returns claim name as a list, accepting either a single string or
+// an array of strings.
+func (c *Claims) Strings(name string) []string {
+	switch value := c.Raw[name].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func parseClaims(payload []byte) (*Claims, error) {
+	raw := map[string]any{}
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrTokenInvalid, err)
+	}
+
+	claims := &Claims{Raw: raw}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.AuthorizedParty, _ = raw["azp"].(string)
+	claims.Nonce, _ = raw["nonce"].(string)
+	claims.Email, _ = raw["email"].(string)
+	claims.Name, _ = raw["name"].(string)
+	claims.Audience = claims.Strings("aud")
+	claims.Expiry = numericDate(raw["exp"])
+	claims.IssuedAt = numericDate(raw["iat"])
+
+	// Some providers send email_verified as a string.
+	switch verified := raw["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = strings.EqualFold(verified, "true")
+	}
+
+	return claims, nil
+}
+
+func numericDate(value any) time.Time {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0)
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verifySignature checks a compact JWS against the provider's keys and
+// returns the decoded payload. Only asymmetric algorithms are accepted.
+func verifySignature(ctx context.Context, c *Client, keys *keySet, token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrTokenInvalid)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: header encoding", ErrTokenInvalid)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("%w: header", ErrTokenInvalid)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload encoding", ErrTokenInvalid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrTokenInvalid)
+	}
+
+	key, err := keys.lookup(ctx, c, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch header.Alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return nil, fmt.Errorf("%w: bad signature", ErrTokenInvalid)
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return nil, fmt.Errorf("%w: bad signature", ErrTokenInvalid)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return nil, fmt.Errorf("%w: bad signature", ErrTokenInvalid)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrTokenInvalid, header.Alg)
+	}
+
+	return payload, nil
+}
+
+// keySet caches a provider's JWKS and refetches it when a token names a key
+// it has not seen, which is how providers roll their signing keys.
+type keySet struct {
+	uri string
+
+	mu        sync.Mutex
+	keys      []jwk
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *keySet) lookup(ctx context.Context, c *Client, kid, alg string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < jwksRefreshGap {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrTokenInvalid, kid)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := c.getJSON(ctx, s.uri, &doc); err != nil {
+		return nil, fmt.Errorf("oidc: fetching keys: %w", err)
+	}
+	s.keys = doc.Keys
+	s.fetchedAt = time.Now()
+
+	if key := s.find(kid, alg); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrTokenInvalid, kid)
+}
+
+func (s *keySet) find(kid, alg string) crypto.PublicKey {
+	for _, candidate := range s.keys {
+		if kid != "" && candidate.Kid != kid {
+			continue
+		}
+		if candidate.Use != "" && candidate.Use != "sig" {
+			continue
+		}
+		if candidate.Alg != "" && candidate.Alg != alg {
+			continue
+		}
+		if key := candidate.publicKey(); key != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+func (k jwk) publicKey() crypto.PublicKey {
+	switch k.Kty {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			return nil
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil
+		}
+		return pub
+	}
+	return nil
+}
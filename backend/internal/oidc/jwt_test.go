@@ -0,0 +1,237 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "lexiflow"
+	testNonce    = "nonce-123"
+)
+
+// testIdP serves discovery and JWKS documents for one RSA and one EC key.
+type testIdP struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &testIdP{rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{
+			{
+				Kty: "RSA", Kid: "rsa", Use: "sig", Alg: "RS256",
+				N: b64(rsaKey.N.Bytes()), E: b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				Kty: "EC", Kid: "ec", Use: "sig", Alg: "ES256", Crv: "P-256",
+				X: b64(ecKey.X.FillBytes(make([]byte, 32))), Y: b64(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		}})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) claims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":            idp.server.URL,
+		"sub":            "subject-1",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          testNonce,
+		"email":          "legal@acme.test",
+		"email_verified": true,
+		"groups":         []string{"legal", "admins"},
+	}
+}
+
+func (idp *testIdP) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		signature = []byte("unsigned")
+	}
+	return signingInput + "." + b64(signature)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestVerifyAcceptsValidTokens(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(idp.server.Client())
+
+	for _, signer := range []struct{ alg, kid string }{{"RS256", "rsa"}, {"ES256", "ec"}} {
+		token := idp.sign(t, signer.alg, signer.kid, idp.claims())
+		claims, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, testNonce)
+		if err != nil {
+			t.Fatalf("%s: %v", signer.alg, err)
+		}
+		if claims.Subject != "subject-1" || claims.Email != "legal@acme.test" || !claims.EmailVerified {
+			t.Fatalf("%s: unexpected claims %+v", signer.alg, claims)
+		}
+		if groups := claims.Strings("groups"); len(groups) != 2 || groups[0] != "legal" {
+			t.Fatalf("%s: groups %v", signer.alg, groups)
+		}
+	}
+}
+
+func TestVerifyRejectsBadSignatures(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(idp.server.Client())
+	valid := idp.sign(t, "RS256", "rsa", idp.claims())
+	parts := strings.Split(valid, ".")
+
+	forged := idp.claims()
+	forged["sub"] = "someone-else"
+	forgedPayload, _ := json.Marshal(forged)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	impostor := &testIdP{server: idp.server, rsaKey: otherKey, ecKey: idp.ecKey}
+
+	cases := map[string]string{
+		"tampered payload":  parts[0] + "." + b64(forgedPayload) + "." + parts[2],
+		"wrong key":         impostor.sign(t, "RS256", "rsa", idp.claims()),
+		"alg none":          idp.sign(t, "none", "rsa", idp.claims()),
+		"symmetric alg":     idp.sign(t, "HS256", "rsa", idp.claims()),
+		"key of other type": idp.sign(t, "ES256", "rsa", idp.claims()),
+		"unknown key":       idp.sign(t, "RS256", "missing", idp.claims()),
+		"malformed":         parts[0] + "." + parts[1],
+	}
+	for name, token := range cases {
+		if _, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, testNonce); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("%s: got %v, want ErrTokenInvalid", name, err)
+		}
+	}
+}
+
+func TestVerifyRejectsInvalidClaims(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(idp.server.Client())
+
+	cases := map[string]func(map[string]any){
+		"wrong issuer":          func(c map[string]any) { c["iss"] = "https://evil.example" },
+		"wrong audience":        func(c map[string]any) { c["aud"] = "another-client" },
+		"audience without azp":  func(c map[string]any) { c["aud"] = []string{testClientID, "another-client"} },
+		"azp of another client": func(c map[string]any) { c["aud"] = []string{testClientID, "other"}; c["azp"] = "other" },
+		"expired":               func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":             func(c map[string]any) { delete(c, "exp") },
+		"issued in the future":  func(c map[string]any) { c["iat"] = time.Now().Add(time.Hour).Unix() },
+		"no subject":            func(c map[string]any) { delete(c, "sub") },
+	}
+	for name, mutate := range cases {
+		claims := idp.claims()
+		mutate(claims)
+		token := idp.sign(t, "RS256", "rsa", claims)
+		if _, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, testNonce); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("%s: got %v, want ErrTokenInvalid", name, err)
+		}
+	}
+}
+
+func TestVerifyAcceptsAuthorizedPartyForMultipleAudiences(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(idp.server.Client())
+
+	claims := idp.claims()
+	claims["aud"] = []string{testClientID, "another-client"}
+	claims["azp"] = testClientID
+	token := idp.sign(t, "RS256", "rsa", claims)
+	if _, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, testNonce); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyRejectsNonceMismatch(t *testing.T) {
+	idp := newTestIdP(t)
+	client := NewClient(idp.server.Client())
+
+	token := idp.sign(t, "RS256", "rsa", idp.claims())
+	if _, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, "another-nonce"); !errors.Is(err, ErrNonceInvalid) {
+		t.Fatalf("got %v, want ErrNonceInvalid", err)
+	}
+
+	claims := idp.claims()
+	delete(claims, "nonce")
+	token = idp.sign(t, "RS256", "rsa", claims)
+	if _, err := client.Verify(context.Background(), idp.server.URL, testClientID, token, testNonce); !errors.Is(err, ErrNonceInvalid) {
+		t.Fatalf("missing nonce: got %v, want ErrNonceInvalid", err)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                "https://evil.example",
+			AuthorizationEndpoint: "https://evil.example/authorize",
+			TokenEndpoint:         "https://evil.example/token",
+			JWKSURI:               "https://evil.example/jwks",
+		})
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.Client()).Discover(context.Background(), server.URL); err == nil {
+		t.Fatal("discovery accepted a document for another issuer")
+	}
+}
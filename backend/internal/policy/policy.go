@@ -0,0 +1,118 @@
+// Package policy decides what a user may do with a case and its documents
+// from how they relate to the case: their role in the organization that owns
+// it and any role granted on the case itself.
+package policy
+
+import (
+	"github.com/google/uuid"
+	"lexiflow/backend/internal/models"
+)
+
+type Action string
+
+const (
+	ActionView     Action = "view"
+	ActionEdit     Action = "edit"
+	ActionUpload   Action = "upload"
+	ActionDownload Action = "download"
+	ActionDelete   Action = "delete"
+	// ActionManage covers granting others access: assigning lawyers and
+	// firms and adding collaborators.
+	ActionManage Action = "manage"
+)
+
+// Actions lists every action in the order they are reported to clients.
+var Actions = []Action{ActionView, ActionEdit, ActionUpload, ActionDownload, ActionDelete, ActionManage}
+
+// Subject is a user's standing on one case. OrgRole is empty when the user
+// does not belong to the owning organization and CaseRole when nothing was
+// granted on the case; a user with neither has no access at all. Proposed
+// marks a lawyer who has not accepted their assignment yet, or whose firm has
+// not accepted its engagement: they may view the case to decide on it, but
+// none of its documents, whatever their CaseRole.
+type Subject struct {
+	UserID   uuid.UUID
+	OrgRole  string
+	CaseRole string
+	Proposed bool
+}
+
+// Participant reports whether s has any access to the case.
+func (s Subject) Participant() bool {
+	return s.OrgRole != "" || s.CaseRole != ""
+}
+
+var orgGrants = map[string][]Action{
+	models.OrgRoleOwner:  {ActionView, ActionEdit, ActionUpload, ActionDownload, ActionDelete, ActionManage},
+	models.OrgRoleAdmin:  {ActionView, ActionEdit, ActionUpload, ActionDownload, ActionDelete, ActionManage},
+	models.OrgRoleMember: {ActionView, ActionEdit, ActionUpload, ActionDownload, ActionDelete, ActionManage},
+	models.OrgRoleViewer: {ActionView, ActionDownload},
+}
+
+var caseGrants = map[string][]Action{
+	models.CaseRoleLeadCounsel:  {ActionView, ActionEdit, ActionUpload, ActionDownload, ActionDelete},
+	models.CaseRoleCounsel:      {ActionView, ActionEdit, ActionUpload, ActionDownload},
+	models.CaseRoleReviewer:     {ActionView, ActionDownload},
+	models.CaseRoleObserver:     {ActionView},
+	models.CaseRoleCollaborator: {ActionView, ActionEdit, ActionUpload, ActionDownload},
+}
+
+func granted(s Subject, action Action) bool {
+	caseRole := caseGrants[s.CaseRole]
+	if s.Proposed {
+		caseRole = []Action{ActionView}
+	}
+	for _, grants := range [][]Action{orgGrants[s.OrgRole], caseRole} {
+		for _, candidate := range grants {
+			if candidate == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Case reports whether s may perform action on the case itself. Deleting a
+// case is reserved to organization admins and the member who opened it;
+// case roles never allow it.
+func Case(s Subject, c *models.Case, action Action) bool {
+	if action == ActionDelete {
+		return models.OrgRoleAtLeast(s.OrgRole, models.OrgRoleAdmin) ||
+			(models.OrgRoleAtLeast(s.OrgRole, models.OrgRoleMember) && c.UserID == s.UserID)
+	}
+	return granted(s, action)
+}
+
+// CaseActions lists the actions s may perform on c.
+func CaseActions(s Subject, c *models.Case) []Action {
+	actions := make([]Action, 0, len(Actions))
+	for _, action := range Actions {
+		if Case(s, c, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// SeesRestricted reports whether s may see restricted documents: members of
+// the owning organization and lead counsel.
+func SeesRestricted(s Subject) bool {
+	return s.OrgRole != "" || (s.CaseRole == models.CaseRoleLeadCounsel && !s.Proposed)
+}
+
+// Document reports whether s may perform action on doc. Restricted documents,
+// and every document for a lawyer yet to accept their assignment, do not exist
+// as far as the subject is concerned. Anyone allowed to upload may delete what
+// they uploaded themselves.
+func Document(s Subject, doc *models.CaseDocument, action Action) bool {
+	if s.Proposed && s.OrgRole == "" {
+		return false
+	}
+	if doc.Visibility == models.DocumentVisibilityRestricted && !SeesRestricted(s) {
+		return false
+	}
+	if action == ActionDelete && !granted(s, ActionDelete) {
+		return granted(s, ActionUpload) && doc.UploadedByID != nil && *doc.UploadedByID == s.UserID
+	}
+	return granted(s, action)
+}
@@ -0,0 +1,360 @@
+// Package recommend ranks lawyers for a case. Every score is the sum of a
+// handful of factors, each reported with the points it earned and why, so
+// clients can see what a suggestion is based on.
+package recommend
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"lexiflow/backend/internal/models"
+)
+
+// Factor names, in the order they are reported.
+const (
+	FactorExpertise    = "expertise"
+	FactorJurisdiction = "jurisdiction"
+	FactorSimilarCases = "similar_cases"
+	FactorExperience   = "experience"
+	FactorCapacity     = "capacity"
+	FactorTrackRecord  = "track_record"
+)
+
+// Points available per factor, and the amounts at which a lawyer earns all
+// of them.
+const (
+	expertiseMatterMax  = 25
+	expertiseFocusMax   = 15
+	jurisdictionMax     = 20
+	similarCasesMax     = 10
+	experienceMax       = 10
+	capacityMax         = 10
+	trackRecordMax      = 10
+	fullExperienceYears = 15
+	fullCaseload        = 8
+	fullSimilarCases    = 3
+)
+
+// Criteria is what a case asks for: its matter type, the terms of its AI
+// focus and the jurisdictions listed in its metadata, all lower-case.
+type Criteria struct {
+	MatterType    string   `json:"matterType"`
+	Focus         []string `json:"focus"`
+	Jurisdictions []string `json:"jurisdictions"`
+}
+
+// CriteriaFor extracts the criteria of a case. Jurisdictions are read from
+// the "jurisdictions" metadata key, a list or a comma-separated string, and
+// the singular "jurisdiction" key.
+func CriteriaFor(c *models.Case) Criteria {
+	criteria := Criteria{
+		MatterType:    strings.ToLower(strings.TrimSpace(c.MatterType)),
+		Focus:         splitTerms(c.AIFocus),
+		Jurisdictions: []string{},
+	}
+	for _, key := range []string{"jurisdictions", "jurisdiction"} {
+		switch value := c.Metadata[key].(type) {
+		case string:
+			criteria.Jurisdictions = appendUnique(criteria.Jurisdictions, splitTerms(value)...)
+		case []any:
+			for _, item := range value {
+				if text, ok := item.(string); ok {
+					criteria.Jurisdictions = appendUnique(criteria.Jurisdictions, splitTerms(text)...)
+				}
+			}
+		}
+	}
+	return criteria
+}
+
+// Candidate is a lawyer with a profile and their assignment history. Statuses
+// counts their assignments on other cases by status; SimilarCompleted counts
+// completed ones on cases of the same matter type.
+type Candidate struct {
+	Profile          *models.LawyerProfile
+	Statuses         map[string]int
+	SimilarCompleted int
+}
+
+type Factor struct {
+	Name      string `json:"factor"`
+	Points    int    `json:"points"`
+	MaxPoints int    `json:"maxPoints"`
+	Detail    string `json:"detail"`
+}
+
+// Recommendation is a scored candidate. Score is the share of the available
+// points the lawyer earned, from 0 to 100; factors the case gives nothing to
+// match against are reported with no available points and do not count.
+type Recommendation struct {
+	LawyerID uuid.UUID
+	Score    int
+	Factors  []Factor
+}
+
+// Rank scores candidates against criteria, best first. Ties are broken by
+// experience and then name so the order is stable.
+func Rank(criteria Criteria, candidates []Candidate) []Recommendation {
+	type scored struct {
+		recommendation Recommendation
+		candidate      *Candidate
+	}
+	results := make([]scored, 0, len(candidates))
+	for i := range candidates {
+		results = append(results, scored{Score(criteria, &candidates[i]), &candidates[i]})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.recommendation.Score != b.recommendation.Score {
+			return a.recommendation.Score > b.recommendation.Score
+		}
+		if a.candidate.Profile.YearsExperience != b.candidate.Profile.YearsExperience {
+			return a.candidate.Profile.YearsExperience > b.candidate.Profile.YearsExperience
+		}
+		return a.candidate.Profile.User.CompanyName < b.candidate.Profile.User.CompanyName
+	})
+
+	ranked := make([]Recommendation, 0, len(results))
+	for _, result := range results {
+		ranked = append(ranked, result.recommendation)
+	}
+	return ranked
+}
+
+// Score scores one candidate against criteria.
+func Score(criteria Criteria, candidate *Candidate) Recommendation {
+	factors := []Factor{
+		expertise(criteria, candidate.Profile),
+		jurisdiction(criteria, candidate.Profile),
+		similarCases(criteria, candidate),
+		experience(candidate.Profile),
+		capacity(candidate),
+		trackRecord(candidate),
+	}
+
+	var points, available int
+	for _, factor := range factors {
+		points += factor.Points
+		available += factor.MaxPoints
+	}
+	score := 0
+	if available > 0 {
+		score = int(math.Round(float64(points) * 100 / float64(available)))
+	}
+	return Recommendation{LawyerID: candidate.Profile.UserID, Score: score, Factors: factors}
+}
+
+func expertise(criteria Criteria, profile *models.LawyerProfile) Factor {
+	factor := Factor{Name: FactorExpertise}
+	areas := profile.PracticeAreas.Data
+	var reasons []string
+
+	if criteria.MatterType != "" {
+		factor.MaxPoints += expertiseMatterMax
+		if area, ok := firstMatch(areas, criteria.MatterType); ok {
+			factor.Points += expertiseMatterMax
+			reasons = append(reasons, fmt.Sprintf("practices %s, matching the %s matter type", area, criteria.MatterType))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("no practice area matches the %s matter type", criteria.MatterType))
+		}
+	}
+
+	if len(criteria.Focus) > 0 {
+		factor.MaxPoints += expertiseFocusMax
+		var covered []string
+		for _, term := range criteria.Focus {
+			if _, ok := firstMatch(areas, term); ok {
+				covered = append(covered, term)
+			}
+		}
+		factor.Points += share(expertiseFocusMax, len(covered), len(criteria.Focus))
+		if len(covered) > 0 {
+			reasons = append(reasons, fmt.Sprintf("covers %d of %d focus areas (%s)", len(covered), len(criteria.Focus), strings.Join(covered, ", ")))
+		} else {
+			reasons = append(reasons, "covers none of the case's focus areas")
+		}
+	}
+
+	if factor.MaxPoints == 0 {
+		factor.Detail = "The case has no matter type or AI focus to match"
+	} else {
+		factor.Detail = capitalise(strings.Join(reasons, "; "))
+	}
+	return factor
+}
+
+func jurisdiction(criteria Criteria, profile *models.LawyerProfile) Factor {
+	factor := Factor{Name: FactorJurisdiction}
+	if len(criteria.Jurisdictions) == 0 {
+		factor.Detail = "The case lists no jurisdictions"
+		return factor
+	}
+	factor.MaxPoints = jurisdictionMax
+
+	admitted := make([]string, 0, len(profile.BarAdmissions.Data))
+	for _, admission := range profile.BarAdmissions.Data {
+		admitted = append(admitted, admission.Jurisdiction)
+	}
+	var covered []string
+	for _, wanted := range criteria.Jurisdictions {
+		if _, ok := firstMatch(profile.Jurisdictions.Data, wanted); ok {
+			covered = append(covered, wanted)
+		} else if _, ok := firstMatch(admitted, wanted); ok {
+			covered = append(covered, wanted)
+		}
+	}
+	factor.Points = share(jurisdictionMax, len(covered), len(criteria.Jurisdictions))
+	if len(covered) == 0 {
+		factor.Detail = fmt.Sprintf("Practices in none of %s", strings.Join(criteria.Jurisdictions, ", "))
+	} else {
+		factor.Detail = fmt.Sprintf("Practices in %d of %d case jurisdictions (%s)", len(covered), len(criteria.Jurisdictions), strings.Join(covered, ", "))
+	}
+	return factor
+}
+
+func similarCases(criteria Criteria, candidate *Candidate) Factor {
+	factor := Factor{Name: FactorSimilarCases}
+	if criteria.MatterType == "" {
+		factor.Detail = "The case has no matter type to compare"
+		return factor
+	}
+	factor.MaxPoints = similarCasesMax
+	factor.Points = share(similarCasesMax, min(candidate.SimilarCompleted, fullSimilarCases), fullSimilarCases)
+	factor.Detail = fmt.Sprintf("Completed %s on LexiFlow", plural(candidate.SimilarCompleted, criteria.MatterType+" case"))
+	return factor
+}
+
+func experience(profile *models.LawyerProfile) Factor {
+	years := profile.YearsExperience
+	return Factor{
+		Name:      FactorExperience,
+		Points:    share(experienceMax, min(years, fullExperienceYears), fullExperienceYears),
+		MaxPoints: experienceMax,
+		Detail:    fmt.Sprintf("%s in practice", plural(years, "year")),
+	}
+}
+
+// capacity rewards lawyers with room for another matter: fewer active
+// assignments, halved for those who report limited availability.
+func capacity(candidate *Candidate) Factor {
+	active := candidate.Statuses[models.AssignmentProposed] + candidate.Statuses[models.AssignmentAccepted]
+	points := share(capacityMax, max(fullCaseload-active, 0), fullCaseload)
+	detail := "no active cases"
+	if active > 0 {
+		detail = fmt.Sprintf("%s in progress", plural(active, "active case"))
+	}
+	if candidate.Profile.Availability == models.AvailabilityLimited {
+		points /= 2
+		detail += ", limited availability"
+	}
+	return Factor{Name: FactorCapacity, Points: points, MaxPoints: capacityMax, Detail: capitalise(detail)}
+}
+
+// trackRecord rewards lawyers who see their matters through: completed
+// assignments against those they withdrew from or were removed from. The
+// rate is smoothed so lawyers new to the platform start in the middle.
+func trackRecord(candidate *Candidate) Factor {
+	completed := candidate.Statuses[models.AssignmentCompleted]
+	ended := completed + candidate.Statuses[models.AssignmentWithdrawn] + candidate.Statuses[models.AssignmentRemoved]
+	factor := Factor{
+		Name:      FactorTrackRecord,
+		Points:    int(math.Round(trackRecordMax * float64(completed+1) / float64(ended+2))),
+		MaxPoints: trackRecordMax,
+	}
+	if ended == 0 {
+		factor.Detail = "No finished cases on LexiFlow yet"
+	} else {
+		factor.Detail = fmt.Sprintf("Completed %d of %s they took on", completed, plural(ended, "finished case"))
+	}
+	return factor
+}
+
+// firstMatch returns the first tag that matches phrase: equal to it or
+// sharing a significant word, so "privacy" matches "data privacy" while short
+// codes such as "ny" only match themselves.
+func firstMatch(tags []string, phrase string) (string, bool) {
+	words := significantWords(phrase)
+	for _, tag := range tags {
+		if tag == phrase {
+			return tag, true
+		}
+		for word := range significantWords(tag) {
+			if words[word] {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+var insignificantWords = map[string]bool{
+	"legal": true, "matter": true, "matters": true, "general": true, "services": true, "advice": true, "issues": true,
+}
+
+func significantWords(phrase string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(phrase, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if len([]rune(word)) >= 4 && !insignificantWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// splitTerms splits a free-text list on commas, semicolons, slashes and
+// ampersands into lower-case terms.
+func splitTerms(text string) []string {
+	terms := []string{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r == ',' || r == ';' || r == '/' || r == '&' || r == '|'
+	}) {
+		terms = appendUnique(terms, strings.TrimSpace(term))
+	}
+	return terms
+}
+
+func appendUnique(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		if addition == "" {
+			continue
+		}
+		seen := false
+		for _, value := range values {
+			if value == addition {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
+
+func share(maxPoints, part, whole int) int {
+	if whole <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(maxPoints) * float64(part) / float64(whole)))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func capitalise(text string) string {
+	if text == "" {
+		return text
+	}
+	runes := []rune(text)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
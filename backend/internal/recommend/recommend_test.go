@@ -0,0 +1,190 @@
+package recommend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"lexiflow/backend/internal/models"
+)
+
+func testProfile(name string, years int, areas, jurisdictions []string, admissions ...string) *models.LawyerProfile {
+	barAdmissions := make([]models.BarAdmission, 0, len(admissions))
+	for _, jurisdiction := range admissions {
+		barAdmissions = append(barAdmissions, models.BarAdmission{Jurisdiction: jurisdiction})
+	}
+	return &models.LawyerProfile{
+		UserID:          uuid.New(),
+		YearsExperience: years,
+		PracticeAreas:   datatypes.JSONType[[]string]{Data: areas},
+		Jurisdictions:   datatypes.JSONType[[]string]{Data: jurisdictions},
+		BarAdmissions:   datatypes.JSONType[[]models.BarAdmission]{Data: barAdmissions},
+		Availability:    models.AvailabilityOpen,
+		User:            models.User{CompanyName: name},
+	}
+}
+
+func factorPoints(recommendation Recommendation) map[string][2]int {
+	points := map[string][2]int{}
+	for _, factor := range recommendation.Factors {
+		points[factor.Name] = [2]int{factor.Points, factor.MaxPoints}
+	}
+	return points
+}
+
+func TestCriteriaFor(t *testing.T) {
+	got := CriteriaFor(&models.Case{
+		MatterType: " Employment ",
+		AIFocus:    "Wrongful termination; Discrimination / harassment",
+		Metadata: datatypes.JSONMap{
+			"jurisdictions": []any{"NY", "ca", 7},
+			"jurisdiction":  "ny, Federal",
+		},
+	})
+	want := Criteria{
+		MatterType:    "employment",
+		Focus:         []string{"wrongful termination", "discrimination", "harassment"},
+		Jurisdictions: []string{"ny", "ca", "federal"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestScore(t *testing.T) {
+	employment := Criteria{
+		MatterType:    "employment",
+		Focus:         []string{"wrongful termination", "discrimination"},
+		Jurisdictions: []string{"ny", "ca"},
+	}
+	limited := testProfile("Limited", 0, nil, nil)
+	limited.Availability = models.AvailabilityLimited
+
+	cases := []struct {
+		name      string
+		criteria  Criteria
+		candidate Candidate
+		score     int
+		factors   map[string][2]int
+	}{
+		{
+			name:     "strong match",
+			criteria: employment,
+			candidate: Candidate{
+				Profile:          testProfile("Strong", 20, []string{"employment law", "discrimination"}, []string{"ny"}, "ca"),
+				Statuses:         map[string]int{models.AssignmentAccepted: 2, models.AssignmentCompleted: 3, models.AssignmentWithdrawn: 1},
+				SimilarCompleted: 5,
+			},
+			score: 88,
+			factors: map[string][2]int{
+				FactorExpertise:    {33, 40},
+				FactorJurisdiction: {20, 20},
+				FactorSimilarCases: {10, 10},
+				FactorExperience:   {10, 10},
+				FactorCapacity:     {8, 10},
+				FactorTrackRecord:  {7, 10},
+			},
+		},
+		{
+			name:     "no match",
+			criteria: employment,
+			candidate: Candidate{
+				Profile:  testProfile("Weak", 3, []string{"tax"}, []string{"tx"}, "new york"),
+				Statuses: map[string]int{models.AssignmentProposed: 8, models.AssignmentRemoved: 2},
+			},
+			score: 5,
+			factors: map[string][2]int{
+				FactorExpertise:    {0, 40},
+				FactorJurisdiction: {0, 20},
+				FactorSimilarCases: {0, 10},
+				FactorExperience:   {2, 10},
+				FactorCapacity:     {0, 10},
+				FactorTrackRecord:  {3, 10},
+			},
+		},
+		{
+			name:      "criteria without anything to match",
+			criteria:  Criteria{},
+			candidate: Candidate{Profile: testProfile("New", 0, nil, nil)},
+			score:     50,
+			factors: map[string][2]int{
+				FactorExpertise:    {0, 0},
+				FactorJurisdiction: {0, 0},
+				FactorSimilarCases: {0, 0},
+				FactorExperience:   {0, 10},
+				FactorCapacity:     {10, 10},
+				FactorTrackRecord:  {5, 10},
+			},
+		},
+		{
+			name:      "limited availability halves capacity",
+			criteria:  Criteria{},
+			candidate: Candidate{Profile: limited},
+			score:     33,
+			factors: map[string][2]int{
+				FactorExpertise:    {0, 0},
+				FactorJurisdiction: {0, 0},
+				FactorSimilarCases: {0, 0},
+				FactorExperience:   {0, 10},
+				FactorCapacity:     {5, 10},
+				FactorTrackRecord:  {5, 10},
+			},
+		},
+	}
+	for _, tc := range cases {
+		got := Score(tc.criteria, &tc.candidate)
+		if got.LawyerID != tc.candidate.Profile.UserID {
+			t.Errorf("%s: lawyer %s, want %s", tc.name, got.LawyerID, tc.candidate.Profile.UserID)
+		}
+		if got.Score != tc.score {
+			t.Errorf("%s: score %d, want %d", tc.name, got.Score, tc.score)
+		}
+		if points := factorPoints(got); !reflect.DeepEqual(points, tc.factors) {
+			t.Errorf("%s: factors %v, want %v", tc.name, points, tc.factors)
+		}
+	}
+}
+
+func TestRankBreaksTiesByExperienceThenName(t *testing.T) {
+	alpha := Candidate{Profile: testProfile("Alpha", 15, nil, nil)}
+	beta := Candidate{Profile: testProfile("Beta", 20, nil, nil)}
+	aardvark := Candidate{Profile: testProfile("Aardvark", 15, nil, nil)}
+	top := Candidate{Profile: testProfile("Top", 20, nil, nil), Statuses: map[string]int{models.AssignmentCompleted: 8}}
+
+	ranked := Rank(Criteria{}, []Candidate{alpha, beta, top, aardvark})
+
+	want := []uuid.UUID{top.Profile.UserID, beta.Profile.UserID, aardvark.Profile.UserID, alpha.Profile.UserID}
+	got := make([]uuid.UUID, 0, len(ranked))
+	for _, recommendation := range ranked {
+		got = append(got, recommendation.LawyerID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got order %v, want %v", got, want)
+	}
+	if ranked[1].Score != ranked[2].Score || ranked[2].Score != ranked[3].Score {
+		t.Fatalf("expected a three-way tie, got scores %d, %d, %d", ranked[1].Score, ranked[2].Score, ranked[3].Score)
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	cases := []struct {
+		tags   []string
+		phrase string
+		want   string
+		ok     bool
+	}{
+		{[]string{"tax", "data privacy"}, "privacy", "data privacy", true},
+		{[]string{"employment law"}, "employment", "employment law", true},
+		{[]string{"ny"}, "ny", "ny", true},
+		{[]string{"nyc", "new york"}, "ny", "", false},
+		{[]string{"general legal services"}, "legal matters", "", false},
+		{nil, "privacy", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := firstMatch(tc.tags, tc.phrase)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("firstMatch(%v, %q) = %q, %v; want %q, %v", tc.tags, tc.phrase, got, ok, tc.want, tc.ok)
+		}
+	}
+}
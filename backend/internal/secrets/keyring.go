@@ -0,0 +1,72 @@
+// Package secrets derives purpose-specific keys from the application secret
+// and uses them to encrypt values that must be recoverable at rest.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrCiphertextInvalid = errors.New("ciphertext invalid")
+
+// Keyring derives independent keys for each purpose so that compromising one
+// use of the application secret does not expose the others.
+type Keyring struct {
+	master []byte
+}
+
+func NewKeyring(secret string) *Keyring {
+	return &Keyring{master: []byte(secret)}
+}
+
+// Key returns the 32-byte key for purpose.
+func (k *Keyring) Key(purpose string) []byte {
+	mac := hmac.New(sha256.New, k.master)
+	mac.Write([]byte("lexiflow:" + purpose))
+	return mac.Sum(nil)
+}
+
+// Seal encrypts plaintext with AES-256-GCM under the purpose key and returns
+// the nonce-prefixed ciphertext as URL-safe base64.
+func (k *Keyring) Seal(purpose string, plaintext []byte) (string, error) {
+	aead, err := k.aead(purpose)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(purpose))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open reverses Seal.
+func (k *Keyring) Open(purpose, ciphertext string) ([]byte, error) {
+	aead, err := k.aead(purpose)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrCiphertextInvalid
+	}
+	plaintext, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], []byte(purpose))
+	if err != nil {
+		return nil, ErrCiphertextInvalid
+	}
+	return plaintext, nil
+}
+
+func (k *Keyring) aead(purpose string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k.Key(purpose))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,188 @@
+// Package throttle tracks authentication failures in the database and turns
+// them into exponential backoff and temporary lockouts.
+package throttle
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lexiflow/backend/internal/models"
+)
+
+// Rule describes how failures for one kind of subject are penalised. The first
+// FreeAttempts failures are not delayed; after that each failure doubles the
+// wait starting at BaseDelay, up to MaxDelay. Reaching LockoutAfter failures
+// blocks the subject for LockoutFor. Counters reset once no failure has been
+// seen for ResetAfter.
+type Rule struct {
+	Kind         string
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockoutAfter int
+	LockoutFor   time.Duration
+	ResetAfter   time.Duration
+}
+
+// Subject pairs a rule with the key it is tracked under, such as a normalised
+// email address or a client IP.
+type Subject struct {
+	Rule Rule
+	Key  string
+}
+
+// Result reports the outcome of recording a failure.
+type Result struct {
+	RetryAfter time.Duration
+	// LockedOut lists subjects that crossed their lockout threshold with
+	// this failure.
+	LockedOut []Subject
+}
+
+type Limiter struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Limiter {
+	return &Limiter{db: db}
+}
+
+// Check returns how long the caller must wait before another attempt in scope
+// is allowed. Zero means the attempt may proceed.
+func (l *Limiter) Check(scope string, subjects ...Subject) (time.Duration, error) {
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, subject := range subjects {
+		if subject.Key == "" {
+			continue
+		}
+		var row models.AuthThrottle
+		err := l.db.Where("scope = ? AND kind = ? AND key = ?", scope, subject.Rule.Kind, subject.Key).First(&row).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return 0, err
+		}
+		if row.BlockedUntil != nil && row.BlockedUntil.After(now) {
+			if remaining := row.BlockedUntil.Sub(now); remaining > wait {
+				wait = remaining
+			}
+		}
+	}
+	return wait, nil
+}
+
+// Fail records a failed attempt for every subject and returns the resulting
+// backoff. Each row is updated under a row lock so concurrent replicas never
+// lose increments.
+func (l *Limiter) Fail(scope string, subjects ...Subject) (Result, error) {
+	now := time.Now().UTC()
+	var result Result
+
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		for _, subject := range subjects {
+			if subject.Key == "" {
+				continue
+			}
+
+			// Create the row if it is missing, then lock and load it into a
+			// fresh value: row must not carry the id the insert generated, or
+			// the lookup would miss an existing row.
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.AuthThrottle{Scope: scope, Kind: subject.Rule.Kind, Key: subject.Key}).Error; err != nil {
+				return err
+			}
+			var row models.AuthThrottle
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("scope = ? AND kind = ? AND key = ?", scope, subject.Rule.Kind, subject.Key).
+				First(&row).Error; err != nil {
+				return err
+			}
+
+			delay, lockedOut := subject.Rule.record(&row, now)
+			if lockedOut {
+				result.LockedOut = append(result.LockedOut, subject)
+			}
+			if delay > result.RetryAfter {
+				result.RetryAfter = delay
+			}
+
+			if err := tx.Save(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// Reset clears the counters for the given subjects after a success.
+func (l *Limiter) Reset(scope string, subjects ...Subject) error {
+	for _, subject := range subjects {
+		if subject.Key == "" {
+			continue
+		}
+		if err := l.db.Where("scope = ? AND kind = ? AND key = ?", scope, subject.Rule.Kind, subject.Key).
+			Delete(&models.AuthThrottle{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune removes counters that have been idle long enough to have reset.
+func (l *Limiter) Prune(olderThan time.Duration) error {
+	cutoff := time.Now().UTC().Add(-olderThan)
+	return l.db.Where("last_failure_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", cutoff, time.Now().UTC()).
+		Delete(&models.AuthThrottle{}).Error
+}
+
+// record counts one more failure on row at now, first resetting counters that
+// have been idle for ResetAfter, and blocks the subject for the delay it has
+// earned. lockedOut reports that this failure crossed LockoutAfter.
+func (r Rule) record(row *models.AuthThrottle, now time.Time) (delay time.Duration, lockedOut bool) {
+	if row.LastFailureAt != nil && now.Sub(*row.LastFailureAt) > r.ResetAfter &&
+		(row.BlockedUntil == nil || row.BlockedUntil.Before(now)) {
+		row.Failures = 0
+		row.LockedOut = false
+	}
+
+	row.Failures++
+	row.LastFailureAt = &now
+
+	delay = r.delayFor(row.Failures)
+	if r.LockoutAfter > 0 && row.Failures >= r.LockoutAfter {
+		delay = r.LockoutFor
+		if !row.LockedOut {
+			row.LockedOut = true
+			lockedOut = true
+		}
+	}
+	if delay > 0 {
+		until := now.Add(delay)
+		row.BlockedUntil = &until
+	}
+	return delay, lockedOut
+}
+
+func (r Rule) delayFor(failures int) time.Duration {
+	over := failures - r.FreeAttempts
+	if over <= 0 || r.BaseDelay <= 0 {
+		return 0
+	}
+	delay := r.BaseDelay
+	for i := 1; i < over; i++ {
+		delay *= 2
+		if r.MaxDelay > 0 && delay >= r.MaxDelay {
+			return r.MaxDelay
+		}
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		return r.MaxDelay
+	}
+	return delay
+}
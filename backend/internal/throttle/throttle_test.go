@@ -0,0 +1,143 @@
+package throttle
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"lexiflow/backend/internal/models"
+)
+
+var testRule = Rule{
+	Kind:         "account",
+	FreeAttempts: 2,
+	BaseDelay:    time.Second,
+	MaxDelay:     8 * time.Second,
+	LockoutAfter: 6,
+	LockoutFor:   time.Hour,
+	ResetAfter:   24 * time.Hour,
+}
+
+func TestDelayFor(t *testing.T) {
+	want := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	for i, expected := range want {
+		if got := testRule.delayFor(i + 1); got != expected {
+			t.Errorf("delayFor(%d) = %v, want %v", i+1, got, expected)
+		}
+	}
+}
+
+func TestRecordBacksOffThenLocksOut(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var row models.AuthThrottle
+
+	for failure := 1; failure <= testRule.LockoutAfter+1; failure++ {
+		at := now.Add(time.Duration(failure) * time.Minute)
+		delay, lockedOut := testRule.record(&row, at)
+
+		if row.Failures != failure {
+			t.Fatalf("failure %d: counter is %d", failure, row.Failures)
+		}
+		switch {
+		case failure < testRule.LockoutAfter:
+			if delay != testRule.delayFor(failure) || lockedOut {
+				t.Fatalf("failure %d: got delay %v, lockedOut %v", failure, delay, lockedOut)
+			}
+		case failure == testRule.LockoutAfter:
+			if delay != testRule.LockoutFor || !lockedOut {
+				t.Fatalf("failure %d: got delay %v, lockedOut %v; want lockout", failure, delay, lockedOut)
+			}
+		default:
+			if delay != testRule.LockoutFor || lockedOut {
+				t.Fatalf("failure %d: got delay %v, lockedOut %v; want lockout reported once", failure, delay, lockedOut)
+			}
+		}
+		if delay > 0 && (row.BlockedUntil == nil || !row.BlockedUntil.Equal(at.Add(delay))) {
+			t.Fatalf("failure %d: blocked until %v, want %v", failure, row.BlockedUntil, at.Add(delay))
+		}
+	}
+}
+
+func TestRecordResetsAfterIdle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var row models.AuthThrottle
+	for i := 0; i < 4; i++ {
+		testRule.record(&row, now)
+	}
+
+	delay, _ := testRule.record(&row, now.Add(testRule.ResetAfter+time.Hour))
+	if row.Failures != 1 || delay != 0 {
+		t.Fatalf("after idle period: failures %d, delay %v; want 1, 0", row.Failures, delay)
+	}
+}
+
+func TestRecordKeepsCountersWhileLockedOut(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rule := testRule
+	rule.LockoutFor = 2 * rule.ResetAfter
+	var row models.AuthThrottle
+	for i := 0; i < rule.LockoutAfter; i++ {
+		rule.record(&row, now)
+	}
+
+	delay, _ := rule.record(&row, now.Add(rule.ResetAfter+time.Hour))
+	if row.Failures != rule.LockoutAfter+1 || delay != rule.LockoutFor {
+		t.Fatalf("during lockout: failures %d, delay %v", row.Failures, delay)
+	}
+}
+
+// TestLimiterFailLocksOut runs against the PostgreSQL database named by
+// TEST_DATABASE_URL and is skipped without one.
+func TestLimiterFailLocksOut(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.AuthThrottle{}); err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := New(db)
+	scope := "test"
+	subject := Subject{Rule: testRule, Key: uuid.NewString()}
+	t.Cleanup(func() { _ = limiter.Reset(scope, subject) })
+
+	var lockouts int
+	for failure := 1; failure <= testRule.LockoutAfter; failure++ {
+		result, err := limiter.Fail(scope, subject)
+		if err != nil {
+			t.Fatalf("failure %d: %v", failure, err)
+		}
+		lockouts += len(result.LockedOut)
+		if failure == testRule.FreeAttempts+1 && result.RetryAfter != testRule.BaseDelay {
+			t.Fatalf("failure %d: retry after %v, want %v", failure, result.RetryAfter, testRule.BaseDelay)
+		}
+	}
+	if lockouts != 1 {
+		t.Fatalf("lockout reported %d times, want once", lockouts)
+	}
+
+	var row models.AuthThrottle
+	if err := db.Where("scope = ? AND kind = ? AND key = ?", scope, testRule.Kind, subject.Key).First(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if row.Failures != testRule.LockoutAfter || !row.LockedOut {
+		t.Fatalf("stored failures %d, lockedOut %v", row.Failures, row.LockedOut)
+	}
+
+	wait, err := limiter.Check(scope, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait <= testRule.LockoutFor-time.Minute {
+		t.Fatalf("check allows an attempt after %v, want about %v", wait, testRule.LockoutFor)
+	}
+}
@@ -0,0 +1,112 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of RFC 6238 appendix B, "12345678901234567890",
+// in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC lists eight-digit codes; six-digit codes are their last six digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeMatchesRFC6238(t *testing.T) {
+	for _, vector := range rfcVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(vector.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != vector.code {
+			t.Errorf("code at %d = %s, want %s", vector.unix, code, vector.code)
+		}
+	}
+}
+
+func TestCodeAcceptsLowerCaseSecret(t *testing.T) {
+	code, err := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0)))
+	if err != nil || code != "287082" {
+		t.Fatalf("got %q, %v", code, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("expected an error for an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	step, ok := Validate(rfcSecret, "050471", now, 1)
+	if !ok || step != current {
+		t.Fatalf("current code: step %d, ok %v", step, ok)
+	}
+
+	previous, _ := Code(rfcSecret, current-1)
+	if step, ok := Validate(rfcSecret, previous, now, 1); !ok || step != current-1 {
+		t.Fatalf("previous code within skew: step %d, ok %v", step, ok)
+	}
+	if _, ok := Validate(rfcSecret, previous, now, 0); ok {
+		t.Fatal("previous code accepted without skew")
+	}
+
+	stale, _ := Code(rfcSecret, current-2)
+	if _, ok := Validate(rfcSecret, stale, now, 1); ok {
+		t.Fatal("code outside the skew window accepted")
+	}
+
+	for _, code := range []string{"", "05047", "0504711", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now, 1); ok {
+			t.Errorf("malformed code %q accepted", code)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " 050 471 ", now, 1); !ok {
+		t.Error("code with spaces rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := GenerateSecret()
+	if first == second {
+		t.Fatal("secrets repeat")
+	}
+	key, err := encoding.DecodeString(first)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("secret decodes to %d bytes, %v", len(key), err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("LexiFlow", "legal@example.com", rfcSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/LexiFlow:legal@example.com" {
+		t.Fatalf("unexpected URI %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != rfcSecret || query.Get("issuer") != "LexiFlow" ||
+		query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Fatalf("unexpected parameters %v", query)
+	}
+}
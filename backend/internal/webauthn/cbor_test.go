@@ -0,0 +1,146 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// cborPairs is a map whose entries keep their order when encoded.
+type cborPairs []any
+
+// encodeCBOR encodes the subset of CBOR the tests build by hand.
+func encodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []any:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborPairs:
+		out := cborHead(5, uint64(len(v)/2))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	}
+	panic("encodeCBOR: unsupported value")
+}
+
+func cborHead(major byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{major<<5 | byte(argument)}
+	case argument <= math.MaxUint8:
+		return []byte{major<<5 | 24, byte(argument)}
+	case argument <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(argument))
+	case argument <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(argument))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, argument)
+}
+
+// The encodings below are taken from RFC 8949 appendix A.
+func TestDecodeCBOR(t *testing.T) {
+	cases := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"20", int64(-1)},
+		{"3903e7", int64(-1000)},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"6449455446", "IETF"},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"fb3ff199999999999a", 1.1},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+	}
+	for _, tc := range cases {
+		data, _ := hex.DecodeString(tc.hex)
+		got, rest, err := decodeCBOR(data)
+		if err != nil {
+			t.Errorf("%s: %v", tc.hex, err)
+			continue
+		}
+		if len(rest) != 0 {
+			t.Errorf("%s: %d trailing bytes", tc.hex, len(rest))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %#v, want %#v", tc.hex, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeCBORReturnsTrailingBytes(t *testing.T) {
+	got, rest, err := decodeCBOR([]byte{0x01, 0x02, 0x03})
+	if err != nil || got != int64(1) || !bytes.Equal(rest, []byte{0x02, 0x03}) {
+		t.Fatalf("got %v, rest %x, err %v", got, rest, err)
+	}
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+	deep := bytes.Repeat([]byte{0x81}, maxCBORDepth+2)
+	deep = append(deep, 0x00)
+
+	cases := map[string]string{
+		"empty":                 "",
+		"truncated argument":    "19 03",
+		"truncated byte string": "44 0102",
+		"truncated array":       "83 0102",
+		"huge array length":     "9b ffffffffffffffff",
+		"integer overflow":      "1b ffffffffffffffff",
+		"indefinite length":     "5f 41 01 ff",
+		"byte string map key":   "a1 41 01 02",
+		"unknown simple value":  "f0",
+		"tag":                   "c0 00",
+		"too deep":              hex.EncodeToString(deep),
+	}
+	for name, encoded := range cases {
+		data, _ := hex.DecodeString(string(bytes.ReplaceAll([]byte(encoded), []byte(" "), nil)))
+		if _, _, err := decodeCBOR(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+}
+
+func TestEncodeCBORRoundTrip(t *testing.T) {
+	value := cborPairs{1, 2, 3, -7, -1, 1, -2, []byte("x"), "fmt", "none"}
+	got, rest, err := decodeCBOR(encodeCBOR(value))
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("rest %x, err %v", rest, err)
+	}
+	want := map[any]any{
+		int64(1): int64(2), int64(3): int64(-7), int64(-1): int64(1), int64(-2): []byte("x"), "fmt": "none",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v", got)
+	}
+}
@@ -0,0 +1,449 @@
+// Package webauthn implements the relying-party checks for WebAuthn passkey
+// registration and assertion. Attestation statements are not verified: the
+// server requests "none" attestation and trusts a credential because the
+// signed-in user registered it, not because of the authenticator's make.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// Timeout is how long the browser is asked to wait for the user.
+	Timeout = 5 * time.Minute
+
+	algES256 = -7
+	algRS256 = -257
+
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagBackupElig   = 0x08
+	flagBackedUp     = 0x10
+	flagAttested     = 0x40
+
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+)
+
+var (
+	ErrVerification = errors.New("webauthn: verification failed")
+	// ErrCloned reports a signature counter that went backwards, which means
+	// the authenticator's key may have been copied.
+	ErrCloned = errors.New("webauthn: signature counter regressed")
+)
+
+// Config identifies the relying party. RPID is the registrable domain
+// credentials are scoped to; Origins lists the exact origins the front-end
+// is served from.
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+}
+
+type RelyingParty struct {
+	cfg Config
+}
+
+func New(cfg Config) *RelyingParty {
+	return &RelyingParty{cfg: cfg}
+}
+
+// CredentialDescriptor names an existing credential in options.
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// CreationOptions is the JSON form of PublicKeyCredentialCreationOptions.
+// Binary values are base64url strings the client decodes before calling
+// navigator.credentials.create.
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []struct {
+		Type string `json:"type"`
+		Alg  int    `json:"alg"`
+	} `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey        string `json:"residentKey"`
+		RequireResidentKey bool   `json:"requireResidentKey"`
+		UserVerification   string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// RequestOptions is the JSON form of PublicKeyCredentialRequestOptions. An
+// empty AllowCredentials lets the user pick any discoverable passkey.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AttestationResponse is a registration result as serialised by the client,
+// with binary fields base64url-encoded.
+type AttestationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is an authentication result as serialised by the client.
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a verified newly registered credential.
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte
+	SignCount      uint32
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// Assertion is the verified outcome of an authentication.
+type Assertion struct {
+	SignCount uint32
+	BackedUp  bool
+}
+
+// NewChallenge returns 32 random bytes as base64url.
+func NewChallenge() (string, error) {
+	buff := make([]byte, 32)
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buff), nil
+}
+
+// CreationOptions builds registration options for a user. Passkeys are
+// requested as discoverable credentials with user verification so a later
+// sign-in needs neither a username nor a password.
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	var opts CreationOptions
+	opts.Challenge = challenge
+	opts.RP.ID = rp.cfg.RPID
+	opts.RP.Name = rp.cfg.RPName
+	opts.User.ID = base64.RawURLEncoding.EncodeToString(userHandle)
+	opts.User.Name = name
+	opts.User.DisplayName = displayName
+	for _, alg := range []int{algES256, algRS256} {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, struct {
+			Type string `json:"type"`
+			Alg  int    `json:"alg"`
+		}{Type: "public-key", Alg: alg})
+	}
+	opts.Timeout = Timeout.Milliseconds()
+	opts.ExcludeCredentials = exclude
+	if opts.ExcludeCredentials == nil {
+		opts.ExcludeCredentials = []CredentialDescriptor{}
+	}
+	opts.AuthenticatorSelection.ResidentKey = "required"
+	opts.AuthenticatorSelection.RequireResidentKey = true
+	opts.AuthenticatorSelection.UserVerification = "required"
+	opts.Attestation = "none"
+	return opts
+}
+
+// RequestOptions builds authentication options.
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: "required",
+	}
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge extracts the challenge the client signed, so the caller can look
+// up the pending ceremony it belongs to before verifying.
+func Challenge(clientDataJSON string) (string, error) {
+	raw, err := decodeBase64(clientDataJSON)
+	if err != nil {
+		return "", fmt.Errorf("%w: clientDataJSON encoding", ErrVerification)
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Challenge == "" {
+		return "", fmt.Errorf("%w: clientDataJSON", ErrVerification)
+	}
+	return data.Challenge, nil
+}
+
+// VerifyRegistration checks a registration response against the expected
+// challenge and returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(resp *AttestationResponse, challenge string) (*Credential, error) {
+	if _, err := rp.verifyClientData(resp.Response.ClientDataJSON, typeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawObject, err := decodeBase64(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestationObject encoding", ErrVerification)
+	}
+	decoded, _, err := decodeCBOR(rawObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	object, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: attestationObject", ErrVerification)
+	}
+	authData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing authData", ErrVerification)
+	}
+
+	parsed, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.flags&flagAttested == 0 {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrVerification)
+	}
+
+	rest := parsed.rest
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("%w: attested credential data truncated", ErrVerification)
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+		return nil, fmt.Errorf("%w: credential id", ErrVerification)
+	}
+	credentialID := append([]byte(nil), rest[:idLength]...)
+	rest = rest[idLength:]
+
+	if rawID, err := decodeBase64(resp.RawID); err != nil || !bytes.Equal(rawID, credentialID) {
+		return nil, fmt.Errorf("%w: rawId does not match authenticator data", ErrVerification)
+	}
+
+	keyItem, remaining, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: credential public key: %v", ErrVerification, err)
+	}
+	if _, err := parseCOSEKey(keyItem); err != nil {
+		return nil, err
+	}
+	publicKey := append([]byte(nil), rest[:len(rest)-len(remaining)]...)
+
+	return &Credential{
+		ID:             credentialID,
+		PublicKey:      publicKey,
+		SignCount:      parsed.signCount,
+		BackupEligible: parsed.flags&flagBackupElig != 0,
+		BackedUp:       parsed.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// VerifyAssertion checks an authentication response for a stored credential.
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge string, publicKey []byte, storedCount uint32) (*Assertion, error) {
+	clientDataRaw, err := rp.verifyClientData(resp.Response.ClientDataJSON, typeGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData, err := decodeBase64(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: authenticatorData encoding", ErrVerification)
+	}
+	parsed, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := decodeBase64(resp.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrVerification)
+	}
+
+	keyItem, _, err := decodeCBOR(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: stored public key: %v", ErrVerification, err)
+	}
+	key, err := parseCOSEKey(keyItem)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataRaw)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return nil, fmt.Errorf("%w: bad signature", ErrVerification)
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return nil, fmt.Errorf("%w: bad signature", ErrVerification)
+		}
+	}
+
+	// Authenticators that do not count always report zero.
+	if (parsed.signCount != 0 || storedCount != 0) && parsed.signCount <= storedCount {
+		return nil, ErrCloned
+	}
+
+	return &Assertion{SignCount: parsed.signCount, BackedUp: parsed.flags&flagBackedUp != 0}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: clientDataJSON encoding", ErrVerification)
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%w: clientDataJSON", ErrVerification)
+	}
+
+	switch {
+	case data.Type != ceremony:
+		return nil, fmt.Errorf("%w: unexpected ceremony %q", ErrVerification, data.Type)
+	case data.Challenge != challenge:
+		return nil, fmt.Errorf("%w: challenge mismatch", ErrVerification)
+	case data.CrossOrigin:
+		return nil, fmt.Errorf("%w: cross-origin ceremony", ErrVerification)
+	case !rp.allowedOrigin(data.Origin):
+		return nil, fmt.Errorf("%w: unexpected origin %q", ErrVerification, data.Origin)
+	}
+	return raw, nil
+}
+
+func (rp *RelyingParty) allowedOrigin(origin string) bool {
+	for _, allowed := range rp.cfg.Origins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+type authenticatorData struct {
+	flags     byte
+	signCount uint32
+	rest      []byte
+}
+
+// parseAuthenticatorData checks the RP ID hash and requires both user
+// presence and user verification, which is what lets a passkey stand in for
+// a password plus second factor.
+func (rp *RelyingParty) parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data truncated", ErrVerification)
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if !bytes.Equal(data[:32], rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: RP ID mismatch", ErrVerification)
+	}
+
+	flags := data[32]
+	if flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("%w: user not present", ErrVerification)
+	}
+	if flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user not verified", ErrVerification)
+	}
+
+	return &authenticatorData{
+		flags:     flags,
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+		rest:      data[37:],
+	}, nil
+}
+
+// parseCOSEKey converts a COSE_Key to a public key for the supported
+// algorithms: ES256 on P-256 and RS256.
+func parseCOSEKey(item any) (crypto.PublicKey, error) {
+	key, ok := item.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is not a COSE map", ErrVerification)
+	}
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == algES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("%w: malformed EC2 key", ErrVerification)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("%w: EC point not on curve", ErrVerification)
+		}
+		return pub, nil
+	case kty == 3 && alg == algRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("%w: malformed RSA key", ErrVerification)
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported key type %d / algorithm %d", ErrVerification, kty, alg)
+}
+
+// decodeBase64 accepts base64url with or without padding, which is what
+// browser helpers commonly produce.
+func decodeBase64(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
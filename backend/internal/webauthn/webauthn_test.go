@@ -0,0 +1,260 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+const (
+	testRPID      = "app.lexiflow.test"
+	testOrigin    = "https://app.lexiflow.test"
+	testChallenge = "Y2hhbGxlbmdl"
+)
+
+var testRP = New(Config{RPID: testRPID, RPName: "LexiFlow", Origins: []string{testOrigin + "/"}})
+
+// testAuthenticator plays the part of a passkey provider holding one key.
+type testAuthenticator struct {
+	credentialID []byte
+	ecKey        *ecdsa.PrivateKey
+	rsaKey       *rsa.PrivateKey
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{credentialID: []byte("credential-1"), ecKey: key}
+}
+
+func newRSAAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{credentialID: []byte("credential-2"), rsaKey: key}
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	if a.rsaKey != nil {
+		return encodeCBOR(cborPairs{
+			1, 3,
+			3, algRS256,
+			-1, a.rsaKey.N.Bytes(),
+			-2, big.NewInt(int64(a.rsaKey.E)).Bytes(),
+		})
+	}
+	return encodeCBOR(cborPairs{
+		1, 2,
+		3, algES256,
+		-1, 1,
+		-2, a.ecKey.X.FillBytes(make([]byte, 32)),
+		-3, a.ecKey.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+// authData builds authenticator data for rpID; attested adds the
+// credential ID and public key a registration carries.
+func (a *testAuthenticator) authData(rpID string, flags byte, signCount uint32, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(ceremony, challenge, origin string) []byte {
+	raw, _ := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	return raw
+}
+
+func (a *testAuthenticator) register(authData, clientData []byte) *AttestationResponse {
+	var resp AttestationResponse
+	resp.ID = b64(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = b64(clientData)
+	resp.Response.AttestationObject = b64(encodeCBOR(cborPairs{
+		"fmt", "none",
+		"attStmt", cborPairs{},
+		"authData", authData,
+	}))
+	return &resp
+}
+
+func (a *testAuthenticator) assert(t *testing.T, authData, clientData []byte) *AssertionResponse {
+	t.Helper()
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	var signature []byte
+	var err error
+	if a.rsaKey != nil {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, a.rsaKey, crypto.SHA256, digest[:])
+	} else {
+		signature, err = ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp AssertionResponse
+	resp.ID = b64(a.credentialID)
+	resp.RawID = resp.ID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = b64(clientData)
+	resp.Response.AuthenticatorData = b64(authData)
+	resp.Response.Signature = b64(signature)
+	return &resp
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+const verifiedFlags = flagUserPresent | flagUserVerified
+
+func TestVerifyRegistration(t *testing.T) {
+	auth := newTestAuthenticator(t)
+	authData := auth.authData(testRPID, verifiedFlags|flagAttested|flagBackupElig, 0, true)
+	resp := auth.register(authData, clientDataJSON(typeCreate, testChallenge, testOrigin))
+
+	credential, err := testRP.VerifyRegistration(resp, testChallenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(credential.ID) != string(auth.credentialID) || string(credential.PublicKey) != string(auth.coseKey()) {
+		t.Fatalf("unexpected credential %+v", credential)
+	}
+	if !credential.BackupEligible || credential.BackedUp {
+		t.Fatalf("backup flags %v/%v", credential.BackupEligible, credential.BackedUp)
+	}
+}
+
+func TestVerifyRegistrationRejectsInvalidResponses(t *testing.T) {
+	auth := newTestAuthenticator(t)
+	goodClientData := clientDataJSON(typeCreate, testChallenge, testOrigin)
+	goodAuthData := auth.authData(testRPID, verifiedFlags|flagAttested, 0, true)
+
+	cases := map[string]*AttestationResponse{
+		"other RP ID":        auth.register(auth.authData("evil.test", verifiedFlags|flagAttested, 0, true), goodClientData),
+		"user not present":   auth.register(auth.authData(testRPID, flagUserVerified|flagAttested, 0, true), goodClientData),
+		"user not verified":  auth.register(auth.authData(testRPID, flagUserPresent|flagAttested, 0, true), goodClientData),
+		"no credential data": auth.register(auth.authData(testRPID, verifiedFlags, 0, false), goodClientData),
+		"wrong challenge":    auth.register(goodAuthData, clientDataJSON(typeCreate, "other", testOrigin)),
+		"wrong origin":       auth.register(goodAuthData, clientDataJSON(typeCreate, testChallenge, "https://evil.test")),
+		"assertion type":     auth.register(goodAuthData, clientDataJSON(typeGet, testChallenge, testOrigin)),
+		"truncated authData": auth.register(goodAuthData[:40], goodClientData),
+	}
+
+	mismatchedID := auth.register(goodAuthData, goodClientData)
+	mismatchedID.RawID = b64([]byte("another-credential"))
+	cases["rawId mismatch"] = mismatchedID
+
+	unsupported := *auth
+	unsupported.rsaKey, _ = rsa.GenerateKey(rand.Reader, 1024)
+	cases["short RSA key"] = unsupported.register(unsupported.authData(testRPID, verifiedFlags|flagAttested, 0, true), goodClientData)
+
+	for name, resp := range cases {
+		if _, err := testRP.VerifyRegistration(resp, testChallenge); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: got %v, want ErrVerification", name, err)
+		}
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	for name, auth := range map[string]*testAuthenticator{"ES256": newTestAuthenticator(t), "RS256": newRSAAuthenticator(t)} {
+		authData := auth.authData(testRPID, verifiedFlags|flagBackedUp, 8, false)
+		resp := auth.assert(t, authData, clientDataJSON(typeGet, testChallenge, testOrigin))
+
+		assertion, err := testRP.VerifyAssertion(resp, testChallenge, auth.coseKey(), 7)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if assertion.SignCount != 8 || !assertion.BackedUp {
+			t.Fatalf("%s: unexpected assertion %+v", name, assertion)
+		}
+	}
+}
+
+func TestVerifyAssertionRejectsInvalidResponses(t *testing.T) {
+	auth := newTestAuthenticator(t)
+	goodClientData := clientDataJSON(typeGet, testChallenge, testOrigin)
+	goodAuthData := auth.authData(testRPID, verifiedFlags, 1, false)
+
+	cases := map[string]*AssertionResponse{
+		"other RP ID":       auth.assert(t, auth.authData("evil.test", verifiedFlags, 1, false), goodClientData),
+		"user not present":  auth.assert(t, auth.authData(testRPID, flagUserVerified, 1, false), goodClientData),
+		"user not verified": auth.assert(t, auth.authData(testRPID, flagUserPresent, 1, false), goodClientData),
+		"wrong challenge":   auth.assert(t, goodAuthData, clientDataJSON(typeGet, "other", testOrigin)),
+		"wrong origin":      auth.assert(t, goodAuthData, clientDataJSON(typeGet, testChallenge, "https://evil.test")),
+		"creation type":     auth.assert(t, goodAuthData, clientDataJSON(typeCreate, testChallenge, testOrigin)),
+	}
+
+	crossOrigin, _ := json.Marshal(clientData{Type: typeGet, Challenge: testChallenge, Origin: testOrigin, CrossOrigin: true})
+	cases["cross-origin"] = auth.assert(t, goodAuthData, crossOrigin)
+
+	tampered := auth.assert(t, goodAuthData, goodClientData)
+	tampered.Response.AuthenticatorData = b64(auth.authData(testRPID, verifiedFlags, 2, false))
+	cases["tampered authenticator data"] = tampered
+
+	otherKey := newTestAuthenticator(t)
+	cases["signed by another key"] = otherKey.assert(t, goodAuthData, goodClientData)
+
+	for name, resp := range cases {
+		if _, err := testRP.VerifyAssertion(resp, testChallenge, auth.coseKey(), 0); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: got %v, want ErrVerification", name, err)
+		}
+	}
+}
+
+func TestVerifyAssertionDetectsCounterRegression(t *testing.T) {
+	auth := newTestAuthenticator(t)
+	clientData := clientDataJSON(typeGet, testChallenge, testOrigin)
+
+	for _, tc := range []struct {
+		name           string
+		stored, signed uint32
+		want           error
+	}{
+		{"counter advanced", 4, 5, nil},
+		{"counter repeated", 5, 5, ErrCloned},
+		{"counter went back", 5, 3, ErrCloned},
+		{"counter reset to zero", 5, 0, ErrCloned},
+		{"authenticator without counter", 0, 0, nil},
+	} {
+		resp := auth.assert(t, auth.authData(testRPID, verifiedFlags, tc.signed, false), clientData)
+		_, err := testRP.VerifyAssertion(resp, testChallenge, auth.coseKey(), tc.stored)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	challenge, err := Challenge(b64(clientDataJSON(typeGet, testChallenge, testOrigin)))
+	if err != nil || challenge != testChallenge {
+		t.Fatalf("got %q, %v", challenge, err)
+	}
+	if _, err := Challenge(b64([]byte(`{"type":"webauthn.get"}`))); !errors.Is(err, ErrVerification) {
+		t.Fatalf("missing challenge: got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package workflow defines the lifecycle of a case: the statuses it moves
+// through and which account roles may move it from one to the next. Matter
+// types may be given workflows of their own; every other case follows the
+// default one.
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"lexiflow/backend/internal/models"
+)
+
+var (
+	ErrUnknownStatus = errors.New("workflow: unknown status")
+	ErrTransition    = errors.New("workflow: transition not allowed")
+	ErrRole          = errors.New("workflow: role may not make this transition")
+)
+
+// maxStatusLength matches the size of the case status column.
+const maxStatusLength = 32
+
+// Transition lets accounts with one of Roles move a case from one status to
+// another.
+type Transition struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Roles []string `json:"roles"`
+}
+
+// Workflow lists the statuses of a lifecycle, the first being the one new
+// cases start in, and the transitions between them.
+type Workflow struct {
+	Statuses    []string     `json:"statuses"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Set is the default workflow and the workflows of specific matter types,
+// keyed by lower-case matter type.
+type Set struct {
+	Default     Workflow
+	MatterTypes map[string]Workflow
+}
+
+// accountRoles are the roles transitions may name: the accounts that work on
+// cases.
+var accountRoles = []string{models.UserRoleClient, models.UserRoleLawyer}
+
+// Default is the built-in lifecycle: Draft, Intake, Active, On Hold, Closed
+// and Archived. Only lawyers close or reopen a case; clients may also
+// archive a draft they abandon.
+func Default() Workflow {
+	both, lawyers := accountRoles, []string{models.UserRoleLawyer}
+	return Workflow{
+		Statuses: []string{
+			models.CaseStatusDraft, models.CaseStatusIntake, models.CaseStatusActive,
+			models.CaseStatusOnHold, models.CaseStatusClosed, models.CaseStatusArchived,
+		},
+		Transitions: []Transition{
+			{models.CaseStatusDraft, models.CaseStatusIntake, both},
+			{models.CaseStatusDraft, models.CaseStatusArchived, []string{models.UserRoleClient}},
+			{models.CaseStatusIntake, models.CaseStatusDraft, both},
+			{models.CaseStatusIntake, models.CaseStatusActive, both},
+			{models.CaseStatusActive, models.CaseStatusOnHold, both},
+			{models.CaseStatusActive, models.CaseStatusClosed, lawyers},
+			{models.CaseStatusOnHold, models.CaseStatusActive, both},
+			{models.CaseStatusOnHold, models.CaseStatusClosed, lawyers},
+			{models.CaseStatusClosed, models.CaseStatusActive, lawyers},
+			{models.CaseStatusClosed, models.CaseStatusArchived, both},
+			{models.CaseStatusArchived, models.CaseStatusClosed, both},
+		},
+	}
+}
+
+// Load reads workflows from the JSON file at path: an optional "default"
+// workflow replacing the built-in one and "matterTypes" mapping matter types
+// to their own. An empty path yields the built-in workflow alone.
+func Load(path string) (Set, error) {
+	set := Set{Default: Default(), MatterTypes: map[string]Workflow{}}
+	if path == "" {
+		return set, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Set{}, err
+	}
+	defer file.Close()
+
+	var raw struct {
+		Default     *Workflow           `json:"default"`
+		MatterTypes map[string]Workflow `json:"matterTypes"`
+	}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return Set{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if raw.Default != nil {
+		if err := raw.Default.validate(); err != nil {
+			return Set{}, fmt.Errorf("default workflow: %w", err)
+		}
+		set.Default = *raw.Default
+	}
+	for matterType, workflow := range raw.MatterTypes {
+		key := strings.ToLower(strings.TrimSpace(matterType))
+		if key == "" {
+			return Set{}, errors.New("matter type workflows need a matter type")
+		}
+		if err := workflow.validate(); err != nil {
+			return Set{}, fmt.Errorf("workflow for %q: %w", matterType, err)
+		}
+		set.MatterTypes[key] = workflow
+	}
+	return set, nil
+}
+
+// For returns the workflow cases of matterType follow.
+func (s Set) For(matterType string) Workflow {
+	if workflow, ok := s.MatterTypes[strings.ToLower(strings.TrimSpace(matterType))]; ok {
+		return workflow
+	}
+	return s.Default
+}
+
+// Initial is the status new cases start in.
+func (w Workflow) Initial() string {
+	return w.Statuses[0]
+}
+
+// Status returns the workflow's spelling of name, matched
+// case-insensitively.
+func (w Workflow) Status(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, status := range w.Statuses {
+		if strings.EqualFold(status, name) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// Check reports whether an account with role may move a case from one status
+// to another. A case whose status is not part of the workflow, such as one
+// predating it or whose matter type changed, moves as if it were in the
+// initial status, and may also be put in the initial status itself.
+func (w Workflow) Check(from, to, role string) error {
+	to, ok := w.Status(to)
+	if !ok {
+		return ErrUnknownStatus
+	}
+	from, known := w.Status(from)
+	if !known {
+		if to == w.Initial() {
+			return nil
+		}
+		from = w.Initial()
+	}
+
+	for _, transition := range w.Transitions {
+		if transition.From != from || transition.To != to {
+			continue
+		}
+		if slices.Contains(transition.Roles, role) {
+			return nil
+		}
+		return ErrRole
+	}
+	return ErrTransition
+}
+
+// Next lists the statuses an account with role may move a case to from
+// status, in workflow order.
+func (w Workflow) Next(status, role string) []string {
+	next := []string{}
+	for _, candidate := range w.Statuses {
+		if candidate != status && w.Check(status, candidate, role) == nil {
+			next = append(next, candidate)
+		}
+	}
+	return next
+}
+
+func (w Workflow) validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("no statuses")
+	}
+	seen := map[string]bool{}
+	for _, status := range w.Statuses {
+		key := strings.ToLower(status)
+		if strings.TrimSpace(status) != status || status == "" || len(status) > maxStatusLength {
+			return fmt.Errorf("invalid status %q", status)
+		}
+		if seen[key] {
+			return fmt.Errorf("status %q listed twice", status)
+		}
+		seen[key] = true
+	}
+	pairs := map[[2]string]bool{}
+	for _, transition := range w.Transitions {
+		if !slices.Contains(w.Statuses, transition.From) || !slices.Contains(w.Statuses, transition.To) {
+			return fmt.Errorf("transition %q to %q uses an unlisted status", transition.From, transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("transition from %q to itself", transition.From)
+		}
+		pair := [2]string{transition.From, transition.To}
+		if pairs[pair] {
+			return fmt.Errorf("transition %q to %q listed twice", transition.From, transition.To)
+		}
+		pairs[pair] = true
+		if len(transition.Roles) == 0 {
+			return fmt.Errorf("transition %q to %q allows no roles", transition.From, transition.To)
+		}
+		for _, role := range transition.Roles {
+			if !slices.Contains(accountRoles, role) {
+				return fmt.Errorf("transition %q to %q names unknown role %q", transition.From, transition.To, role)
+			}
+		}
+	}
+	return nil
+}
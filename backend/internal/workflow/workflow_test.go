@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lexiflow/backend/internal/models"
+)
+
+func TestCheck(t *testing.T) {
+	flow := Default()
+	cases := []struct {
+		from, to, role string
+		want           error
+	}{
+		{models.CaseStatusDraft, models.CaseStatusIntake, models.UserRoleClient, nil},
+		{models.CaseStatusDraft, "intake", models.UserRoleLawyer, nil},
+		{models.CaseStatusDraft, models.CaseStatusArchived, models.UserRoleClient, nil},
+		{models.CaseStatusDraft, models.CaseStatusArchived, models.UserRoleLawyer, ErrRole},
+		{models.CaseStatusActive, models.CaseStatusClosed, models.UserRoleLawyer, nil},
+		{models.CaseStatusActive, models.CaseStatusClosed, models.UserRoleClient, ErrRole},
+		{models.CaseStatusClosed, models.CaseStatusActive, models.UserRoleClient, ErrRole},
+		{models.CaseStatusDraft, models.CaseStatusClosed, models.UserRoleLawyer, ErrTransition},
+		{models.CaseStatusActive, "Settled", models.UserRoleLawyer, ErrUnknownStatus},
+		// An unknown status behaves as the initial one and may be reset to it.
+		{"Legacy", models.CaseStatusDraft, models.UserRoleClient, nil},
+		{"Legacy", models.CaseStatusIntake, models.UserRoleClient, nil},
+		{"Legacy", models.CaseStatusArchived, models.UserRoleLawyer, ErrRole},
+		{"Legacy", models.CaseStatusActive, models.UserRoleLawyer, ErrTransition},
+	}
+	for _, tc := range cases {
+		if err := flow.Check(tc.from, tc.to, tc.role); !errors.Is(err, tc.want) {
+			t.Errorf("Check(%q, %q, %q) = %v, want %v", tc.from, tc.to, tc.role, err, tc.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	flow := Default()
+	cases := []struct {
+		status, role string
+		want         []string
+	}{
+		{models.CaseStatusDraft, models.UserRoleClient, []string{models.CaseStatusIntake, models.CaseStatusArchived}},
+		{models.CaseStatusDraft, models.UserRoleLawyer, []string{models.CaseStatusIntake}},
+		{models.CaseStatusActive, models.UserRoleClient, []string{models.CaseStatusOnHold}},
+		{models.CaseStatusActive, models.UserRoleLawyer, []string{models.CaseStatusOnHold, models.CaseStatusClosed}},
+		{"Legacy", models.UserRoleLawyer, []string{models.CaseStatusDraft, models.CaseStatusIntake}},
+		{models.CaseStatusArchived, models.UserRoleClient, []string{models.CaseStatusClosed}},
+	}
+	for _, tc := range cases {
+		if got := flow.Next(tc.status, tc.role); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Next(%q, %q) = %v, want %v", tc.status, tc.role, got, tc.want)
+		}
+	}
+}
+
+func writeWorkflows(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "workflows.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	set, err := Load(writeWorkflows(t, `{
+		"matterTypes": {
+			" Litigation ": {
+				"statuses": ["Filed", "Discovery", "Trial"],
+				"transitions": [
+					{"from": "Filed", "to": "Discovery", "roles": ["lawyer"]},
+					{"from": "Discovery", "to": "Trial", "roles": ["lawyer", "client"]}
+				]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := set.For("LITIGATION").Initial(); got != "Filed" {
+		t.Errorf("litigation starts in %q", got)
+	}
+	if got := set.For("employment").Initial(); got != models.CaseStatusDraft {
+		t.Errorf("other matter types start in %q, want the built-in workflow", got)
+	}
+	if err := set.For("litigation").Check("Filed", "Discovery", models.UserRoleClient); !errors.Is(err, ErrRole) {
+		t.Errorf("client moving a litigation case: %v", err)
+	}
+
+	empty, err := Load("")
+	if err != nil || !reflect.DeepEqual(empty.Default, Default()) || len(empty.MatterTypes) != 0 {
+		t.Errorf("Load(\"\") = %+v, %v", empty, err)
+	}
+}
+
+func TestLoadRejectsInvalidWorkflows(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		want    string
+	}{
+		"malformed JSON":      {`{"default": `, "parsing"},
+		"unknown field":       {`{"defaults": {}}`, "unknown field"},
+		"no statuses":         {`{"default": {"statuses": []}}`, "no statuses"},
+		"duplicate status":    {`{"default": {"statuses": ["Open", "open"]}}`, "listed twice"},
+		"padded status":       {`{"default": {"statuses": [" Open"]}}`, "invalid status"},
+		"overlong status":     {`{"default": {"statuses": ["` + strings.Repeat("x", maxStatusLength+1) + `"]}}`, "invalid status"},
+		"unlisted status":     {`{"default": {"statuses": ["Open"], "transitions": [{"from": "Open", "to": "Closed", "roles": ["lawyer"]}]}}`, "unlisted status"},
+		"self transition":     {`{"default": {"statuses": ["Open"], "transitions": [{"from": "Open", "to": "Open", "roles": ["lawyer"]}]}}`, "to itself"},
+		"no roles":            {`{"default": {"statuses": ["Open", "Closed"], "transitions": [{"from": "Open", "to": "Closed", "roles": []}]}}`, "allows no roles"},
+		"admin role":          {`{"default": {"statuses": ["Open", "Closed"], "transitions": [{"from": "Open", "to": "Closed", "roles": ["admin"]}]}}`, "unknown role"},
+		"blank matter type":   {`{"matterTypes": {" ": {"statuses": ["Open"]}}}`, "need a matter type"},
+		"invalid matter type": {`{"matterTypes": {"tax": {"statuses": []}}}`, `workflow for "tax"`},
+		"duplicate transition": {`{"default": {"statuses": ["Open", "Closed"], "transitions": [
+			{"from": "Open", "to": "Closed", "roles": ["lawyer"]},
+			{"from": "Open", "to": "Closed", "roles": ["client"]}]}}`, "listed twice"},
+	}
+	for name, tc := range cases {
+		_, err := Load(writeWorkflows(t, tc.content))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, want an error mentioning %q", name, err, tc.want)
+		}
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: got %v", err)
+	}
+}
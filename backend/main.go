@@ -8,6 +8,7 @@ import (
 	"lexiflow/backend/internal/config"
 	"lexiflow/backend/internal/database"
 	httpServer "lexiflow/backend/internal/http"
+	"lexiflow/backend/internal/mailer"
 )
 
 func main() {
@@ -17,7 +18,12 @@ func main() {
 	}
 	db := database.Connect(cfg.DatabaseURL)
 
-	srv := httpServer.NewServer(cfg, db)
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("unable to configure mailer: %v", err)
+	}
+
+	srv := httpServer.NewServer(cfg, db, mail)
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	if err := srv.Run(addr); err != nil {
@@ -3,14 +3,16 @@ import { useForm } from "react-hook-form";
 import { zodResolver } from "@hookform/resolvers/zod";
 import { z } from "zod";
 import { useAuth } from "../../state/authContext.jsx";
+import { MFAChallengeForm } from "./MFAChallengeForm.jsx";
 
 const loginSchema = z.object({
   email: z.string().email(),
-  password: z.string().min(6, "Password must be at least 6 characters")
+  password: z.string().min(6, "Password must be at least 6 characters"),
+  rememberMe: z.boolean().optional()
 });
 
 export const LoginForm = () => {
-  const { login, isLoading, error } = useAuth();
+  const { login, mfaChallenge, recoveryCodes, isLoading, error } = useAuth();
   const [demoLoading, setDemoLoading] = useState(false);
   const [accountType, setAccountType] = useState("client");
 
@@ -38,7 +40,8 @@ export const LoginForm = () => {
     resolver: zodResolver(loginSchema),
     defaultValues: {
       email: "",
-      password: ""
+      password: "",
+      rememberMe: true
     }
   });
 
@@ -56,6 +59,10 @@ export const LoginForm = () => {
     }
   };
 
+  if (mfaChallenge || recoveryCodes) {
+    return <MFAChallengeForm />;
+  }
+
   return (
     <form className="auth-form" onSubmit={handleSubmit(onSubmit)}>
       <div className="account-switcher" role="tablist" aria-label="Select account type">
@@ -88,7 +95,7 @@ export const LoginForm = () => {
       </label>
       <div className="auth-row">
         <label className="checkbox">
-          <input type="checkbox" defaultChecked /> Remember me
+          <input type="checkbox" {...register("rememberMe")} /> Remember me
         </label>
         <a href="#">Forgot password?</a>
       </div>
@@ -0,0 +1,145 @@
+import { useEffect, useState } from "react";
+import { useForm } from "react-hook-form";
+import { zodResolver } from "@hookform/resolvers/zod";
+import { z } from "zod";
+import { useAuth } from "../../state/authContext.jsx";
+
+const codeSchema = z.object({
+  code: z.string().trim().regex(/^\d{6}$/, "Enter the 6-digit code from your authenticator app")
+});
+
+const recoverySchema = z.object({
+  code: z.string().trim().min(8, "Enter one of your recovery codes")
+});
+
+export const MFAChallengeForm = () => {
+  const {
+    mfaChallenge,
+    recoveryCodes,
+    startMFAEnrollment,
+    completeMFALogin,
+    acknowledgeRecoveryCodes,
+    cancelMFA,
+    isLoading,
+    error
+  } = useAuth();
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const [enrollment, setEnrollment] = useState(null);
+
+  const enrollmentRequired = Boolean(mfaChallenge?.enrollmentRequired);
+  const canUseRecoveryCode = Boolean(mfaChallenge?.methods?.includes("recovery_code"));
+  const canUseCode = Boolean(mfaChallenge?.methods?.includes("totp"));
+
+  useEffect(() => {
+    if (!enrollmentRequired || enrollment) return;
+    startMFAEnrollment()
+      .then(setEnrollment)
+      .catch(() => {});
+  }, [enrollmentRequired, enrollment, startMFAEnrollment]);
+
+  const {
+    handleSubmit,
+    register,
+    reset,
+    formState: { errors }
+  } = useForm({
+    resolver: zodResolver(useRecoveryCode ? recoverySchema : codeSchema),
+    defaultValues: { code: "" }
+  });
+
+  const onSubmit = async ({ code }) => {
+    try {
+      await completeMFALogin(useRecoveryCode ? { recoveryCode: code } : { code });
+    } catch {
+      reset({ code: "" });
+    }
+  };
+
+  if (recoveryCodes) {
+    return (
+      <div className="auth-form">
+        <p className="muted">
+          Two-factor authentication is on. Store these recovery codes somewhere safe: each one signs you in once if you
+          lose your authenticator, and they will not be shown again.
+        </p>
+        <ul className="recovery-codes">
+          {recoveryCodes.map((recoveryCode) => (
+            <li key={recoveryCode}>
+              <code>{recoveryCode}</code>
+            </li>
+          ))}
+        </ul>
+        <button className="btn btn-primary" type="button" onClick={acknowledgeRecoveryCodes}>
+          I have saved my recovery codes
+        </button>
+      </div>
+    );
+  }
+
+  if (!canUseCode && !canUseRecoveryCode) {
+    return (
+      <div className="auth-form">
+        <p className="muted">This account signs in with a passkey. Use a browser that supports passkeys to continue.</p>
+        <button className="btn btn-secondary" type="button" onClick={cancelMFA}>
+          Back to sign in
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <form className="auth-form" onSubmit={handleSubmit(onSubmit)}>
+      {enrollmentRequired ? (
+        <>
+          <p className="muted">
+            Your organization requires two-factor authentication. Add this account to your authenticator app, then
+            enter the code it shows.
+          </p>
+          {enrollment ? (
+            <label className="form-label">
+              Setup key
+              <code className="mfa-secret">{enrollment.secret}</code>
+              <a href={enrollment.otpauthUrl}>Open in authenticator app</a>
+            </label>
+          ) : null}
+        </>
+      ) : (
+        <p className="muted">
+          {useRecoveryCode
+            ? "Enter one of the recovery codes you saved when you turned on two-factor authentication."
+            : "Enter the 6-digit code from your authenticator app to finish signing in."}
+        </p>
+      )}
+      <label className="form-label">
+        {useRecoveryCode ? "Recovery code" : "Authentication code"}
+        <input
+          className="input"
+          autoComplete="one-time-code"
+          inputMode={useRecoveryCode ? "text" : "numeric"}
+          placeholder={useRecoveryCode ? "xxxxx-xxxxx" : "123456"}
+          {...register("code")}
+        />
+        {errors.code ? <span className="error-text">{errors.code.message}</span> : null}
+      </label>
+      <button className="btn btn-primary" type="submit" disabled={isLoading || (enrollmentRequired && !enrollment)}>
+        {isLoading ? "Verifying..." : "Verify and sign in"}
+      </button>
+      {canUseRecoveryCode && canUseCode ? (
+        <button
+          className="btn btn-secondary"
+          type="button"
+          onClick={() => {
+            setUseRecoveryCode((current) => !current);
+            reset({ code: "" });
+          }}
+        >
+          {useRecoveryCode ? "Use an authentication code instead" : "Use a recovery code instead"}
+        </button>
+      ) : null}
+      <button className="btn btn-secondary" type="button" onClick={cancelMFA}>
+        Back to sign in
+      </button>
+      {error ? <p className="error-text">{error}</p> : null}
+    </form>
+  );
+};
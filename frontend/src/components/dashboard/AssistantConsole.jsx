@@ -47,7 +47,7 @@ export const AssistantConsole = () => {
                   <div>
                     <p>{lawyer.name}</p>
                     <small className="muted">
-                      {lawyer.expertise} · {lawyer.experience} · {lawyer.location}
+                      {[lawyer.expertise, lawyer.experience, lawyer.location].filter(Boolean).join(" · ")}
                     </small>
                   </div>
                   <button className="btn btn-secondary">Book</button>
@@ -20,7 +20,7 @@ export const AttorneySpotlight = () => {
             <article key={attorney.id} className="attorney-card">
               <h4 style={{ margin: 0 }}>{attorney.name}</h4>
               <p className="attorney-card__meta">
-                {attorney.expertise} · {attorney.experience} · {attorney.location}
+                {[attorney.expertise, attorney.experience, attorney.location].filter(Boolean).join(" · ")}
               </p>
               <footer>
                 <button className="btn btn-secondary" type="button">
@@ -35,7 +35,7 @@ export const AttorneySpotlight = () => {
         </div>
       ) : (
         <p className="muted" style={{ margin: 0 }}>
-          No recommendations yet. Share more context or upload a document to surface attorneys.
+          No recommendations yet. Select a case and add its matter type and jurisdictions to surface attorneys.
         </p>
       )}
     </section>
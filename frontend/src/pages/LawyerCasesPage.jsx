@@ -20,6 +20,11 @@ const formatDate = (value) => {
   }
 };
 
+// pickSummary keeps a list entry in step with a freshly loaded case without
+// pulling the case's documents and other detail into the list.
+const pickSummary = (caseData, summary) =>
+  Object.fromEntries(Object.keys(summary).map((key) => [key, caseData[key] ?? summary[key]]));
+
 const emptyState = (
   <div className="case-manager__empty">
     <h3>No matters assigned yet</h3>
@@ -29,14 +34,18 @@ const emptyState = (
 
 const LawyerCasesContent = ({
   cases,
+  total,
+  hasMore,
+  onLoadMore,
+  isLoadingMore,
   activeCaseId,
+  activeCase,
+  isLoadingCase,
   onSelectCase,
   onRefreshCase,
   isRefreshing,
   currentLawyerId
 }) => {
-  const activeCase = useMemo(() => cases.find((item) => item.id === activeCaseId) ?? null, [cases, activeCaseId]);
-
   const groupedDocuments = useMemo(() => {
     if (!activeCase?.documents?.length) return [];
     const groups = activeCase.documents.reduce((acc, doc) => {
@@ -88,7 +97,7 @@ const LawyerCasesContent = ({
         </div>
         <div className="case-manager__actions">
           <span className="pill">
-            {cases.length} {cases.length === 1 ? "matter" : "matters"}
+            {total} {total === 1 ? "matter" : "matters"}
           </span>
           <button type="button" className="btn btn-secondary" onClick={onRefreshCase} disabled={!activeCase || isRefreshing}>
             {isRefreshing ? "Refreshing..." : "Refresh case"}
@@ -102,7 +111,7 @@ const LawyerCasesContent = ({
         <div className="case-manager__body">
           <aside className="case-manager__list">
             {cases.map((caseItem) => {
-              const isActive = caseItem.id === activeCase?.id;
+              const isActive = caseItem.id === activeCaseId;
               return (
                 <button
                   key={caseItem.id}
@@ -117,6 +126,11 @@ const LawyerCasesContent = ({
                 </button>
               );
             })}
+            {hasMore ? (
+              <button type="button" className="btn btn-secondary" onClick={onLoadMore} disabled={isLoadingMore}>
+                {isLoadingMore ? "Loading..." : "Load more matters"}
+              </button>
+            ) : null}
           </aside>
 
           {activeCase ? (
@@ -233,7 +247,7 @@ const LawyerCasesContent = ({
             </div>
           ) : (
             <div className="case-manager__placeholder">
-              <p className="muted">Select a matter to load its details.</p>
+              <p className="muted">{isLoadingCase ? "Loading matter..." : "Select a matter to load its details."}</p>
             </div>
           )}
         </div>
@@ -246,8 +260,13 @@ const LawyerCasesPage = () => {
   const { user, isAuthenticated, status } = useAuth();
   const navigate = useNavigate();
   const [cases, setCases] = useState([]);
+  const [total, setTotal] = useState(0);
+  const [nextCursor, setNextCursor] = useState(null);
   const [activeCaseId, setActiveCaseId] = useState(null);
+  const [activeCase, setActiveCase] = useState(null);
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
+  const [loadingCase, setLoadingCase] = useState(false);
   const [error, setError] = useState("");
   const [refreshing, setRefreshing] = useState(false);
 
@@ -262,10 +281,12 @@ const LawyerCasesPage = () => {
     setLoading(true);
     setError("");
     try {
-      const data = await listCases();
-      setCases(data);
-      if (data.length) {
-        setActiveCaseId((current) => current ?? data[0].id);
+      const page = await listCases();
+      setCases(page.cases);
+      setTotal(page.total);
+      setNextCursor(page.nextCursor);
+      if (page.cases.length) {
+        setActiveCaseId((current) => current ?? page.cases[0].id);
       } else {
         setActiveCaseId(null);
       }
@@ -276,32 +297,60 @@ const LawyerCasesPage = () => {
     }
   }, []);
 
+  const fetchMoreCases = useCallback(async () => {
+    if (!nextCursor) return;
+    setLoadingMore(true);
+    setError("");
+    try {
+      const page = await listCases({ cursor: nextCursor });
+      setCases((prev) => prev.concat(page.cases.filter((item) => !prev.some((existing) => existing.id === item.id))));
+      setTotal(page.total);
+      setNextCursor(page.nextCursor);
+    } catch (err) {
+      setError(err.message ?? "Unable to fetch matters");
+    } finally {
+      setLoadingMore(false);
+    }
+  }, [nextCursor]);
+
   useEffect(() => {
     if (user?.role === "lawyer") {
       fetchCases();
     }
   }, [user, fetchCases]);
 
+  const loadActiveCase = useCallback(async (caseId) => {
+    const caseData = await getCase(caseId);
+    setActiveCase(caseData);
+    if (caseData) {
+      setCases((prev) =>
+        prev.map((item) => (item.id === caseData.id ? { ...item, ...pickSummary(caseData, item) } : item))
+      );
+    }
+  }, []);
+
+  useEffect(() => {
+    setActiveCase(null);
+    if (!activeCaseId) return;
+    setLoadingCase(true);
+    setError("");
+    loadActiveCase(activeCaseId)
+      .catch((err) => setError(err.message ?? "Unable to load matter"))
+      .finally(() => setLoadingCase(false));
+  }, [activeCaseId, loadActiveCase]);
+
   const handleRefreshCase = useCallback(async () => {
     if (!activeCaseId) return;
     setRefreshing(true);
     setError("");
     try {
-      const caseData = await getCase(activeCaseId);
-      if (!caseData) return;
-      setCases((prev) => {
-        const exists = prev.some((item) => item.id === caseData.id);
-        if (exists) {
-          return prev.map((item) => (item.id === caseData.id ? caseData : item));
-        }
-        return [caseData, ...prev];
-      });
+      await loadActiveCase(activeCaseId);
     } catch (err) {
       setError(err.message ?? "Failed to refresh matter");
     } finally {
       setRefreshing(false);
     }
-  }, [activeCaseId]);
+  }, [activeCaseId, loadActiveCase]);
 
   return (
     <AppShell>
@@ -312,7 +361,7 @@ const LawyerCasesPage = () => {
             <p className="muted">Review every matter assigned to you, including client documents and AI-generated context.</p>
             <div className="usage-badges">
               <span className="usage-badge">Workspace: {user?.companyName ?? user?.email}</span>
-              <span className="usage-badge">Matters: {cases.length}</span>
+              <span className="usage-badge">Matters: {total}</span>
             </div>
           </div>
           <div className="workspace-plan">
@@ -333,7 +382,13 @@ const LawyerCasesPage = () => {
         ) : (
           <LawyerCasesContent
             cases={cases}
+            total={total}
+            hasMore={Boolean(nextCursor)}
+            onLoadMore={fetchMoreCases}
+            isLoadingMore={loadingMore}
             activeCaseId={activeCaseId}
+            activeCase={activeCase}
+            isLoadingCase={loadingCase}
             onSelectCase={setActiveCaseId}
             onRefreshCase={handleRefreshCase}
             isRefreshing={refreshing}
@@ -1,6 +1,7 @@
 import { format } from "date-fns";
 import { v4 as uuid } from "uuid";
 import { derivePaperwork } from "./paperworkService.js";
+import { getRecommendedLawyers } from "./caseService.js";
 
 const regulatoryMap = [
   { keyword: "gdpr", citation: "GDPR Art. 44 – Cross-border data transfer" },
@@ -10,12 +11,25 @@ const regulatoryMap = [
   { keyword: "hipaa", citation: "HIPAA Security Rule 45 CFR Part 164" }
 ];
 
-const lawyerPool = [
-  { id: "km", name: "Karen Meng", expertise: "Data compliance", experience: "8 yrs", location: "Shanghai" },
-  { id: "ac", name: "Avery Cole", expertise: "Employment & labor", experience: "11 yrs", location: "New York" },
-  { id: "rl", name: "Riya Lal", expertise: "FinTech licensing", experience: "6 yrs", location: "Singapore" },
-  { id: "jp", name: "Javier Prado", expertise: "LATAM privacy", experience: "9 yrs", location: "São Paulo" }
-];
+const RECOMMENDATION_LIMIT = 2;
+
+const toRecommendation = ({ lawyer, score, factors }) => ({
+  id: lawyer.id,
+  name: lawyer.companyName,
+  expertise: lawyer.practiceAreas?.length ? lawyer.practiceAreas.join(", ") : lawyer.headline,
+  experience: `${lawyer.yearsExperience} yrs`,
+  location: lawyer.jurisdictions?.[0] ?? "",
+  score,
+  factors
+});
+
+// Lawyers are ranked by the backend for the case the conversation is about.
+// Without a case there is nothing to match against.
+export const recommendLawyers = async (caseId) => {
+  if (!caseId) return [];
+  const recommendations = await getRecommendedLawyers(caseId, { limit: RECOMMENDATION_LIMIT });
+  return recommendations.map(toRecommendation);
+};
 
 const tagsFor = (message) =>
   regulatoryMap
@@ -24,7 +38,7 @@ const tagsFor = (message) =>
 
 const findCitation = (message) => regulatoryMap.find(({ keyword }) => message.toLowerCase().includes(keyword));
 
-export const converse = async ({ message, thread }) => {
+export const converse = async ({ message, thread, caseId }) => {
   const id = uuid();
   const createdAt = new Date();
 
@@ -72,13 +86,9 @@ export const converse = async ({ message, thread }) => {
     tags
   };
 
-  const recommendations = lawyerPool
-    .filter((lawyer) => {
-      if (tags.includes("#GDPR") || tags.includes("#PIPL")) return ["km", "rl"].includes(lawyer.id);
-      if (tags.includes("#EMPLOYMENT")) return lawyer.id === "ac";
-      return true;
-    })
-    .slice(0, 2);
+  // Recommendations are only offered to those who manage access to the
+  // case; the reply stands on its own without them.
+  const recommendations = await recommendLawyers(caseId).catch(() => []);
 
   const updatedSummary = summarise(thread.concat([{ role: "user", content: message }, aiMessage]));
 
@@ -107,5 +117,3 @@ const summarise = (timeline) => {
     ]
   };
 };
-
-export const getLawyerPool = () => lawyerPool;
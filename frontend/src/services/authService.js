@@ -2,6 +2,20 @@ const SESSION_KEY = "lexiflow:session";
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || "http://localhost:8080/api/v1";
 
+const CSRF_COOKIE = "lexiflow_csrf";
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS"];
+
+const readCookie = (name) => {
+  if (typeof document === "undefined") return null;
+  const entry = document.cookie.split("; ").find((cookie) => cookie.startsWith(`${name}=`));
+  return entry ? decodeURIComponent(entry.slice(name.length + 1)) : null;
+};
+
+// Cookie-authenticated writes must echo the CSRF token. The cookie is only
+// readable when the API shares the app's host, so the token returned at login
+// is kept with the session as a fallback.
+const csrfToken = () => readCookie(CSRF_COOKIE) ?? readSession()?.csrfToken ?? null;
+
 const request = async (path, options = {}) => {
   const session = readSession();
   const headers = {
@@ -11,11 +25,17 @@ const request = async (path, options = {}) => {
   if (session?.sessionToken) {
     headers.Authorization = `Bearer ${session.sessionToken}`;
   }
+  if (!SAFE_METHODS.includes((options.method ?? "GET").toUpperCase())) {
+    const token = csrfToken();
+    if (token) {
+      headers["X-CSRF-Token"] = token;
+    }
+  }
 
   const response = await fetch(`${API_BASE_URL}${path}`, {
-    headers,
     credentials: "include",
-    ...options
+    ...options,
+    headers
   });
 
   if (!response.ok) {
@@ -58,7 +78,7 @@ export const clearSession = () => {
   window.localStorage.removeItem(SESSION_KEY);
 };
 
-const normaliseUser = (payload) => {
+const normaliseUser = (payload, csrf) => {
   if (!payload) return null;
   const stored = readSession();
   const sessionToken = payload.sessionToken ?? stored?.sessionToken ?? null;
@@ -70,7 +90,8 @@ const normaliseUser = (payload) => {
     subscription: payload.subscription ?? "starter",
     role: payload.role ?? stored?.role ?? "client",
     createdAt: payload.createdAt,
-    sessionToken
+    sessionToken,
+    csrfToken: csrf ?? stored?.csrfToken ?? null
   };
 };
 
@@ -86,16 +107,46 @@ export const register = async ({ companyName, email, password, plan, role }) =>
 
 export const apiRequest = request;
 
-export const login = async ({ email, password }) => {
-  const { user } = await request("/auth/login", {
+// login resolves to the signed-in user, or to the pending challenge when the
+// account needs a second factor; finish it with completeMFALogin.
+export const login = async ({ email, password, rememberMe }) => {
+  const data = await request("/auth/login", {
     method: "POST",
-    body: JSON.stringify({ email, password })
+    body: JSON.stringify({ email, password, rememberMe })
   });
-  const normalised = normaliseUser(user);
+  if (data?.mfaRequired) {
+    return {
+      mfaRequired: true,
+      challengeToken: data.challengeToken,
+      enrollmentRequired: Boolean(data.enrollmentRequired),
+      methods: data.methods ?? []
+    };
+  }
+  const normalised = normaliseUser(data.user, data.csrfToken);
   persistSession(normalised);
   return normalised;
 };
 
+// startMFAEnrollment registers an authenticator for a login whose challenge
+// requires enrollment, returning the secret and otpauth URI to show.
+export const startMFAEnrollment = async (challengeToken) => {
+  const data = await request("/auth/login/mfa/setup", {
+    method: "POST",
+    body: JSON.stringify({ challengeToken })
+  });
+  return { secret: data.secret, otpauthUrl: data.otpauthUrl };
+};
+
+export const completeMFALogin = async ({ challengeToken, code, recoveryCode }) => {
+  const data = await request("/auth/login/mfa", {
+    method: "POST",
+    body: JSON.stringify({ challengeToken, code, recoveryCode })
+  });
+  const normalised = normaliseUser(data.user, data.csrfToken);
+  persistSession(normalised);
+  return { user: normalised, recoveryCodes: data.recoveryCodes ?? [] };
+};
+
 export const verifyEmail = async ({ email, code }) => {
   const { user } = await request("/auth/verify-email", {
     method: "POST",
@@ -107,13 +158,11 @@ export const verifyEmail = async ({ email, code }) => {
 };
 
 export const updateSubscription = async ({ email, plan }) => {
-  const { user } = await request("/auth/subscription", {
+  const { user, csrfToken: csrf } = await request("/auth/subscription", {
     method: "POST",
     body: JSON.stringify({ email, plan })
   });
-  const normalised = normaliseUser(user);
+  const normalised = normaliseUser(user, csrf);
   persistSession(normalised);
   return normalised;
 };
-
-export const apiRequest = request;
@@ -1,10 +1,43 @@
 import { apiRequest } from "./authService.js";
 
-export const listCases = async () => {
-  const data = await apiRequest("/cases", {
+// listCases fetches one page of the cases the caller can see. Pages are
+// summaries by default; load a single case with getCase for its documents
+// and the rest of the detail. Pass the returned nextCursor back as cursor,
+// with the same filters, for the next page.
+export const listCases = async ({ view = "summary", ...params } = {}) => {
+  const query = new URLSearchParams();
+  Object.entries({ ...params, view }).forEach(([key, value]) => {
+    [value].flat().forEach((item) => {
+      if (item !== undefined && item !== null && item !== "") {
+        query.append(key, item);
+      }
+    });
+  });
+  const data = await apiRequest(`/cases?${query}`, {
+    method: "GET"
+  });
+  return {
+    cases: data?.cases ?? [],
+    nextCursor: data?.nextCursor ?? null,
+    total: data?.total ?? 0
+  };
+};
+
+export const searchCases = async (q, { type, limit, offset } = {}) => {
+  const query = new URLSearchParams({ q });
+  Object.entries({ type, limit, offset }).forEach(([key, value]) => {
+    if (value !== undefined && value !== null && value !== "") {
+      query.append(key, value);
+    }
+  });
+  const data = await apiRequest(`/cases/search?${query}`, {
     method: "GET"
   });
-  return data?.cases ?? [];
+  return {
+    cases: data?.cases ?? [],
+    documents: data?.documents ?? [],
+    totals: data?.totals ?? {}
+  };
 };
 
 export const getCase = async (caseId) => {
@@ -21,3 +54,32 @@ export const assignLawyer = async ({ caseId, lawyerId, notes }) => {
   });
   return data?.assignment ?? null;
 };
+
+export const getRecommendedLawyers = async (caseId, { limit } = {}) => {
+  const query = limit ? `?limit=${limit}` : "";
+  const data = await apiRequest(`/cases/${caseId}/recommended-lawyers${query}`, {
+    method: "GET"
+  });
+  return data?.recommendations ?? [];
+};
+
+export const updateCase = async (caseId, patch, version) => {
+  const data = await apiRequest(`/cases/${caseId}`, {
+    method: "PATCH",
+    headers: {
+      "Content-Type": "application/merge-patch+json",
+      "If-Match": `"${version}"`
+    },
+    body: JSON.stringify(patch)
+  });
+  return data?.case ?? null;
+};
+
+export const changeCaseStatus = async (caseId, status, reason, version) => {
+  const data = await apiRequest(`/cases/${caseId}/status`, {
+    method: "POST",
+    headers: { "If-Match": `"${version}"` },
+    body: JSON.stringify({ status, reason })
+  });
+  return data?.case ?? null;
+};
@@ -1,5 +1,5 @@
-import { createContext, useCallback, useContext, useMemo, useReducer } from "react";
-import { converse, getLawyerPool } from "../services/assistantService.js";
+import { createContext, useCallback, useContext, useEffect, useMemo, useReducer } from "react";
+import { converse, recommendLawyers } from "../services/assistantService.js";
 import { derivePaperwork } from "../services/paperworkService.js";
 import { uploadCaseDocument } from "../services/caseService.js";
 import { v4 as uuid } from "uuid";
@@ -21,7 +21,7 @@ const initialState = {
     risk: "Awaiting initial intake",
     nextSteps: ["Share your scenario", "Confirm jurisdictions involved", "Flag target launch timeline"]
   },
-  recommendations: getLawyerPool().slice(0, 2),
+  recommendations: [],
   processing: false,
   uploading: false,
   error: null,
@@ -75,6 +75,8 @@ const assistantReducer = (state, action) => {
         }
       };
     }
+    case "RECOMMENDATIONS":
+      return { ...state, recommendations: action.payload };
     case "UPLOAD_START":
       return { ...state, uploading: true, error: null };
     case "UPLOAD_SUCCESS": {
@@ -182,15 +184,31 @@ export const AssistantProvider = ({ children }) => {
     dispatch({ type: "SEND", payload: message });
 
     try {
-      const response = await converse({ message: trimmed, thread: state.messages.concat(message) });
+      const response = await converse({
+        message: trimmed,
+        thread: state.messages.concat(message),
+        caseId: state.activeCaseId
+      });
       dispatch({ type: "RESPONSE", payload: response });
     } catch (error) {
       dispatch({ type: "ERROR", payload: error.message });
     }
     },
-    [state.messages]
+    [state.messages, state.activeCaseId]
   );
 
+  useEffect(() => {
+    let cancelled = false;
+    recommendLawyers(state.activeCaseId)
+      .catch(() => [])
+      .then((recommendations) => {
+        if (!cancelled) dispatch({ type: "RECOMMENDATIONS", payload: recommendations });
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [state.activeCaseId]);
+
   const uploadDocument = useCallback(async (file) => {
     if (!file) return null;
     if (!state.activeCaseId) {
@@ -1,12 +1,27 @@
 import { createContext, useCallback, useContext, useEffect, useMemo, useReducer, useState } from "react";
-import { clearSession, login, readSession, register, updateSubscription, verifyEmail } from "../services/authService.js";
+import {
+  clearSession,
+  completeMFALogin,
+  login,
+  readSession,
+  register,
+  startMFAEnrollment,
+  updateSubscription,
+  verifyEmail
+} from "../services/authService.js";
 
 const AuthContext = createContext(null);
 
 const initialState = {
   user: null,
   status: "idle",
-  error: null
+  error: null,
+  // mfaChallenge holds a login waiting for its second factor; recoveryCodes
+  // and pendingUser hold a login that enrolled an authenticator until the
+  // new recovery codes have been shown.
+  mfaChallenge: null,
+  recoveryCodes: null,
+  pendingUser: null
 };
 
 const authReducer = (state, action) => {
@@ -14,24 +29,58 @@ const authReducer = (state, action) => {
     case "INIT":
       return { ...state, user: action.payload, status: "ready" };
     case "LOGIN_REQUEST":
+    case "MFA_REQUEST":
     case "REGISTER_REQUEST":
     case "VERIFY_REQUEST":
     case "PLAN_REQUEST":
       return { ...state, status: "loading", error: null };
+    case "MFA_REQUIRED":
+      return { ...state, mfaChallenge: action.payload, status: "ready", error: null };
+    case "MFA_RECOVERY_CODES":
+      return {
+        ...state,
+        mfaChallenge: null,
+        recoveryCodes: action.payload.recoveryCodes,
+        pendingUser: action.payload.user,
+        status: "ready",
+        error: null
+      };
     case "LOGIN_SUCCESS":
+      return {
+        ...state,
+        user: action.payload,
+        mfaChallenge: null,
+        recoveryCodes: null,
+        pendingUser: null,
+        status: "ready",
+        error: null
+      };
     case "REGISTER_SUCCESS":
     case "VERIFY_SUCCESS":
     case "PLAN_SUCCESS":
       return { ...state, user: action.payload, status: "ready", error: null };
     case "ERROR":
       return { ...state, status: "error", error: action.payload };
+    case "MFA_CANCEL":
+      return { ...state, mfaChallenge: null, status: "idle", error: null };
     case "LOGOUT":
-      return { ...state, user: null, status: "idle", error: null };
+      return { ...initialState };
     default:
       return state;
   }
 };
 
+const checkRole = (user, expectedRole) => {
+  if (expectedRole && user.role !== expectedRole) {
+    clearSession();
+    throw new Error(
+      expectedRole === "lawyer"
+        ? "This account is not registered as a lawyer workspace."
+        : "This account is not registered as a client workspace."
+    );
+  }
+};
+
 export const AuthProvider = ({ children }) => {
   const [state, dispatch] = useReducer(authReducer, initialState);
   const [initialised, setInitialised] = useState(false);
@@ -47,17 +96,14 @@ export const AuthProvider = ({ children }) => {
   const handleLogin = useCallback(async (credentials, expectedRole) => {
     dispatch({ type: "LOGIN_REQUEST" });
     try {
-      const user = await login(credentials);
-      if (expectedRole && user.role !== expectedRole) {
-        clearSession();
-        throw new Error(
-          expectedRole === "lawyer"
-            ? "This account is not registered as a lawyer workspace."
-            : "This account is not registered as a client workspace."
-        );
+      const result = await login(credentials);
+      if (result.mfaRequired) {
+        dispatch({ type: "MFA_REQUIRED", payload: { ...result, expectedRole } });
+        return result;
       }
-      dispatch({ type: "LOGIN_SUCCESS", payload: user });
-      return user;
+      checkRole(result, expectedRole);
+      dispatch({ type: "LOGIN_SUCCESS", payload: result });
+      return result;
     } catch (error) {
       const message = error?.message ?? "Unable to sign in";
       dispatch({ type: "ERROR", payload: message });
@@ -65,6 +111,51 @@ export const AuthProvider = ({ children }) => {
     }
   }, []);
 
+  const handleStartEnrollment = useCallback(async () => {
+    if (!state.mfaChallenge) return null;
+    try {
+      return await startMFAEnrollment(state.mfaChallenge.challengeToken);
+    } catch (error) {
+      dispatch({ type: "ERROR", payload: error.message });
+      throw error;
+    }
+  }, [state.mfaChallenge]);
+
+  const handleCompleteMFA = useCallback(
+    async ({ code, recoveryCode }) => {
+      if (!state.mfaChallenge) return null;
+      dispatch({ type: "MFA_REQUEST" });
+      try {
+        const { user, recoveryCodes } = await completeMFALogin({
+          challengeToken: state.mfaChallenge.challengeToken,
+          code,
+          recoveryCode
+        });
+        checkRole(user, state.mfaChallenge.expectedRole);
+        if (recoveryCodes.length > 0) {
+          dispatch({ type: "MFA_RECOVERY_CODES", payload: { user, recoveryCodes } });
+        } else {
+          dispatch({ type: "LOGIN_SUCCESS", payload: user });
+        }
+        return user;
+      } catch (error) {
+        const message = error?.message ?? "Unable to verify the code";
+        dispatch({ type: "ERROR", payload: message });
+        throw error;
+      }
+    },
+    [state.mfaChallenge]
+  );
+
+  const handleAcknowledgeRecoveryCodes = useCallback(() => {
+    if (!state.pendingUser) return;
+    dispatch({ type: "LOGIN_SUCCESS", payload: state.pendingUser });
+  }, [state.pendingUser]);
+
+  const handleCancelMFA = useCallback(() => {
+    dispatch({ type: "MFA_CANCEL" });
+  }, []);
+
   const handleRegister = useCallback(async (payload) => {
     dispatch({ type: "REGISTER_REQUEST" });
     try {
@@ -117,14 +208,32 @@ export const AuthProvider = ({ children }) => {
       error: state.error,
       isLoading: state.status === "loading",
       isAuthenticated: Boolean(state.user),
+      mfaChallenge: state.mfaChallenge,
+      recoveryCodes: state.recoveryCodes,
       login: handleLogin,
+      startMFAEnrollment: handleStartEnrollment,
+      completeMFALogin: handleCompleteMFA,
+      acknowledgeRecoveryCodes: handleAcknowledgeRecoveryCodes,
+      cancelMFA: handleCancelMFA,
       register: handleRegister,
       verifyEmail: handleVerify,
       changePlan: handlePlanChange,
       logout: handleLogout,
       initialised
     }),
-    [state, initialised, handleLogin, handleRegister, handleVerify, handlePlanChange, handleLogout]
+    [
+      state,
+      initialised,
+      handleLogin,
+      handleStartEnrollment,
+      handleCompleteMFA,
+      handleAcknowledgeRecoveryCodes,
+      handleCancelMFA,
+      handleRegister,
+      handleVerify,
+      handlePlanChange,
+      handleLogout
+    ]
   );
 
   return <AuthContext.Provider value={value}>{children}</AuthContext.Provider>;